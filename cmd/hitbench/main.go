@@ -0,0 +1,180 @@
+package main
+
+import (
+	"URLRotatorGo/infra/config"
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/infra/workerpool"
+	"URLRotatorGo/internal/adapter/storage/postgres"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/internal/core/services"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+type link struct {
+	id   int
+	code string
+}
+
+// Compares how fast hits reach postgres when every redirect writes them itself, with two
+// transactions like redirects used to, and when they are batched by the hit counter. The hits are
+// spread over existing destinations and subtracted again afterwards, still only run it against a
+// development database:
+//
+//	go run ./cmd/hitbench -links 100 -hits 20000 -workers 50
+//
+// The reproducible benchmarks are BenchmarkHitCounter and BenchmarkPerHitTx in internal/core/services,
+// which need no database, and BenchmarkAddHits in internal/adapter/storage/postgres, which only
+// writes to a table of its own.
+func main() {
+	cfg := config.InitConfig("config.json", "../../")
+	mylog := logger.NewLogger(cfg)
+	workerpool.IntializePool(cfg, mylog)
+
+	var links, hits, workers int
+	flag.IntVar(&links, "links", 100, "how many destinations the hits are spread over")
+	flag.IntVar(&hits, "hits", 20000, "how many hits every mode writes")
+	flag.IntVar(&workers, "workers", 50, "how many redirects run concurrently")
+	flag.Parse()
+	if links < 1 || hits < 1 || workers < 1 {
+		log.Fatal("-links, -hits and -workers must be at least 1")
+	}
+
+	ctx := context.Background()
+
+	var db *database.Postgres
+	var urlRepository ports.URLRepository
+	var shortcodeRepository ports.ShortCodeRepository
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(func() *viper.Viper { return cfg }),
+		fx.Provide(func() *zap.SugaredLogger { return mylog }),
+		fx.Provide(func() context.Context { return ctx }),
+		fx.Provide(database.NewPostgresConn),
+		fx.Provide(postgres.NewURLRepository),
+		fx.Provide(postgres.NewShortCodeRepository),
+		fx.Populate(&db, &urlRepository, &shortcodeRepository),
+	)
+
+	startCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := app.Start(startCtx); err != nil {
+		log.Fatal("failed to start: ", err.Error())
+	}
+	defer app.Stop(ctx)
+
+	rows, err := db.Pool.Query(ctx, "SELECT id, shortcode FROM urls ORDER BY id LIMIT $1", links)
+	if err != nil {
+		log.Fatal("failed to load destinations: ", err.Error())
+	}
+	targets, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (link, error) {
+		var target link
+		err := row.Scan(&target.id, &target.code)
+		return target, err
+	})
+	if err != nil || len(targets) == 0 {
+		log.Fatal("no destinations to count hits for, shorten some links first")
+	}
+
+	perHit := run(targets, hits, workers, func(target link) {
+		// what every redirect did before the hit counter: two transactions of one update each
+		for _, update := range []struct {
+			sql string
+			key any
+		}{
+			{"UPDATE urls SET total_hit = total_hit + 1, updated_at = $1 WHERE id = $2", target.id},
+			{"UPDATE shortcodes SET total_hit = total_hit + 1, updated_at = $1 WHERE code = $2", target.code},
+		} {
+			tx, err := db.Pool.Begin(ctx)
+			if err != nil {
+				log.Fatal("failed to start transaction: ", err.Error())
+			}
+			if _, err = tx.Exec(ctx, update.sql, time.Now(), update.key); err != nil {
+				log.Fatal("failed to count hit: ", err.Error())
+			}
+			if err = tx.Commit(ctx); err != nil {
+				log.Fatal("failed to commit transaction: ", err.Error())
+			}
+		}
+	}, nil)
+	undo(ctx, urlRepository, shortcodeRepository, targets, hits)
+
+	counter := services.NewHitCounter(urlRepository, shortcodeRepository)
+	if err = counter.Start(ctx); err != nil {
+		log.Fatal("failed to start hit counter: ", err.Error())
+	}
+	batched := run(targets, hits, workers, func(target link) {
+		counter.Record(target.code, target.id)
+	}, func() {
+		// the final flush is part of the measurement
+		if err := counter.Stop(ctx); err != nil {
+			log.Fatal("failed to flush hits: ", err.Error())
+		}
+	})
+	undo(ctx, urlRepository, shortcodeRepository, targets, hits)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "MODE\tHITS\tDURATION\tHITS/S")
+	for _, result := range []struct {
+		mode     string
+		duration time.Duration
+	}{{"per hit", perHit}, {"batched", batched}} {
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%.0f\n", result.mode, hits, result.duration.Round(time.Millisecond), float64(hits)/result.duration.Seconds())
+	}
+	writer.Flush()
+	fmt.Printf("batched is %.1fx faster\n", perHit.Seconds()/batched.Seconds())
+}
+
+// run counts the hits round robin over the targets from the given number of workers and returns
+// how long it took until finish returned.
+func run(targets []link, hits, workers int, hit func(link), finish func()) time.Duration {
+	var next atomic.Int64
+	var wg sync.WaitGroup
+
+	started := time.Now()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := next.Add(1) - 1; n < int64(hits); n = next.Add(1) - 1 {
+				hit(targets[n%int64(len(targets))])
+			}
+		}()
+	}
+	wg.Wait()
+	if finish != nil {
+		finish()
+	}
+
+	return time.Since(started)
+}
+
+// undo subtracts the hits a run added, with the same batched updates the hit counter uses.
+func undo(ctx context.Context, urlRepository ports.URLRepository, shortcodeRepository ports.ShortCodeRepository, targets []link, hits int) {
+	links := make(map[int]int64)
+	shortcodes := make(map[string]int64)
+	for n := 0; n < hits; n++ {
+		target := targets[n%len(targets)]
+		links[target.id]--
+		shortcodes[target.code]--
+	}
+
+	if err := urlRepository.AddHits(ctx, links); err != nil {
+		log.Fatal("failed to subtract hits: ", err.Error())
+	}
+	if err := shortcodeRepository.AddHits(ctx, shortcodes); err != nil {
+		log.Fatal("failed to subtract hits: ", err.Error())
+	}
+}
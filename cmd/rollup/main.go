@@ -0,0 +1,92 @@
+package main
+
+import (
+	"URLRotatorGo/infra/config"
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/infra/workerpool"
+	"URLRotatorGo/internal/adapter/storage/cache"
+	"URLRotatorGo/internal/adapter/storage/postgres"
+	"URLRotatorGo/internal/core/policy"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/internal/core/services"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"log"
+	"time"
+)
+
+// Recounts the click rollups of a range from the click events, e.g. to backfill after a restore
+// or after changing app.timezone. Rolling up a range again is always safe:
+//
+//	go run ./cmd/rollup -from 2024-01-01 -to 2024-02-01
+func main() {
+	cfg := config.InitConfig("config.json", "../../")
+	mylog := logger.NewLogger(cfg)
+	workerpool.IntializePool(cfg, mylog)
+	services.RollupLocation = config.AppLocation(cfg)
+	services.CountBots = cfg.GetBool("analytics.count_bots")
+
+	var from, to string
+	var step time.Duration
+	flag.StringVar(&from, "from", "", "first day to roll up in the app timezone, YYYY-MM-DD")
+	flag.StringVar(&to, "to", "", "day after the last day to roll up, YYYY-MM-DD, today by default")
+	flag.DurationVar(&step, "step", 24*time.Hour, "how much is rolled up in one transaction")
+	flag.Parse()
+
+	start, err := time.ParseInLocation(time.DateOnly, from, services.RollupLocation)
+	if err != nil {
+		log.Fatal("-from must be a date like 2024-01-31")
+	}
+	end := time.Now()
+	if to != "" {
+		if end, err = time.ParseInLocation(time.DateOnly, to, services.RollupLocation); err != nil {
+			log.Fatal("-to must be a date like 2024-01-31")
+		}
+	}
+	if !start.Before(end) || step < time.Hour {
+		log.Fatal("-from must be before -to and -step at least 1h")
+	}
+
+	ctx := context.Background()
+
+	var service ports.AnalyticsService
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(func() *viper.Viper { return cfg }),
+		fx.Provide(func() *zap.SugaredLogger { return mylog }),
+		fx.Provide(func() context.Context { return ctx }),
+		fx.Provide(database.NewPostgresConn),
+		fx.Provide(database.NewRedisConn),
+		fx.Provide(cache.NewRedisCache),
+		fx.Provide(postgres.NewShortCodeRepository),
+		fx.Provide(postgres.NewURLRepository),
+		fx.Provide(postgres.NewClickRepository),
+		fx.Provide(postgres.NewWorkspaceRepository),
+		fx.Provide(policy.NewPolicy),
+		fx.Provide(services.NewAnalyticsService),
+		fx.Populate(&service),
+	)
+
+	startCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err = app.Start(startCtx); err != nil {
+		log.Fatal("failed to start: ", err.Error())
+	}
+	defer app.Stop(ctx)
+
+	for chunk := start; chunk.Before(end); chunk = chunk.Add(step) {
+		chunkEnd := chunk.Add(step)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		if err = service.RollupClicks(ctx, chunk, chunkEnd); err != nil {
+			log.Fatal("failed to roll up clicks: ", err.Error())
+		}
+		fmt.Printf("rolled up %s - %s\n", chunk.Format(time.DateTime), chunkEnd.Format(time.DateTime))
+	}
+}
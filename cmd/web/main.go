@@ -10,6 +10,8 @@ import (
 	"URLRotatorGo/internal/adapter/http/handler"
 	"URLRotatorGo/internal/adapter/storage/cache"
 	"URLRotatorGo/internal/adapter/storage/postgres"
+	"URLRotatorGo/internal/adapter/webhook"
+	"URLRotatorGo/internal/core/policy"
 	"URLRotatorGo/internal/core/ports"
 	"URLRotatorGo/internal/core/services"
 	"context"
@@ -22,6 +24,8 @@ func main() {
 	cfg := config.InitConfig("config.json", "../..")
 	mylog := logger.NewLogger(cfg)
 	workerpool.IntializePool(cfg, mylog)
+	services.RollupLocation = config.AppLocation(cfg)
+	services.CountBots = cfg.GetBool("analytics.count_bots")
 
 	ctx := context.Background()
 
@@ -41,6 +45,42 @@ func main() {
 				postgres.NewURLRepository,
 				fx.As(new(ports.URLRepository)),
 			),
+			fx.Annotate(
+				postgres.NewTagRepository,
+				fx.As(new(ports.TagRepository)),
+			),
+			fx.Annotate(
+				postgres.NewRevisionRepository,
+				fx.As(new(ports.RevisionRepository)),
+			),
+			fx.Annotate(
+				postgres.NewAPIKeyRepository,
+				fx.As(new(ports.APIKeyRepository)),
+			),
+			fx.Annotate(
+				postgres.NewUserRepository,
+				fx.As(new(ports.UserRepository)),
+			),
+			fx.Annotate(
+				postgres.NewWorkspaceRepository,
+				fx.As(new(ports.WorkspaceRepository)),
+			),
+			fx.Annotate(
+				postgres.NewDomainRepository,
+				fx.As(new(ports.DomainRepository)),
+			),
+			fx.Annotate(
+				postgres.NewAuditRepository,
+				fx.As(new(ports.AuditRepository)),
+			),
+			fx.Annotate(
+				postgres.NewClickRepository,
+				fx.As(new(ports.ClickRepository)),
+			),
+			fx.Annotate(
+				postgres.NewWebhookRepository,
+				fx.As(new(ports.WebhookRepository)),
+			),
 		),
 		fx.Provide(
 			fx.Annotate(
@@ -49,15 +89,115 @@ func main() {
 			),
 		),
 		fx.Provide(
+			fx.Annotate(
+				webhook.NewHTTPSender,
+				fx.As(new(ports.WebhookSender)),
+			),
+		),
+		fx.Provide(
+			fx.Annotate(
+				policy.NewPolicy,
+				fx.As(new(ports.Policy)),
+			),
+		),
+		fx.Provide(
+			fx.Annotate(
+				services.NewClickRecorder,
+				fx.As(new(ports.ClickRecorder)),
+			),
+			fx.Annotate(
+				services.NewHitCounter,
+				fx.As(new(ports.HitCounter)),
+			),
 			fx.Annotate(
 				services.NewShortenerService,
 				fx.As(new(ports.ShortenerService)),
 			),
+			fx.Annotate(
+				services.NewExportService,
+				fx.As(new(ports.ExportService)),
+			),
+			fx.Annotate(
+				services.NewLinkService,
+				fx.As(new(ports.LinkService)),
+			),
+			fx.Annotate(
+				services.NewAPIKeyService,
+				fx.As(new(ports.APIKeyService)),
+			),
+			fx.Annotate(
+				services.NewUserService,
+				fx.As(new(ports.UserService)),
+			),
+			fx.Annotate(
+				services.NewWorkspaceService,
+				fx.As(new(ports.WorkspaceService)),
+			),
+			fx.Annotate(
+				services.NewDomainService,
+				fx.As(new(ports.DomainService)),
+			),
+			fx.Annotate(
+				services.NewAuditService,
+				fx.As(new(ports.AuditService)),
+			),
+			fx.Annotate(
+				services.NewAnalyticsService,
+				fx.As(new(ports.AnalyticsService)),
+			),
+			fx.Annotate(
+				services.NewRollupJob,
+				fx.As(new(ports.RollupJob)),
+			),
+			fx.Annotate(
+				services.NewWebhookService,
+				fx.As(new(ports.WebhookService)),
+			),
+			fx.Annotate(
+				services.NewWebhookDispatcher,
+				fx.As(new(ports.WebhookDispatcher)),
+			),
 		),
 		fx.Provide(
 			handler.NewURLHandler,
+			handler.NewExportHandler,
+			handler.NewLinkHandler,
+			handler.NewQRHandler,
+			handler.NewAPIKeyHandler,
+			handler.NewAuthHandler,
+			handler.NewWorkspaceHandler,
+			handler.NewDomainHandler,
+			handler.NewAuditHandler,
+			handler.NewAnalyticsHandler,
+			handler.NewWebhookHandler,
 			http.NewRouter,
 		),
+		fx.Invoke(func(lc fx.Lifecycle) {
+			// runs before any other hook, so an insecure deployment never starts serving
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					return config.ValidateSecret(cfg)
+				},
+			})
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, recorder ports.ClickRecorder, hits ports.HitCounter, rollup ports.RollupJob, dispatcher ports.WebhookDispatcher) {
+			lc.Append(fx.Hook{
+				OnStart: recorder.Start,
+				OnStop:  recorder.Stop,
+			})
+			lc.Append(fx.Hook{
+				OnStart: hits.Start,
+				OnStop:  hits.Stop,
+			})
+			lc.Append(fx.Hook{
+				OnStart: rollup.Start,
+				OnStop:  rollup.Stop,
+			})
+			lc.Append(fx.Hook{
+				OnStart: dispatcher.Start,
+				OnStop:  dispatcher.Stop,
+			})
+		}),
 		fx.Invoke(func(r *http.Router) {
 			r.SetupRoutes()
 		}),
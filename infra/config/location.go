@@ -0,0 +1,16 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+	"time"
+)
+
+// AppLocation returns the configured app timezone, falling back to UTC.
+func AppLocation(cfg *viper.Viper) *time.Location {
+	loc, err := time.LoadLocation(cfg.GetString("app.timezone"))
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
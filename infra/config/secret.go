@@ -0,0 +1,25 @@
+package config
+
+import (
+	"errors"
+	"github.com/spf13/viper"
+)
+
+// DefaultSecret is the placeholder security.secret once shipped with, it is rejected like a short one.
+const DefaultSecret = "change-this-secret"
+
+// MinSecretLength is the shortest security.secret that is accepted, in bytes.
+const MinSecretLength = 32
+
+var ErrInsecureSecret = errors.New("security.secret must be set to a random value of at least 32 bytes")
+
+// ValidateSecret rejects an empty, short or default security.secret. The secret signs unlock
+// cookies and session tokens, with a known secret anyone could forge them.
+func ValidateSecret(cfg *viper.Viper) error {
+	secret := cfg.GetString("security.secret")
+	if secret == DefaultSecret || len(secret) < MinSecretLength {
+		return ErrInsecureSecret
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestValidateSecret(t *testing.T) {
+	tests := map[string]struct {
+		secret string
+		valid  bool
+	}{
+		"empty":   {"", false},
+		"default": {DefaultSecret, false},
+		"short":   {"too-short", false},
+		"random":  {strings.Repeat("x", MinSecretLength), true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := viper.New()
+			cfg.Set("security.secret", test.secret)
+			if err := ValidateSecret(cfg); (err == nil) != test.valid {
+				t.Errorf("ValidateSecret() error = %v, want valid %v", err, test.valid)
+			}
+		})
+	}
+}
@@ -19,17 +19,30 @@ import (
 )
 
 func InitServer(lc fx.Lifecycle, cfg *viper.Viper) *fiber.App {
+	// streamed responses such as exports extend it with service.http.stream_timeout themselves
+	writeTimeout := cfg.GetDuration("service.http.write_timeout")
+	if writeTimeout <= 0 {
+		writeTimeout = 10 * time.Second
+	}
+
+	// the client ip is only taken from the proxy header of the proxies in service.http.trusted_proxies,
+	// otherwise every client could send its own and get a fresh per ip rate limit with every request
+	trustedProxies := cfg.GetStringSlice("service.http.trusted_proxies")
+
 	app := fiber.New(fiber.Config{
-		AppName:            cfg.GetString("app.name"),
-		CaseSensitive:      true,
-		EnablePrintRoutes:  true,
-		JSONEncoder:        sonic.Marshal,
-		JSONDecoder:        sonic.Unmarshal,
-		StrictRouting:      true,
-		WriteTimeout:       10 * time.Second,
-		Prefork:            cfg.GetBool("service.http.prefork"),
-		ProxyHeader:        "Cf-Connecting-Ip",
-		EnableIPValidation: true,
+		AppName:                 cfg.GetString("app.name"),
+		CaseSensitive:           true,
+		EnablePrintRoutes:       true,
+		JSONEncoder:             sonic.Marshal,
+		JSONDecoder:             sonic.Unmarshal,
+		StrictRouting:           true,
+		WriteTimeout:            writeTimeout,
+		Prefork:                 cfg.GetBool("service.http.prefork"),
+		ProxyHeader:             "Cf-Connecting-Ip",
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          trustedProxies,
+		EnableIPValidation:      true,
+		StreamRequestBody:       true,
 	})
 
 	app.Use(recover.New(recover.ConfigDefault))
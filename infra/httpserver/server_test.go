@@ -0,0 +1,50 @@
+package httpserver
+
+import (
+	"URLRotatorGo/infra/logger"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+func TestProxyHeaderOnlyFromTrustedProxies(t *testing.T) {
+	logger.L = zap.NewNop().Sugar()
+
+	tests := map[string]struct {
+		trusted []string
+		ip      string
+	}{
+		"no trusted proxies": {nil, "0.0.0.0"},
+		"other proxy":        {[]string{"10.0.0.1", "192.168.0.0/16"}, "0.0.0.0"},
+		"trusted proxy":      {[]string{"0.0.0.0"}, "203.0.113.1"},
+		"trusted range":      {[]string{"0.0.0.0/8"}, "203.0.113.1"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := viper.New()
+			cfg.Set("service.http.trusted_proxies", test.trusted)
+			app := InitServer(fxtest.NewLifecycle(t), cfg)
+			app.Get("/ip", func(c *fiber.Ctx) error {
+				return c.SendString(c.IP())
+			})
+
+			// app.Test connects from 0.0.0.0
+			req := httptest.NewRequest(fiber.MethodGet, "/ip", nil)
+			req.Header.Set("Cf-Connecting-Ip", "203.0.113.1")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+
+			if string(body) != test.ip {
+				t.Errorf("client ip = %q, want %q", body, test.ip)
+			}
+		})
+	}
+}
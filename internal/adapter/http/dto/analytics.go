@@ -0,0 +1,102 @@
+package dto
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"time"
+)
+
+type Analytics struct {
+	Code      string              `json:"code"`
+	Domain    string              `json:"domain,omitempty"`
+	From      time.Time           `json:"from"`
+	To        time.Time           `json:"to"`
+	Interval  string              `json:"interval"`
+	Timezone  string              `json:"timezone"`
+	Total     int64               `json:"total"`
+	Visitors  int64               `json:"visitors"`
+	Series    []DestinationSeries `json:"series"`
+	Referrers []DimensionCount    `json:"referrers"`
+	Countries []DimensionCount    `json:"countries"`
+	Devices   []DimensionCount    `json:"devices"`
+	Traffic   []DimensionCount    `json:"traffic"`
+}
+
+type DestinationSeries struct {
+	DestinationID int              `json:"destination_id"`
+	URL           string           `json:"url"`
+	Total         int64            `json:"total"`
+	Visitors      int64            `json:"visitors"`
+	Points        []AnalyticsPoint `json:"points"`
+}
+
+type AnalyticsPoint struct {
+	Time   time.Time `json:"time"`
+	Clicks int64     `json:"clicks"`
+}
+
+type DimensionCount struct {
+	Value  string `json:"value"`
+	Clicks int64  `json:"clicks"`
+}
+
+func NewAnalytics(analytics *domain.Analytics) Analytics {
+	host, code := domain.SplitLinkKey(analytics.Query.Code)
+
+	response := Analytics{
+		Code:      code,
+		Domain:    host,
+		From:      analytics.Query.From,
+		To:        analytics.Query.To,
+		Interval:  string(analytics.Query.Interval),
+		Timezone:  analytics.Query.Location.String(),
+		Total:     analytics.Total,
+		Visitors:  analytics.Visitors,
+		Series:    make([]DestinationSeries, 0, len(analytics.Series)),
+		Referrers: newDimensionCounts(analytics.Referrers),
+		Countries: newDimensionCounts(analytics.Countries),
+		Devices:   newDimensionCounts(analytics.Devices),
+		Traffic:   newDimensionCounts(analytics.Traffic),
+	}
+	for _, series := range analytics.Series {
+		destination := DestinationSeries{
+			DestinationID: series.URLID,
+			URL:           series.URL,
+			Total:         series.Total,
+			Visitors:      series.Visitors,
+			Points:        make([]AnalyticsPoint, 0, len(series.Points)),
+		}
+		for _, point := range series.Points {
+			destination.Points = append(destination.Points, AnalyticsPoint{Time: point.Time, Clicks: point.Clicks})
+		}
+		response.Series = append(response.Series, destination)
+	}
+
+	return response
+}
+
+func newDimensionCounts(counts []domain.DimensionCount) []DimensionCount {
+	results := make([]DimensionCount, 0, len(counts))
+	for _, count := range counts {
+		results = append(results, DimensionCount{Value: count.Value, Clicks: count.Clicks})
+	}
+
+	return results
+}
+
+type LiveClick struct {
+	Time          time.Time `json:"time"`
+	DestinationID int       `json:"destination_id"`
+	Country       string    `json:"country"`
+	Device        string    `json:"device"`
+	Traffic       string    `json:"traffic"`
+}
+
+func NewLiveClick(event *domain.ClickEvent) LiveClick {
+	return LiveClick{
+		Time:          event.ClickedAt,
+		DestinationID: event.URLID,
+		Country:       event.Country,
+		Device:        event.Device,
+		Traffic:       string(event.Traffic),
+	}
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"encoding/json"
+	"time"
+)
+
+type AuditEntry struct {
+	ID          int             `json:"id"`
+	Action      string          `json:"action"`
+	Actor       string          `json:"actor"`
+	IP          string          `json:"ip"`
+	RequestID   string          `json:"request_id"`
+	TargetType  string          `json:"target_type"`
+	TargetID    string          `json:"target_id"`
+	WorkspaceID int             `json:"workspace_id,omitempty"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type ResponseListAudit struct {
+	Items      []AuditEntry `json:"items"`
+	NextCursor string       `json:"next_cursor"`
+}
+
+func NewAuditEntry(entry *domain.AuditEntry) AuditEntry {
+	return AuditEntry{
+		ID:          entry.ID,
+		Action:      entry.Action,
+		Actor:       entry.Actor,
+		IP:          entry.IP,
+		RequestID:   entry.RequestID,
+		TargetType:  entry.TargetType,
+		TargetID:    entry.TargetID,
+		WorkspaceID: entry.WorkspaceID,
+		Before:      nullJSON(entry.Before),
+		After:       nullJSON(entry.After),
+		CreatedAt:   entry.CreatedAt,
+	}
+}
+
+func nullJSON(data json.RawMessage) json.RawMessage {
+	if len(data) == 0 {
+		return json.RawMessage("null")
+	}
+
+	return data
+}
@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"time"
+)
+
+type RequestRegisterDomain struct {
+	Host         string `json:"host" validate:"required,max=253"`
+	WorkspaceID  int    `json:"workspace_id" validate:"required,min=1"`
+	RootRedirect string `json:"root_redirect" validate:"omitempty,max=1000,url"`
+	NotFoundURL  string `json:"not_found_url" validate:"omitempty,max=1000,url"`
+}
+
+func (r *RequestRegisterDomain) ToDomain() *domain.Domain {
+	return &domain.Domain{
+		Host:         r.Host,
+		WorkspaceID:  r.WorkspaceID,
+		RootRedirect: r.RootRedirect,
+		NotFoundURL:  r.NotFoundURL,
+	}
+}
+
+// RequestUpdateDomain only changes the fields that are set, an empty string clears a field.
+type RequestUpdateDomain struct {
+	RootRedirect *string `json:"root_redirect" validate:"omitempty,max=1000,url"`
+	NotFoundURL  *string `json:"not_found_url" validate:"omitempty,max=1000,url"`
+}
+
+func (r *RequestUpdateDomain) ToDomain() domain.DomainUpdate {
+	return domain.DomainUpdate{
+		RootRedirect: r.RootRedirect,
+		NotFoundURL:  r.NotFoundURL,
+	}
+}
+
+type Domain struct {
+	ID           int       `json:"id"`
+	Host         string    `json:"host"`
+	WorkspaceID  int       `json:"workspace_id"`
+	RootRedirect string    `json:"root_redirect"`
+	NotFoundURL  string    `json:"not_found_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func NewDomain(customDomain *domain.Domain) Domain {
+	return Domain{
+		ID:           customDomain.ID,
+		Host:         customDomain.Host,
+		WorkspaceID:  customDomain.WorkspaceID,
+		RootRedirect: customDomain.RootRedirect,
+		NotFoundURL:  customDomain.NotFoundURL,
+		CreatedAt:    customDomain.CreatedAt,
+		UpdatedAt:    customDomain.UpdatedAt,
+	}
+}
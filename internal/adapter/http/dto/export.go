@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"time"
+)
+
+type ExportedLink struct {
+	Code         string                `json:"code"`
+	Domain       string                `json:"domain,omitempty"`
+	URL          string                `json:"url"`
+	Strategy     string                `json:"strategy"`
+	TotalHit     int                   `json:"total_hit"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+	Destinations []ExportedDestination `json:"destinations"`
+}
+
+type ExportedDestination struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Enabled   bool      `json:"enabled"`
+	TotalHit  int       `json:"total_hit"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewExportedLink(data *domain.ShortCodeExport, shortLink string) ExportedLink {
+	host, code := domain.SplitLinkKey(data.Code)
+
+	link := ExportedLink{
+		Code:         code,
+		Domain:       host,
+		URL:          shortLink,
+		Strategy:     string(data.Strategy),
+		TotalHit:     data.TotalHit,
+		CreatedAt:    data.CreatedAt,
+		UpdatedAt:    data.UpdatedAt,
+		Destinations: make([]ExportedDestination, 0, len(data.URLs)),
+	}
+	for _, url := range data.URLs {
+		link.Destinations = append(link.Destinations, ExportedDestination{
+			ID:        url.ID,
+			URL:       url.Original,
+			Enabled:   url.Enabled,
+			TotalHit:  url.TotalHit,
+			CreatedAt: url.CreatedAt,
+		})
+	}
+
+	return link
+}
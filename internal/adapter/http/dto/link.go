@@ -0,0 +1,158 @@
+package dto
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"time"
+)
+
+type RequestSetTags struct {
+	Tags []string `json:"tags" validate:"max=20,dive,min=1,max=64"`
+}
+
+type LinkItem struct {
+	Code      string     `json:"code"`
+	Domain    string     `json:"domain,omitempty"`
+	URL       string     `json:"url"`
+	Strategy  string     `json:"strategy"`
+	TotalHit  int        `json:"total_hit"`
+	Protected bool       `json:"protected"`
+	Workspace int        `json:"workspace_id,omitempty"`
+	Social    SocialMeta `json:"social"`
+	Redirect  Redirect   `json:"redirect"`
+	Tags      []string   `json:"tags"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type ResponseListLinks struct {
+	Items      []LinkItem `json:"items"`
+	NextCursor string     `json:"next_cursor"`
+}
+
+func NewLinkItem(shortcode *domain.ShortCode, shortLink string) LinkItem {
+	tags := shortcode.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	host, code := domain.SplitLinkKey(shortcode.Code)
+
+	return LinkItem{
+		Code:      code,
+		Domain:    host,
+		URL:       shortLink,
+		Strategy:  string(shortcode.Strategy),
+		TotalHit:  shortcode.TotalHit,
+		Protected: shortcode.IsProtected(),
+		Workspace: shortcode.WorkspaceID,
+		Social:    NewSocialMeta(shortcode.Social),
+		Redirect:  NewRedirect(shortcode.Redirect),
+		Tags:      tags,
+		CreatedAt: shortcode.CreatedAt,
+		UpdatedAt: shortcode.UpdatedAt,
+	}
+}
+
+type RequestSetEnabled struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+type Destination struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Enabled   bool      `json:"enabled"`
+	TotalHit  int       `json:"total_hit"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewDestination(url *domain.URL) Destination {
+	return Destination{
+		ID:        url.ID,
+		URL:       url.Original,
+		Enabled:   url.Enabled,
+		TotalHit:  url.TotalHit,
+		CreatedAt: url.CreatedAt,
+		UpdatedAt: url.UpdatedAt,
+	}
+}
+
+type RequestUpdateLink struct {
+	Strategy *string     `json:"strategy"`
+	URLs     []string    `json:"urls" validate:"omitempty,min=1,max=100,dive,url"`
+	Password *string     `json:"password" validate:"omitempty,maxbytes=72"`
+	Social   *SocialMeta `json:"social"`
+	Redirect *Redirect   `json:"redirect"`
+}
+
+func (r *RequestUpdateLink) ToDomain() domain.UpdateLinkRequest {
+	request := domain.UpdateLinkRequest{
+		Strategy: r.Strategy,
+		URLs:     r.URLs,
+		Password: r.Password,
+	}
+	if r.Social != nil {
+		social := r.Social.ToDomain()
+		request.Social = &social
+	}
+	if r.Redirect != nil {
+		redirect := r.Redirect.ToDomain()
+		request.Redirect = &redirect
+	}
+
+	return request
+}
+
+type RevisionDestination struct {
+	ID      int    `json:"id"`
+	URL     string `json:"url"`
+	Enabled bool   `json:"enabled"`
+}
+
+type RevisionChange struct {
+	Field  string `json:"field"`
+	Target string `json:"target,omitempty"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+type RevisionItem struct {
+	Revision     int                   `json:"revision"`
+	Action       string                `json:"action"`
+	Actor        string                `json:"actor"`
+	Strategy     string                `json:"strategy"`
+	Protected    bool                  `json:"protected"`
+	Social       SocialMeta            `json:"social"`
+	Redirect     Redirect              `json:"redirect"`
+	Destinations []RevisionDestination `json:"destinations"`
+	Tags         []string              `json:"tags"`
+	Changes      []RevisionChange      `json:"changes"`
+	CreatedAt    time.Time             `json:"created_at"`
+}
+
+func NewRevisionItem(revision *domain.Revision) RevisionItem {
+	item := RevisionItem{
+		Revision:     revision.Revision,
+		Action:       revision.Action,
+		Actor:        revision.Actor,
+		Strategy:     string(revision.Snapshot.Strategy),
+		Protected:    revision.Snapshot.PasswordHash != "",
+		Social:       NewSocialMeta(revision.Snapshot.Social),
+		Redirect:     NewRedirect(revision.Snapshot.Redirect),
+		Destinations: make([]RevisionDestination, 0, len(revision.Snapshot.Destinations)),
+		Tags:         revision.Snapshot.Tags,
+		Changes:      make([]RevisionChange, 0, len(revision.Diff)),
+		CreatedAt:    revision.CreatedAt,
+	}
+	if item.Tags == nil {
+		item.Tags = []string{}
+	}
+	for _, destination := range revision.Snapshot.Destinations {
+		item.Destinations = append(item.Destinations, RevisionDestination(destination))
+	}
+	for _, change := range revision.Diff {
+		item.Changes = append(item.Changes, RevisionChange(change))
+	}
+
+	return item
+}
@@ -1,14 +1,137 @@
 package dto
 
-import "time"
+import (
+	"URLRotatorGo/internal/core/domain"
+	"time"
+)
 
 type RequestShortURL struct {
-	URL      []string `json:"urls" validate:"required,dive,min=5,max=1000,url"`
-	Strategy string   `json:"strategy" validate:"required"`
+	URL      []string    `json:"urls" validate:"required,dive,min=5,max=1000,url"`
+	Strategy string      `json:"strategy" validate:"required"`
+	Password string      `json:"password" validate:"omitempty,min=4,maxbytes=72"`
+	Alias    string      `json:"alias" validate:"omitempty,min=3,max=64"`
+	Social   *SocialMeta `json:"social"`
+	Redirect *Redirect   `json:"redirect"`
+	// Deduplicate overrides the shortener.deduplicate setting for this request.
+	Deduplicate *bool `json:"deduplicate"`
+	// WorkspaceID creates the link in a workspace instead of as a personal link.
+	WorkspaceID int `json:"workspace_id" validate:"min=0"`
+	// Domain creates the link on a registered custom domain instead of the default one.
+	Domain string `json:"domain" validate:"max=253"`
+}
+
+func (r *RequestShortURL) ToDomain() domain.ShortenRequest {
+	return domain.ShortenRequest{
+		URLs:     r.URL,
+		Strategy: r.Strategy,
+		Password: r.Password,
+		Alias:    r.Alias,
+		Social:   r.Social.ToDomain(),
+		Redirect: r.Redirect.ToDomain(),
+		Domain:   r.Domain,
+	}
+}
+
+// Redirect selects how visitors are redirected: 301, 302, 307, 308, meta or js. The delay in
+// seconds only applies to meta and js.
+type Redirect struct {
+	Type  string `json:"type"`
+	Delay int    `json:"delay" validate:"min=0,max=30"`
+}
+
+func (r *Redirect) ToDomain() domain.Redirect {
+	if r == nil {
+		return domain.Redirect{}
+	}
+
+	return domain.Redirect{
+		Type:  domain.RedirectType(r.Type),
+		Delay: r.Delay,
+	}
+}
+
+func NewRedirect(redirect domain.Redirect) Redirect {
+	if redirect.Type == "" {
+		redirect.Type = domain.DefaultRedirectType
+	}
+
+	return Redirect{
+		Type:  string(redirect.Type),
+		Delay: redirect.Delay,
+	}
+}
+
+// SocialMeta is the OpenGraph and Twitter Card metadata served to link unfurling crawlers.
+type SocialMeta struct {
+	Title       string `json:"title" validate:"max=200"`
+	Description string `json:"description" validate:"max=500"`
+	Image       string `json:"image" validate:"omitempty,max=1000,url"`
+}
+
+func (m *SocialMeta) ToDomain() domain.SocialMeta {
+	if m == nil {
+		return domain.SocialMeta{}
+	}
+
+	return domain.SocialMeta{
+		Title:       m.Title,
+		Description: m.Description,
+		Image:       m.Image,
+	}
+}
+
+func NewSocialMeta(meta domain.SocialMeta) SocialMeta {
+	return SocialMeta{
+		Title:       meta.Title,
+		Description: meta.Description,
+		Image:       meta.Image,
+	}
 }
 
 type ResponseShortURL struct {
 	URL       string    `json:"url"`
 	Strategy  string    `json:"strategy"`
+	Protected bool      `json:"protected"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+type PasswordForm struct {
+	Code  string
+	Error string
+}
+
+type PreviewPage struct {
+	Code         string
+	URL          string
+	Strategy     string
+	CreatedAt    string
+	Protected    bool
+	Destinations []string
+}
+
+type SocialPage struct {
+	URL         string
+	Title       string
+	Description string
+	Image       string
+}
+
+type RedirectPage struct {
+	URL        string
+	Delay      int
+	JavaScript bool
+}
+
+type BulkShortURLResult struct {
+	Row     int    `json:"row"`
+	Success bool   `json:"success"`
+	URL     string `json:"url,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type ResponseBulkShortURL struct {
+	Total     int                  `json:"total"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []BulkShortURLResult `json:"results"`
+}
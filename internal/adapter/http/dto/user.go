@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"time"
+)
+
+type RequestSignup struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	// bcrypt refuses passwords longer than 72 bytes
+	Password string `json:"password" validate:"required,min=8,maxbytes=72"`
+}
+
+type RequestLogin struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type User struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ResponseLogin struct {
+	User      User      `json:"user"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewUser(user *domain.User) User {
+	return User{
+		ID:        user.ID,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt,
+	}
+}
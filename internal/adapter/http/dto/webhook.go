@@ -0,0 +1,102 @@
+package dto
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"encoding/json"
+	"time"
+)
+
+type RequestRegisterWebhook struct {
+	URL         string   `json:"url" validate:"required,max=2000,url"`
+	WorkspaceID int      `json:"workspace_id" validate:"omitempty,min=1"`
+	Events      []string `json:"events" validate:"required,min=1,dive,oneof=link.created link.updated link.clicked"`
+}
+
+func (r *RequestRegisterWebhook) ToDomain() *domain.Webhook {
+	return &domain.Webhook{
+		URL:         r.URL,
+		WorkspaceID: r.WorkspaceID,
+		Events:      r.Events,
+	}
+}
+
+// RequestUpdateWebhook only changes the fields that are set.
+type RequestUpdateWebhook struct {
+	URL     *string  `json:"url" validate:"omitempty,max=2000,url"`
+	Events  []string `json:"events" validate:"omitempty,min=1,dive,oneof=link.created link.updated link.clicked"`
+	Enabled *bool    `json:"enabled"`
+}
+
+func (r *RequestUpdateWebhook) ToDomain() domain.WebhookUpdate {
+	return domain.WebhookUpdate{
+		URL:     r.URL,
+		Events:  r.Events,
+		Enabled: r.Enabled,
+	}
+}
+
+type Webhook struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url"`
+	WorkspaceID int       `json:"workspace_id"`
+	OwnerID     int       `json:"owner_id"`
+	Events      []string  `json:"events"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ResponseRegisterWebhook is the only response that contains the secret.
+type ResponseRegisterWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+func NewWebhook(webhook *domain.Webhook) Webhook {
+	return Webhook{
+		ID:          webhook.ID,
+		URL:         webhook.URL,
+		WorkspaceID: webhook.WorkspaceID,
+		OwnerID:     webhook.OwnerID,
+		Events:      webhook.Events,
+		Enabled:     webhook.Enabled,
+		CreatedAt:   webhook.CreatedAt,
+		UpdatedAt:   webhook.UpdatedAt,
+	}
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	DeliveryID     string          `json:"delivery_id"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code"`
+	LastError      string          `json:"last_error"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
+
+func NewWebhookDelivery(delivery *domain.WebhookDelivery) WebhookDelivery {
+	// only pending deliveries are attempted again
+	var nextAttemptAt *time.Time
+	if delivery.Status == domain.DeliveryPending {
+		nextAttemptAt = &delivery.NextAttemptAt
+	}
+
+	return WebhookDelivery{
+		ID:             delivery.ID,
+		DeliveryID:     delivery.DeliveryID,
+		Event:          delivery.Event,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  nextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		Payload:        delivery.Payload,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+}
@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"time"
+)
+
+type RequestCreateWorkspace struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+type RequestSetMemberRole struct {
+	Role string `json:"role" validate:"required,oneof=owner admin editor viewer"`
+}
+
+type RequestInviteMember struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	Role  string `json:"role" validate:"required,oneof=owner admin editor viewer"`
+}
+
+type RequestAcceptInvitation struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type Workspace struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Member struct {
+	UserID    int       `json:"user_id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Invitation struct {
+	ID          int        `json:"id"`
+	WorkspaceID int        `json:"workspace_id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	Pending     bool       `json:"pending"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
+
+// ResponseInviteMember is the only response that contains the invitation token.
+type ResponseInviteMember struct {
+	Invitation
+	Token string `json:"token"`
+}
+
+func NewWorkspace(workspace *domain.Workspace) Workspace {
+	return Workspace{
+		ID:        workspace.ID,
+		Name:      workspace.Name,
+		Role:      string(workspace.Role),
+		CreatedAt: workspace.CreatedAt,
+	}
+}
+
+func NewMember(member *domain.Member) Member {
+	return Member{
+		UserID:    member.UserID,
+		Email:     member.Email,
+		Role:      string(member.Role),
+		CreatedAt: member.CreatedAt,
+	}
+}
+
+func NewInvitation(invitation *domain.Invitation) Invitation {
+	return Invitation{
+		ID:          invitation.ID,
+		WorkspaceID: invitation.WorkspaceID,
+		Email:       invitation.Email,
+		Role:        string(invitation.Role),
+		Pending:     invitation.IsPending(),
+		CreatedAt:   invitation.CreatedAt,
+		ExpiresAt:   invitation.ExpiresAt,
+		AcceptedAt:  invitation.AcceptedAt,
+		RevokedAt:   invitation.RevokedAt,
+	}
+}
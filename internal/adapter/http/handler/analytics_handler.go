@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"URLRotatorGo/infra/config"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+const (
+	liveStreamBuffer    = 100
+	liveStreamHeartbeat = 15 * time.Second
+	liveStreamRetry     = 5 * time.Second
+)
+
+type AnalyticsHandler struct {
+	AnalyticsService ports.AnalyticsService
+	cfg              *viper.Viper
+}
+
+func NewAnalyticsHandler(AnalyticsService ports.AnalyticsService, cfg *viper.Viper) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		AnalyticsService: AnalyticsService,
+		cfg:              cfg,
+	}
+}
+
+// GetAnalytics returns the clicks of a shortcode over time. Supported query parameters are from
+// and to (RFC3339 or YYYY-MM-DD, the last 7 days by default), interval (hour or day) and tz, an
+// IANA timezone that defaults to the app timezone.
+func (h *AnalyticsHandler) GetAnalytics(c *fiber.Ctx) error {
+	query, err := h.parseAnalyticsQuery(c)
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	analytics, err := h.AnalyticsService.GetAnalytics(c.UserContext(), query)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: dto.NewAnalytics(analytics),
+	})
+}
+
+// StreamClicks streams the clicks of a shortcode as server-sent events while they happen. Every
+// click is a "click" event, comments are sent in between so that closed connections are noticed.
+// Streams end at the stream_timeout of the server, EventSource clients reconnect on their own.
+func (h *AnalyticsHandler) StreamClicks(c *fiber.Ctx) error {
+	code := linkKey(h.cfg, c)
+	if err := h.AnalyticsService.AuthorizeStream(c.UserContext(), code); err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+	principal := domain.PrincipalFromContext(c.UserContext())
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// proxies like nginx would otherwise hold the events back
+	c.Set("X-Accel-Buffering", "no")
+
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		timeout := extendWriteDeadline(h.cfg, conn)
+
+		// the request context is gone once the handler returns, the stream runs on its own
+		ctx, cancel := context.WithTimeout(domain.WithPrincipal(context.Background(), principal), timeout)
+		defer cancel()
+
+		events := make(chan *domain.ClickEvent, liveStreamBuffer)
+		go func() {
+			defer cancel()
+
+			err := h.AnalyticsService.StreamClicks(ctx, code, func(event *domain.ClickEvent) error {
+				select {
+				case events <- event:
+				default:
+					// the client doesn't keep up, it misses the click
+				}
+				return nil
+			})
+			if err != nil {
+				logger.L.Errorw("failed to stream clicks", "shortcode", code, "error", err.Error())
+			}
+		}()
+
+		heartbeat := time.NewTicker(liveStreamHeartbeat)
+		defer heartbeat.Stop()
+
+		fmt.Fprintf(w, "retry: %d\n\n", liveStreamRetry.Milliseconds())
+		for {
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			select {
+			case event := <-events:
+				data, err := sonic.Marshal(dto.NewLiveClick(event))
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: click\ndata: %s\n\n", data)
+			case <-heartbeat.C:
+				_, _ = w.WriteString(": ping\n\n")
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func (h *AnalyticsHandler) parseAnalyticsQuery(c *fiber.Ctx) (domain.AnalyticsQuery, error) {
+	query := domain.AnalyticsQuery{
+		Code:     linkKey(h.cfg, c),
+		Interval: domain.AnalyticsInterval(strings.ToLower(c.Query("interval"))),
+		Location: config.AppLocation(h.cfg),
+	}
+	if query.Interval != "" && query.Interval != domain.IntervalHour && query.Interval != domain.IntervalDay {
+		return query, errors.New("invalid interval, use hour or day")
+	}
+
+	if tz := c.Query("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil || tz == "Local" {
+			return query, errors.New("invalid tz, use an IANA timezone like Asia/Jakarta")
+		}
+		query.Location = loc
+	}
+
+	var err error
+	if value := c.Query("from"); value != "" {
+		if query.From, err = parseTimeIn(value, query.Location); err != nil {
+			return query, errors.New("invalid from, use RFC3339 or YYYY-MM-DD")
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if query.To, err = parseTimeIn(value, query.Location); err != nil {
+			return query, errors.New("invalid to, use RFC3339 or YYYY-MM-DD")
+		}
+	}
+
+	return query, nil
+}
+
+// parseTimeIn accepts either a RFC3339 timestamp or a plain date in loc.
+func parseTimeIn(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation(time.DateOnly, value, loc)
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+func TestParseAnalyticsQuery(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+
+	tests := map[string]struct {
+		query    string
+		want     domain.AnalyticsQuery
+		invalid  bool
+		location string
+	}{
+		"defaults": {
+			query: "", want: domain.AnalyticsQuery{Code: "spring"}, location: "UTC",
+		},
+		"dates in timezone": {
+			query:    "?from=2024-03-01&to=2024-03-08&interval=DAY&tz=Asia/Jakarta",
+			want:     domain.AnalyticsQuery{Code: "spring", From: time.Date(2024, 3, 1, 0, 0, 0, 0, jakarta), To: time.Date(2024, 3, 8, 0, 0, 0, 0, jakarta), Interval: domain.IntervalDay},
+			location: "Asia/Jakarta",
+		},
+		"timestamps": {
+			query:    "?from=2024-03-01T10:00:00Z&to=2024-03-01T12:00:00%2B02:00&interval=hour",
+			want:     domain.AnalyticsQuery{Code: "spring", From: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), To: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), Interval: domain.IntervalHour},
+			location: "UTC",
+		},
+		"custom domain":    {query: "?domain=go.acme.example", want: domain.AnalyticsQuery{Code: "go.acme.example/spring"}, location: "UTC"},
+		"default domain":   {query: "?domain=example.com", want: domain.AnalyticsQuery{Code: "spring"}, location: "UTC"},
+		"unknown interval": {query: "?interval=week", invalid: true},
+		"unknown timezone": {query: "?tz=Mars/Olympus", invalid: true},
+		"local timezone":   {query: "?tz=Local", invalid: true},
+		"malformed from":   {query: "?from=yesterday", invalid: true},
+		"malformed to":     {query: "?to=01.03.2024", invalid: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := viper.New()
+			cfg.Set("app.domain", "example.com")
+			h := NewAnalyticsHandler(nil, cfg)
+
+			var query domain.AnalyticsQuery
+			var err error
+			app := fiber.New()
+			app.Get("/:code", func(c *fiber.Ctx) error {
+				query, err = h.parseAnalyticsQuery(c)
+				return nil
+			})
+			if _, testErr := app.Test(httptest.NewRequest("GET", "/spring"+test.query, nil)); testErr != nil {
+				t.Fatalf("app.Test() error = %v", testErr)
+			}
+
+			if test.invalid {
+				if err == nil {
+					t.Errorf("parseAnalyticsQuery() = %+v, want an error", query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAnalyticsQuery() error = %v", err)
+			}
+			if query.Code != test.want.Code || query.Interval != test.want.Interval || !query.From.Equal(test.want.From) ||
+				!query.To.Equal(test.want.To) || query.Location.String() != test.location {
+				t.Errorf("parseAnalyticsQuery() = %+v, want %+v in %s", query, test.want, test.location)
+			}
+		})
+	}
+}
+
+// fakeAnalyticsService streams the events of its shortcode and then waits for the stream to end.
+type fakeAnalyticsService struct {
+	ports.AnalyticsService
+
+	events map[string][]*domain.ClickEvent
+}
+
+func (s *fakeAnalyticsService) AuthorizeStream(ctx context.Context, code string) error {
+	if _, ok := s.events[code]; !ok {
+		return domain.ErrForbidden
+	}
+
+	return nil
+}
+
+func (s *fakeAnalyticsService) StreamClicks(ctx context.Context, code string, fn func(*domain.ClickEvent) error) error {
+	for _, event := range s.events[code] {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	<-ctx.Done()
+
+	return nil
+}
+
+func TestStreamClicks(t *testing.T) {
+	clickedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	service := &fakeAnalyticsService{events: map[string][]*domain.ClickEvent{"spring": {
+		{ClickedAt: clickedAt, ShortCode: "spring", URLID: 1, Visit: domain.Visit{Country: "DE", IPHash: "secret-hash"}},
+		{ClickedAt: clickedAt, ShortCode: "spring", URLID: 2, Visit: domain.Visit{Traffic: domain.TrafficBot}},
+	}}}
+	cfg := viper.New()
+	cfg.Set("service.http.stream_timeout", "200ms")
+	h := NewAnalyticsHandler(service, cfg)
+	app := fiber.New()
+	app.Get("/:code/live", h.StreamClicks)
+
+	response, err := app.Test(httptest.NewRequest("GET", "/spring/live", nil), 5000)
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	body, _ := io.ReadAll(response.Body)
+
+	if response.Header.Get(fiber.HeaderContentType) != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want an event stream", response.Header.Get(fiber.HeaderContentType))
+	}
+	for _, want := range []string{
+		"retry: 5000\n\n",
+		`event: click` + "\n" + `data: {"time":"2024-03-01T10:00:00Z","destination_id":1,"country":"DE","device":"","traffic":""}` + "\n\n",
+		`"destination_id":2`,
+		`"traffic":"bot"`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("stream %q misses %q", body, want)
+		}
+	}
+	// the stream only shows what the dashboard needs, never who the visitor is
+	if strings.Contains(string(body), "secret-hash") {
+		t.Errorf("stream %q leaks the ip hash", body)
+	}
+
+	response, err = app.Test(httptest.NewRequest("GET", "/other/live", nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if response.StatusCode != 403 || response.Header.Get(fiber.HeaderContentType) == "text/event-stream" {
+		t.Errorf("stream of a foreign shortcode = %d %s, want 403 without a stream", response.StatusCode, response.Header.Get(fiber.HeaderContentType))
+	}
+}
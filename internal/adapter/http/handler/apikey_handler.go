@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type APIKeyHandler struct {
+	APIKeyService ports.APIKeyService
+}
+
+func NewAPIKeyHandler(APIKeyService ports.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		APIKeyService: APIKeyService,
+	}
+}
+
+func (h *APIKeyHandler) IssueKey(c *fiber.Ctx) error {
+	var request dto.RequestIssueKey
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err := pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	apiKey, key, err := h.APIKeyService.IssueKey(c.UserContext(), request.Name, request.Scopes)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(dto.ApiResponse{
+		Data: dto.ResponseIssueKey{
+			APIKey: dto.NewAPIKey(apiKey),
+			Key:    key,
+		},
+		Message: "store this key now, it can't be shown again",
+	})
+}
+
+func (h *APIKeyHandler) ListKeys(c *fiber.Ctx) error {
+	keys, err := h.APIKeyService.ListKeys(c.UserContext())
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	items := make([]dto.APIKey, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, dto.NewAPIKey(key))
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: items,
+	})
+}
+
+func (h *APIKeyHandler) RevokeKey(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid key id",
+		})
+	}
+
+	if err = h.APIKeyService.RevokeKey(c.UserContext(), id); err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Message: "key revoked",
+	})
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+type AuditHandler struct {
+	AuditService ports.AuditService
+	cfg          *viper.Viper
+}
+
+func NewAuditHandler(AuditService ports.AuditService, cfg *viper.Viper) *AuditHandler {
+	return &AuditHandler{
+		AuditService: AuditService,
+		cfg:          cfg,
+	}
+}
+
+// ListEntries returns a page of the audit log, newest first. The log can be filtered by action,
+// actor, target_type, target_id, workspace_id, created_after and created_before.
+func (h *AuditHandler) ListEntries(c *fiber.Ctx) error {
+	filter, err := h.parseAuditFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+	filter.Limit = c.QueryInt("limit")
+
+	page, err := h.AuditService.ListEntries(c.UserContext(), filter, c.Query("cursor"))
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	response := dto.ResponseListAudit{
+		Items:      make([]dto.AuditEntry, 0, len(page.Items)),
+		NextCursor: page.NextCursor,
+	}
+	for _, entry := range page.Items {
+		response.Items = append(response.Items, dto.NewAuditEntry(entry))
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: response,
+	})
+}
+
+// ExportEntries streams every entry matching the filters of ListEntries as CSV or NDJSON.
+func (h *AuditHandler) ExportEntries(c *fiber.Ctx) error {
+	format := strings.ToLower(c.Query("format", "ndjson"))
+	if format != "csv" && format != "ndjson" {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "unsupported format, use csv or ndjson",
+		})
+	}
+
+	filter, err := h.parseAuditFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	if err = h.AuditService.Authorize(c.UserContext(), filter); err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+	principal := domain.PrincipalFromContext(c.UserContext())
+
+	var write func(w *bufio.Writer) func(*domain.AuditEntry) error
+	if format == "csv" {
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		write = writeAuditCSV
+	} else {
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+		write = writeAuditNDJSON
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="audit-%s.%s"`, time.Now().Format("20060102150405"), format))
+
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		timeout := extendWriteDeadline(h.cfg, conn)
+
+		// the request context is gone once the handler returns, the stream runs on its own
+		ctx, cancel := context.WithTimeout(domain.WithPrincipal(context.Background(), principal), timeout)
+		defer cancel()
+
+		if err := h.AuditService.ExportEntries(ctx, filter, write(w)); err != nil {
+			logger.L.Errorw("failed to export audit log", "error", err.Error())
+		}
+		_ = w.Flush()
+	})
+
+	return nil
+}
+
+func writeAuditCSV(w *bufio.Writer) func(*domain.AuditEntry) error {
+	writer := csv.NewWriter(w)
+	header := false
+
+	return func(entry *domain.AuditEntry) error {
+		if !header {
+			header = true
+			if err := writer.Write([]string{"id", "created_at", "action", "actor", "ip", "request_id", "target_type", "target_id", "workspace_id", "before", "after"}); err != nil {
+				return err
+			}
+		}
+
+		err := writer.Write([]string{
+			strconv.Itoa(entry.ID),
+			entry.CreatedAt.Format(time.RFC3339),
+			entry.Action,
+			entry.Actor,
+			entry.IP,
+			entry.RequestID,
+			entry.TargetType,
+			entry.TargetID,
+			strconv.Itoa(entry.WorkspaceID),
+			string(entry.Before),
+			string(entry.After),
+		})
+		if err != nil {
+			return err
+		}
+
+		writer.Flush()
+		return writer.Error()
+	}
+}
+
+func writeAuditNDJSON(w *bufio.Writer) func(*domain.AuditEntry) error {
+	return func(entry *domain.AuditEntry) error {
+		line, err := sonic.Marshal(dto.NewAuditEntry(entry))
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(line); err != nil {
+			return err
+		}
+		return w.WriteByte('\n')
+	}
+}
+
+func (h *AuditHandler) parseAuditFilter(c *fiber.Ctx) (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{
+		Action:      c.Query("action"),
+		Actor:       c.Query("actor"),
+		TargetType:  c.Query("target_type"),
+		TargetID:    c.Query("target_id"),
+		WorkspaceID: c.QueryInt("workspace_id"),
+	}
+	if filter.WorkspaceID < 0 {
+		return filter, errors.New("invalid workspace_id")
+	}
+
+	var err error
+	if value := c.Query("created_after"); value != "" {
+		if filter.CreatedAfter, err = parseTimeParam(h.cfg, value); err != nil {
+			return filter, errors.New("invalid created_after, use RFC3339 or YYYY-MM-DD")
+		}
+	}
+	if value := c.Query("created_before"); value != "" {
+		if filter.CreatedBefore, err = parseTimeParam(h.cfg, value); err != nil {
+			return filter, errors.New("invalid created_before, use RFC3339 or YYYY-MM-DD")
+		}
+	}
+
+	return filter, nil
+}
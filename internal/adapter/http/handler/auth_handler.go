@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// SessionCookie holds the session token of a user who logged in through the browser.
+const SessionCookie = "session"
+
+type AuthHandler struct {
+	UserService ports.UserService
+	cfg         *viper.Viper
+}
+
+func NewAuthHandler(UserService ports.UserService, cfg *viper.Viper) *AuthHandler {
+	return &AuthHandler{
+		UserService: UserService,
+		cfg:         cfg,
+	}
+}
+
+func (h *AuthHandler) Signup(c *fiber.Ctx) error {
+	if h.cfg.IsSet("security.allow_signup") && !h.cfg.GetBool("security.allow_signup") {
+		return c.Status(403).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "signup is disabled",
+		})
+	}
+
+	var request dto.RequestSignup
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err := pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	user, err := h.UserService.Signup(c.UserContext(), request.Email, request.Password)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(dto.ApiResponse{
+		Data: dto.NewUser(user),
+	})
+}
+
+// Login returns a session token for api clients and sets it as a cookie for browsers.
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	var request dto.RequestLogin
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err := pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	user, err := h.UserService.Login(c.UserContext(), request.Email, request.Password, c.IP())
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	ttl := h.cfg.GetDuration("security.session_ttl")
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	expires := time.Now().Add(ttl)
+	token := pkg.SignSessionToken(h.cfg.GetString("security.secret"), user.ID, expires)
+
+	c.Cookie(&fiber.Cookie{
+		Name:     SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   h.cfg.GetString("app.scheme") == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.JSON(dto.ApiResponse{
+		Data: dto.ResponseLogin{
+			User:      dto.NewUser(user),
+			Token:     token,
+			ExpiresAt: expires,
+		},
+	})
+}
+
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	c.ClearCookie(SessionCookie)
+
+	return c.JSON(dto.ApiResponse{
+		Message: "logged out",
+	})
+}
+
+func (h *AuthHandler) Me(c *fiber.Ctx) error {
+	user, err := h.UserService.GetUser(c.UserContext(), domain.PrincipalFromContext(c.UserContext()).UserID)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: dto.NewUser(user),
+	})
+}
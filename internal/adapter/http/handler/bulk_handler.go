@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/domain"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	bulkBatchSize    = 100
+	maxBulkRows      = 100000
+	maxNDJSONLine    = 1024 * 1024
+	bulkFormatCSV    = "csv"
+	bulkFormatNDJSON = "ndjson"
+)
+
+// bulkRow is a single record of an import file. Err is set when the record itself is malformed.
+type bulkRow struct {
+	Row     int
+	Request dto.RequestShortURL
+	Err     error
+}
+
+type bulkReader interface {
+	// Next returns the next record of the file, or io.EOF once the file is exhausted.
+	Next() (*bulkRow, error)
+}
+
+// BulkShortURL creates one shortcode per record of an uploaded CSV or NDJSON file. The file is
+// read as a stream and imported in batches, so only one batch is held in memory at a time.
+// The links are created in the workspace given by the workspace_id query parameter and on the
+// custom domain given by the domain query parameter, if any.
+func (h *URLHandler) BulkShortURL(c *fiber.Ctx) error {
+	owner := domain.LinkScope{
+		OwnerID:     domain.PrincipalFromContext(c.UserContext()).UserID,
+		WorkspaceID: c.QueryInt("workspace_id"),
+	}
+
+	source, format, err := bulkSource(c)
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+	defer source.Close()
+
+	var reader bulkReader
+	switch format {
+	case bulkFormatCSV:
+		reader, err = newCSVBulkReader(source)
+	case bulkFormatNDJSON:
+		reader = newNDJSONBulkReader(source)
+	default:
+		err = errors.New("unsupported file format, use csv or ndjson")
+	}
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	var result dto.ResponseBulkShortURL
+	batch := make([]*bulkRow, 0, bulkBatchSize)
+
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			h.importBulkBatch(c, owner, batch, &result)
+			return c.Status(400).JSON(dto.ApiResponse{
+				Error:   true,
+				Data:    result,
+				Message: fmt.Sprintf("failed to read row %d: %s", result.Total+1, err.Error()),
+			})
+		}
+
+		if result.Total >= maxBulkRows {
+			h.importBulkBatch(c, owner, batch, &result)
+			return c.Status(413).JSON(dto.ApiResponse{
+				Error:   true,
+				Data:    result,
+				Message: fmt.Sprintf("maximum %d rows per import!", maxBulkRows),
+			})
+		}
+		result.Total++
+
+		batch = append(batch, row)
+		if len(batch) == bulkBatchSize {
+			h.importBulkBatch(c, owner, batch, &result)
+			batch = batch[:0]
+		}
+	}
+	h.importBulkBatch(c, owner, batch, &result)
+
+	return c.JSON(dto.ApiResponse{
+		Data: result,
+	})
+}
+
+// importBulkBatch validates and saves a batch of rows, appending one result per row in order.
+func (h *URLHandler) importBulkBatch(c *fiber.Ctx, owner domain.LinkScope, batch []*bulkRow, result *dto.ResponseBulkShortURL) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var requests []domain.ShortenRequest
+	var valid []*bulkRow
+	for _, row := range batch {
+		if row.Err == nil && row.Request.Password != "" {
+			row.Err = errors.New("password is not supported in bulk import")
+		}
+		if row.Err == nil {
+			row.Err = validateShortURLRequest(&row.Request)
+		}
+		if row.Err == nil {
+			if row.Request.Domain == "" {
+				row.Request.Domain = c.Query("domain")
+			}
+			requests = append(requests, row.Request.ToDomain())
+			valid = append(valid, row)
+		}
+	}
+
+	created := make(map[*bulkRow]domain.BulkShortenResult, len(valid))
+	if len(requests) > 0 {
+		for i, res := range h.ShortenerService.BulkShortURL(c.UserContext(), owner, requests) {
+			created[valid[i]] = res
+		}
+	}
+
+	for _, row := range batch {
+		item := dto.BulkShortURLResult{Row: row.Row}
+
+		if res, ok := created[row]; ok {
+			row.Err = res.Err
+			if res.Err == nil {
+				item.Success = true
+				item.URL = shortLink(h.cfg, res.ShortCode.Code)
+			}
+		}
+		if row.Err != nil {
+			item.Error = row.Err.Error()
+			result.Failed++
+		} else {
+			result.Succeeded++
+		}
+
+		result.Results = append(result.Results, item)
+	}
+}
+
+// bulkSource returns the import file either from a multipart "file" field or from the raw request
+// body, together with its format. The format is taken from the "format" query parameter, falling
+// back to the content type and the file extension.
+func bulkSource(c *fiber.Ctx) (io.ReadCloser, string, error) {
+	format := strings.ToLower(c.Query("format"))
+
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", errors.New("file is required")
+		}
+		if format == "" {
+			format = bulkFormat(file.Header.Get(fiber.HeaderContentType), file.Filename)
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			return nil, "", errors.New("failed to open uploaded file")
+		}
+		return f, format, nil
+	}
+
+	if format == "" {
+		format = bulkFormat(c.Get(fiber.HeaderContentType), "")
+	}
+	if c.Request().IsBodyStream() {
+		return io.NopCloser(c.Context().RequestBodyStream()), format, nil
+	}
+
+	return io.NopCloser(bytes.NewReader(c.Body())), format, nil
+}
+
+func bulkFormat(contentType, filename string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return bulkFormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return bulkFormatNDJSON
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return bulkFormatCSV
+	case ".ndjson", ".jsonl":
+		return bulkFormatNDJSON
+	}
+
+	return ""
+}
+
+// csvBulkReader reads CSV files with a header row. The "urls" column is required and holds the
+// destinations separated by whitespace or "|", while "strategy" and "alias" are optional.
+type csvBulkReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	row     int
+}
+
+func newCSVBulkReader(r io.Reader) (*csvBulkReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("failed to read csv header")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["urls"]; !ok {
+		return nil, errors.New("csv header must contain a urls column")
+	}
+
+	return &csvBulkReader{
+		reader:  reader,
+		columns: columns,
+	}, nil
+}
+
+func (r *csvBulkReader) Next() (*bulkRow, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	r.row++
+
+	return &bulkRow{
+		Row: r.row,
+		Request: dto.RequestShortURL{
+			URL: strings.FieldsFunc(r.field(record, "urls"), func(ch rune) bool {
+				return ch == '|' || unicode.IsSpace(ch)
+			}),
+			Strategy: r.field(record, "strategy"),
+			Password: r.field(record, "password"),
+			Alias:    r.field(record, "alias"),
+		},
+	}, nil
+}
+
+func (r *csvBulkReader) field(record []string, name string) string {
+	i, ok := r.columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
+
+// ndjsonBulkReader reads one JSON object per line, using the same fields as POST /api/shorten.
+type ndjsonBulkReader struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+func newNDJSONBulkReader(r io.Reader) *ndjsonBulkReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+
+	return &ndjsonBulkReader{scanner: scanner}
+}
+
+func (r *ndjsonBulkReader) Next() (*bulkRow, error) {
+	for r.scanner.Scan() {
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		r.row++
+
+		row := &bulkRow{Row: r.row}
+		if err := sonic.Unmarshal(line, &row.Request); err != nil {
+			row.Err = errors.New("invalid json")
+		}
+		return row, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+type DomainHandler struct {
+	DomainService ports.DomainService
+	cfg           *viper.Viper
+}
+
+func NewDomainHandler(DomainService ports.DomainService, cfg *viper.Viper) *DomainHandler {
+	return &DomainHandler{
+		DomainService: DomainService,
+		cfg:           cfg,
+	}
+}
+
+func (h *DomainHandler) RegisterDomain(c *fiber.Ctx) error {
+	var request dto.RequestRegisterDomain
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err := pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	// the default domain serves every shortcode already
+	host, _ := domain.NormalizeHost(request.Host)
+	if defaultHost, _ := domain.NormalizeHost(h.cfg.GetString("app.domain")); host == defaultHost {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: domain.ErrInvalidDomain.Error(),
+		})
+	}
+
+	customDomain, err := h.DomainService.RegisterDomain(c.UserContext(), request.ToDomain())
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(dto.ApiResponse{
+		Data: dto.NewDomain(customDomain),
+	})
+}
+
+// ListDomains returns the domains of the workspace given by the workspace_id query parameter.
+func (h *DomainHandler) ListDomains(c *fiber.Ctx) error {
+	workspaceID := c.QueryInt("workspace_id")
+	if workspaceID < 0 {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid workspace_id",
+		})
+	}
+
+	domains, err := h.DomainService.ListDomains(c.UserContext(), workspaceID)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	items := make([]dto.Domain, 0, len(domains))
+	for _, customDomain := range domains {
+		items = append(items, dto.NewDomain(customDomain))
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: items,
+	})
+}
+
+func (h *DomainHandler) UpdateDomain(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid domain id",
+		})
+	}
+
+	var request dto.RequestUpdateDomain
+	if err = c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err = pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	customDomain, err := h.DomainService.UpdateDomain(c.UserContext(), id, request.ToDomain())
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: dto.NewDomain(customDomain),
+	})
+}
+
+func (h *DomainHandler) DeleteDomain(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid domain id",
+		})
+	}
+
+	if err = h.DomainService.DeleteDomain(c.UserContext(), id); err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Message: "domain deleted",
+	})
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"URLRotatorGo/infra/config"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// DefaultStreamTimeout is how long streamed responses may take to be written when
+// service.http.stream_timeout is not set.
+const DefaultStreamTimeout = time.Hour
+
+type ExportHandler struct {
+	ExportService ports.ExportService
+	cfg           *viper.Viper
+}
+
+func NewExportHandler(ExportService ports.ExportService, cfg *viper.Viper) *ExportHandler {
+	return &ExportHandler{
+		ExportService: ExportService,
+		cfg:           cfg,
+	}
+}
+
+// ExportLinks streams every shortcode with its destinations and hit counts as CSV or NDJSON.
+// CSV has one row per destination, NDJSON has one object per shortcode.
+func (h *ExportHandler) ExportLinks(c *fiber.Ctx) error {
+	format := strings.ToLower(c.Query("format", "csv"))
+	if format != "csv" && format != "ndjson" {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "unsupported format, use csv or ndjson",
+		})
+	}
+
+	filter, err := h.parseExportFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	if err = h.ExportService.Authorize(c.UserContext(), filter); err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+	principal := domain.PrincipalFromContext(c.UserContext())
+
+	var write func(w *bufio.Writer) func(*domain.ShortCodeExport) error
+	if format == "csv" {
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		write = h.writeCSV
+	} else {
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+		write = h.writeNDJSON
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="links-%s.%s"`, time.Now().Format("20060102150405"), format))
+
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		timeout := extendWriteDeadline(h.cfg, conn)
+
+		// the request context is gone once the handler returns, the stream runs on its own
+		ctx, cancel := context.WithTimeout(domain.WithPrincipal(context.Background(), principal), timeout)
+		defer cancel()
+
+		if err := h.ExportService.ExportShortCodes(ctx, filter, write(w)); err != nil {
+			logger.L.Errorw("failed to export links", "error", err.Error())
+		}
+		_ = w.Flush()
+	})
+
+	return nil
+}
+
+// extendWriteDeadline gives a streamed response service.http.stream_timeout to be written and
+// returns the timeout. All other responses keep the short write timeout of the server, which
+// protects it from slow clients.
+func extendWriteDeadline(cfg *viper.Viper, conn net.Conn) time.Duration {
+	timeout := cfg.GetDuration("service.http.stream_timeout")
+	if timeout <= 0 {
+		timeout = DefaultStreamTimeout
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(timeout))
+
+	return timeout
+}
+
+func (h *ExportHandler) writeCSV(w *bufio.Writer) func(*domain.ShortCodeExport) error {
+	writer := csv.NewWriter(w)
+	header := false
+
+	return func(data *domain.ShortCodeExport) error {
+		if !header {
+			header = true
+			if err := writer.Write([]string{"code", "url", "strategy", "total_hit", "created_at", "destination_id", "destination", "destination_enabled", "destination_total_hit"}); err != nil {
+				return err
+			}
+		}
+
+		_, code := domain.SplitLinkKey(data.Code)
+		record := []string{
+			code,
+			shortLink(h.cfg, data.Code),
+			string(data.Strategy),
+			strconv.Itoa(data.TotalHit),
+			data.CreatedAt.Format(time.RFC3339),
+			"", "", "", "",
+		}
+		if len(data.URLs) == 0 {
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		for _, url := range data.URLs {
+			record[5] = strconv.Itoa(url.ID)
+			record[6] = url.Original
+			record[7] = strconv.FormatBool(url.Enabled)
+			record[8] = strconv.Itoa(url.TotalHit)
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+
+		writer.Flush()
+		return writer.Error()
+	}
+}
+
+func (h *ExportHandler) writeNDJSON(w *bufio.Writer) func(*domain.ShortCodeExport) error {
+	return func(data *domain.ShortCodeExport) error {
+		line, err := sonic.Marshal(dto.NewExportedLink(data, shortLink(h.cfg, data.Code)))
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(line); err != nil {
+			return err
+		}
+		return w.WriteByte('\n')
+	}
+}
+
+func (h *ExportHandler) parseExportFilter(c *fiber.Ctx) (domain.ExportFilter, error) {
+	var filter domain.ExportFilter
+	var err error
+
+	if value := c.Query("created_after"); value != "" {
+		if filter.CreatedAfter, err = parseTimeParam(h.cfg, value); err != nil {
+			return filter, errors.New("invalid created_after, use RFC3339 or YYYY-MM-DD")
+		}
+	}
+	if value := c.Query("created_before"); value != "" {
+		if filter.CreatedBefore, err = parseTimeParam(h.cfg, value); err != nil {
+			return filter, errors.New("invalid created_before, use RFC3339 or YYYY-MM-DD")
+		}
+	}
+
+	if filter.WorkspaceID = c.QueryInt("workspace_id"); filter.WorkspaceID < 0 {
+		return filter, errors.New("invalid workspace_id")
+	}
+
+	switch strategy := domain.Strategy(strings.ToUpper(c.Query("strategy"))); strategy {
+	case "", domain.RoundRobin, domain.Random:
+		filter.Strategy = strategy
+	default:
+		return filter, errors.New("invalid strategy")
+	}
+
+	return filter, nil
+}
+
+// parseTimeParam accepts either a RFC3339 timestamp or a plain date in the configured app timezone.
+func parseTimeParam(cfg *viper.Viper, value string) (time.Time, error) {
+	return parseTimeIn(value, config.AppLocation(cfg))
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type deadlineConn struct {
+	net.Conn
+
+	deadline time.Time
+}
+
+func (c *deadlineConn) SetWriteDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
+
+func TestExtendWriteDeadline(t *testing.T) {
+	tests := map[string]struct {
+		configured string
+		want       time.Duration
+	}{
+		"configured": {"5m", 5 * time.Minute},
+		"default":    {"", DefaultStreamTimeout},
+		"invalid":    {"-1s", DefaultStreamTimeout},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := viper.New()
+			if test.configured != "" {
+				cfg.Set("service.http.stream_timeout", test.configured)
+			}
+			conn := &deadlineConn{}
+
+			started := time.Now()
+			if timeout := extendWriteDeadline(cfg, conn); timeout != test.want {
+				t.Errorf("extendWriteDeadline() = %s, want %s", timeout, test.want)
+			}
+			if conn.deadline.Before(started.Add(test.want)) {
+				t.Errorf("write deadline %s is earlier than %s from now", conn.deadline, test.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("app.scheme", "https")
+	cfg.Set("app.domain", "short.example")
+	h := NewExportHandler(nil, cfg)
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	write := h.writeCSV(w)
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	links := []*domain.ShortCodeExport{
+		{
+			ShortCode: domain.ShortCode{Code: "abc", Strategy: domain.RoundRobin, TotalHit: 3, CreatedAt: created},
+			URLs: []*domain.URL{
+				{ID: 1, Original: "https://a.example", Enabled: true, TotalHit: 2},
+				{ID: 2, Original: "https://b.example", TotalHit: 1},
+			},
+		},
+		{ShortCode: domain.ShortCode{Code: "empty", Strategy: domain.Random, CreatedAt: created}},
+	}
+	for _, link := range links {
+		if err := write(link); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+	}
+	_ = w.Flush()
+
+	want := "code,url,strategy,total_hit,created_at,destination_id,destination,destination_enabled,destination_total_hit\n" +
+		"abc,https://short.example/abc,RR,3,2024-05-01T12:00:00Z,1,https://a.example,true,2\n" +
+		"abc,https://short.example/abc,RR,3,2024-05-01T12:00:00Z,2,https://b.example,false,1\n" +
+		"empty,https://short.example/empty,RNDM,0,2024-05-01T12:00:00Z,,,,\n"
+	if buf.String() != want {
+		t.Errorf("writeCSV() wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+type LinkHandler struct {
+	LinkService ports.LinkService
+	cfg         *viper.Viper
+}
+
+func NewLinkHandler(LinkService ports.LinkService, cfg *viper.Viper) *LinkHandler {
+	return &LinkHandler{
+		LinkService: LinkService,
+		cfg:         cfg,
+	}
+}
+
+// ListLinks returns a page of shortcodes. Supported query parameters are q (search over the
+// destination URLs), tag, sort (created_at or hits), order (asc or desc), limit and cursor.
+func (h *LinkHandler) ListLinks(c *fiber.Ctx) error {
+	query := domain.LinkQuery{
+		Search: c.Query("q"),
+		Tag:    c.Query("tag"),
+		Sort:   domain.LinkSort(strings.ToLower(c.Query("sort", string(domain.SortByCreatedAt)))),
+		Asc:    strings.EqualFold(c.Query("order"), "asc"),
+		Limit:  c.QueryInt("limit"),
+		// without a workspace the personal links are listed
+		WorkspaceID: c.QueryInt("workspace_id"),
+	}
+	if query.Sort != domain.SortByCreatedAt && query.Sort != domain.SortByHits {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid sort, use created_at or hits",
+		})
+	}
+
+	page, err := h.LinkService.ListLinks(c.UserContext(), query, c.Query("cursor"))
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	response := dto.ResponseListLinks{
+		Items:      make([]dto.LinkItem, 0, len(page.Items)),
+		NextCursor: page.NextCursor,
+	}
+	for _, item := range page.Items {
+		response.Items = append(response.Items, dto.NewLinkItem(item, shortLink(h.cfg, item.Code)))
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: response,
+	})
+}
+
+func (h *LinkHandler) SetTags(c *fiber.Ctx) error {
+	var request dto.RequestSetTags
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err := pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	tags, err := h.LinkService.SetTags(c.UserContext(), linkKey(h.cfg, c), request.Tags)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: dto.RequestSetTags{Tags: tags},
+	})
+}
+
+func (h *LinkHandler) GetDestinations(c *fiber.Ctx) error {
+	links, err := h.LinkService.GetDestinations(c.UserContext(), linkKey(h.cfg, c))
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	destinations := make([]dto.Destination, 0, len(links))
+	for _, link := range links {
+		destinations = append(destinations, dto.NewDestination(link))
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: destinations,
+	})
+}
+
+func (h *LinkHandler) SetDestinationEnabled(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid destination id",
+		})
+	}
+
+	var request dto.RequestSetEnabled
+	if err = c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err = pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	link, err := h.LinkService.SetDestinationEnabled(c.UserContext(), linkKey(h.cfg, c), id, *request.Enabled)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: dto.NewDestination(link),
+	})
+}
+
+// UpdateLink changes the strategy, destinations or password of a shortcode. Destinations that are
+// kept keep their hit counts, an empty password removes the protection.
+func (h *LinkHandler) UpdateLink(c *fiber.Ctx) error {
+	var request dto.RequestUpdateLink
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err := pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+	if request.Password != nil && *request.Password != "" && len(*request.Password) < 4 {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "password must be at least 4 characters",
+		})
+	}
+
+	shortcode, err := h.LinkService.UpdateLink(c.UserContext(), linkKey(h.cfg, c), request.ToDomain())
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: dto.NewLinkItem(shortcode, shortLink(h.cfg, shortcode.Code)),
+	})
+}
+
+func (h *LinkHandler) ListRevisions(c *fiber.Ctx) error {
+	revisions, err := h.LinkService.ListRevisions(c.UserContext(), linkKey(h.cfg, c))
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	items := make([]dto.RevisionItem, 0, len(revisions))
+	for _, revision := range revisions {
+		items = append(items, dto.NewRevisionItem(revision))
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: items,
+	})
+}
+
+// RollbackLink restores the shortcode to the state recorded in an earlier revision, the rollback
+// itself is recorded as a new revision.
+func (h *LinkHandler) RollbackLink(c *fiber.Ctx) error {
+	number, err := c.ParamsInt("revision")
+	if err != nil || number < 1 {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid revision",
+		})
+	}
+
+	revision, err := h.LinkService.RollbackLink(c.UserContext(), linkKey(h.cfg, c), number)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+	if revision == nil {
+		return c.JSON(dto.ApiResponse{
+			Message: "nothing to roll back, the link already matches this revision",
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: dto.NewRevisionItem(revision),
+	})
+}
+
+// errorStatus maps domain errors to HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrDataNotFound):
+		return 404
+	case errors.Is(err, domain.ErrUnauthorized), errors.Is(err, domain.ErrInvalidCredentials):
+		return 401
+	case errors.Is(err, domain.ErrForbidden):
+		return 403
+	case errors.Is(err, domain.ErrCodeAlreadyExists), errors.Is(err, domain.ErrEmailAlreadyExists), errors.Is(err, domain.ErrLastOwner),
+		errors.Is(err, domain.ErrDomainAlreadyExists), errors.Is(err, domain.ErrDomainInUse):
+		return 409
+	case errors.Is(err, domain.ErrInvitationExpired):
+		return 410
+	case errors.Is(err, domain.ErrTooManyAttempts):
+		return 429
+	case errors.Is(err, domain.ErrInternalServerError):
+		return 500
+	default:
+		return 400
+	}
+}
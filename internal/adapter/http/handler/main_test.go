@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"URLRotatorGo/infra/logger"
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.L = zap.NewNop().Sugar()
+	// the views are loaded from ./public like in production
+	if err := os.Chdir("../../../.."); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/viper"
+)
+
+var (
+	DefaultQRSize   = 256
+	MinQRSize       = 64
+	MaxQRSize       = 2048
+	DefaultQRMargin = 4
+	MaxQRMargin     = 16
+)
+
+type QRHandler struct {
+	ShortenerService ports.ShortenerService
+	cfg              *viper.Viper
+
+	logoOnce sync.Once
+	logo     image.Image
+	logoHash string
+}
+
+func NewQRHandler(ShortenerService ports.ShortenerService, cfg *viper.Viper) *QRHandler {
+	return &QRHandler{
+		ShortenerService: ShortenerService,
+		cfg:              cfg,
+	}
+}
+
+// GetQRCode returns a QR code for the short URL. Supported query parameters are format (png or
+// svg), size in pixels, level (L, M, Q or H), margin in modules, fg and bg as hex colors and
+// logo=true to put the configured logo in the center.
+func (h *QRHandler) GetQRCode(c *fiber.Ctx) error {
+	shortcode, err := h.ShortenerService.GetShortCode(c.UserContext(), linkKey(h.cfg, c))
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	format := strings.ToLower(c.Query("format", "png"))
+	if format != "png" && format != "svg" {
+		return qrBadRequest(c, "invalid format, use png or svg")
+	}
+
+	opts := pkg.QROptions{
+		Size:   c.QueryInt("size", DefaultQRSize),
+		Margin: c.QueryInt("margin", DefaultQRMargin),
+	}
+	if opts.Size < MinQRSize || opts.Size > MaxQRSize {
+		return qrBadRequest(c, fmt.Sprintf("size must be between %d and %d", MinQRSize, MaxQRSize))
+	}
+	if opts.Margin < 0 || opts.Margin > MaxQRMargin {
+		return qrBadRequest(c, fmt.Sprintf("margin must be between 0 and %d", MaxQRMargin))
+	}
+
+	var ok bool
+	if opts.Level, ok = pkg.ParseQRLevel(c.Query("level", "M")); !ok {
+		return qrBadRequest(c, "invalid level, use L, M, Q or H")
+	}
+	if opts.Foreground, err = pkg.ParseHexColor(c.Query("fg", "000000")); err != nil {
+		return qrBadRequest(c, "invalid fg color")
+	}
+	if opts.Background, err = pkg.ParseHexColor(c.Query("bg", "ffffff")); err != nil {
+		return qrBadRequest(c, "invalid bg color")
+	}
+
+	logoHash := ""
+	if c.QueryBool("logo") {
+		if opts.Logo, logoHash = h.loadLogo(); opts.Logo == nil {
+			return qrBadRequest(c, "no logo is configured")
+		}
+		// the logo hides part of the code, only the higher levels can restore it
+		if opts.Level < qrcode.High {
+			opts.Level = qrcode.Highest
+		}
+	}
+
+	content := shortLink(h.cfg, shortcode.Code)
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%d|%d|%v|%v|%s",
+		content, format, opts.Size, opts.Level, opts.Margin, opts.Foreground, opts.Background, logoHash)))
+	etag := `"` + hex.EncodeToString(hash[:16]) + `"`
+
+	c.Set(fiber.HeaderETag, etag)
+	// the route is authenticated, so only the client may keep the code and shared caches must not
+	c.Set(fiber.HeaderCacheControl, "private, max-age=86400")
+	if strings.Contains(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		return c.SendStatus(304)
+	}
+
+	var body []byte
+	if format == "svg" {
+		c.Set(fiber.HeaderContentType, "image/svg+xml")
+		body, err = pkg.GenerateQRSVG(content, opts)
+	} else {
+		c.Set(fiber.HeaderContentType, "image/png")
+		body, err = pkg.GenerateQRPNG(content, opts)
+	}
+	if err != nil {
+		logger.L.Errorw("failed to generate qr code", "shortcode", shortcode.Code, "error", err.Error())
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.Status(500).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "failed to generate qr code",
+		})
+	}
+
+	return c.Send(body)
+}
+
+// loadLogo reads the image configured in qr.logo once and returns it with a hash of its content.
+func (h *QRHandler) loadLogo() (image.Image, string) {
+	h.logoOnce.Do(func() {
+		path := h.cfg.GetString("qr.logo")
+		if path == "" {
+			return
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logger.L.Errorw("failed to read qr logo", "path", path, "error", err.Error())
+			return
+		}
+
+		logo, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			logger.L.Errorw("failed to decode qr logo", "path", path, "error", err.Error())
+			return
+		}
+
+		hash := sha256.Sum256(data)
+		h.logo, h.logoHash = logo, hex.EncodeToString(hash[:8])
+	})
+
+	return h.logo, h.logoHash
+}
+
+func qrBadRequest(c *fiber.Ctx, message string) error {
+	return c.Status(400).JSON(dto.ApiResponse{
+		Error:   true,
+		Message: message,
+	})
+}
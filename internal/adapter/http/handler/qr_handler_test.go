@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+func newQRTestApp() *fiber.App {
+	cfg := viper.New()
+	cfg.Set("app.scheme", "https")
+	cfg.Set("app.domain", "example.com")
+	h := NewQRHandler(&fakeShortenerService{shortcodes: map[string]*domain.ShortCode{"spring": {Code: "spring"}}}, cfg)
+
+	app := fiber.New()
+	app.Get("/links/:code/qr", h.GetQRCode)
+	return app
+}
+
+func TestGetQRCode(t *testing.T) {
+	app := newQRTestApp()
+
+	response, err := app.Test(httptest.NewRequest("GET", "/links/spring/qr?format=svg&size=128", nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if response.StatusCode != 200 || response.Header.Get(fiber.HeaderContentType) != "image/svg+xml" {
+		t.Fatalf("status = %d, content type %s, want a svg", response.StatusCode, response.Header.Get(fiber.HeaderContentType))
+	}
+	// the route is authenticated, shared caches must not keep the response
+	if cacheControl := response.Header.Get(fiber.HeaderCacheControl); cacheControl != "private, max-age=86400" {
+		t.Errorf("Cache-Control = %q, want a private response", cacheControl)
+	}
+
+	request := httptest.NewRequest("GET", "/links/spring/qr?format=svg&size=128", nil)
+	request.Header.Set(fiber.HeaderIfNoneMatch, response.Header.Get(fiber.HeaderETag))
+	if response, err = app.Test(request); err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if response.StatusCode != 304 {
+		t.Errorf("status with a matching ETag = %d, want 304", response.StatusCode)
+	}
+}
+
+func TestGetQRCodeRejectsInvalidOptions(t *testing.T) {
+	app := newQRTestApp()
+
+	for _, query := range []string{"format=gif", "size=10", "size=5000", "margin=-1", "level=X", "fg=zzz", "bg=12", "logo=true"} {
+		t.Run(query, func(t *testing.T) {
+			response, err := app.Test(httptest.NewRequest("GET", "/links/spring/qr?"+query, nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if response.StatusCode != 400 {
+				t.Errorf("status = %d, want 400", response.StatusCode)
+			}
+		})
+	}
+
+	response, err := app.Test(httptest.NewRequest("GET", "/links/missing/qr", nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if response.StatusCode != 404 {
+		t.Errorf("status of an unknown shortcode = %d, want 404", response.StatusCode)
+	}
+}
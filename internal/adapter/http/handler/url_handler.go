@@ -1,42 +1,315 @@
 package handler
 
 import (
+	"URLRotatorGo/infra/config"
+	"URLRotatorGo/infra/logger"
 	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/domain"
 	"URLRotatorGo/internal/core/ports"
 	"URLRotatorGo/pkg"
+	"errors"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/spf13/viper"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const unlockCookiePrefix = "unlock_"
+
 type URLHandler struct {
 	ShortenerService ports.ShortenerService
+	DomainService    ports.DomainService
 	cfg              *viper.Viper
+	datacenters      pkg.IPRanges
 }
 
-func NewURLHandler(ShortenerService ports.ShortenerService, cfg *viper.Viper) *URLHandler {
+func NewURLHandler(ShortenerService ports.ShortenerService, DomainService ports.DomainService, cfg *viper.Viper) *URLHandler {
+	var datacenters pkg.IPRanges
+	if path := cfg.GetString("analytics.datacenter_ranges"); path != "" {
+		ranges, err := pkg.LoadIPRanges(path)
+		if err != nil {
+			logger.L.Warnw("failed to load datacenter ranges, they are not detected as bots", "path", path, "error", err.Error())
+		}
+		datacenters = ranges
+	}
+
 	return &URLHandler{
 		ShortenerService: ShortenerService,
+		DomainService:    DomainService,
 		cfg:              cfg,
+		datacenters:      datacenters,
 	}
 }
 
+// Index shows the index page, custom domains may send their visitors somewhere else instead.
 func (h *URLHandler) Index(c *fiber.Ctx) error {
+	if customDomain := h.customDomain(c); customDomain != nil && customDomain.RootRedirect != "" {
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+		return c.Redirect(customDomain.RootRedirect, 302)
+	}
+
 	return c.SendFile("./public/index.html")
 }
 
 func (h *URLHandler) RedirectToOriginal(c *fiber.Ctx) error {
-	code := c.Params("code")
+	code, customDomain := h.resolveLink(c)
 
-	redirectUrl, err := h.ShortenerService.GetRedirectURL(c.UserContext(), code)
+	shortcode, err := h.ShortenerService.GetShortCode(c.UserContext(), code)
 	if err != nil {
+		return h.notFound(c, customDomain, err)
+	}
+
+	if c.Query("preview") == "1" {
+		return h.renderPreview(c, shortcode)
+	}
+
+	// chat apps and social networks get the configured preview card instead of a random destination
+	if shortcode.Social.IsSet() && pkg.IsLinkUnfurler(c.Get(fiber.HeaderUserAgent)) {
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		return render(c, 200, "social.html", dto.SocialPage{
+			URL:         shortLink(h.cfg, code),
+			Title:       shortcode.Social.Title,
+			Description: shortcode.Social.Description,
+			Image:       shortcode.Social.Image,
+		})
+	}
+
+	if shortcode.IsProtected() && !h.isUnlocked(c, code) {
+		return render(c, 401, "password.html", dto.PasswordForm{Code: c.Params("code")})
+	}
+
+	redirectUrl, err := h.ShortenerService.GetRedirectURL(c.UserContext(), code, h.visit(c))
+	if err != nil {
+		return h.notFound(c, customDomain, err)
+	}
+
+	return h.redirect(c, shortcode.Redirect, redirectUrl)
+}
+
+// visit describes the visitor of the request, the country comes from the header the proxy in
+// front of the app sets, e.g. CF-IPCountry of Cloudflare.
+func (h *URLHandler) visit(c *fiber.Ctx) domain.Visit {
+	country := ""
+	if header := h.cfg.GetString("analytics.country_header"); header != "" {
+		country = strings.ToUpper(c.Get(header))
+		// Cloudflare uses XX for unknown and T1 for Tor exit nodes
+		if len(country) != 2 || country == "XX" || country == "T1" {
+			country = ""
+		}
+	}
+
+	userAgent := c.Get(fiber.HeaderUserAgent)
+
+	return domain.Visit{
+		IPHash:    pkg.HashIP(h.cfg.GetString("security.secret"), c.IP()),
+		UserAgent: userAgent,
+		Device:    pkg.DeviceType(userAgent),
+		Referrer:  c.Get(fiber.HeaderReferer),
+		Country:   country,
+		RequestID: domain.RequestInfoFromContext(c.UserContext()).RequestID,
+		Traffic:   h.traffic(c, userAgent),
+	}
+}
+
+// traffic classifies who is behind the request, only humans are counted as clicks.
+func (h *URLHandler) traffic(c *fiber.Ctx, userAgent string) domain.Traffic {
+	switch {
+	case pkg.IsPrefetch(c.Get("Sec-Purpose"), c.Get("Purpose"), c.Get("X-Moz")):
+		return domain.TrafficPrefetch
+	case pkg.IsKnownBot(userAgent):
+		return domain.TrafficBot
+	case pkg.IsHeadlessBrowser(userAgent), h.datacenters.Contains(c.IP()),
+		// every browser sends these, scripts and scanners often don't
+		userAgent == "", c.Get(fiber.HeaderAccept) == "", c.Get(fiber.HeaderAcceptLanguage) == "":
+		return domain.TrafficSuspectedBot
+	default:
+		return domain.TrafficHuman
+	}
+}
+
+// resolveLink returns the key of the requested shortcode and the custom domain it was requested
+// on. Requests for the default domain or for an unknown host return no domain.
+func (h *URLHandler) resolveLink(c *fiber.Ctx) (string, *domain.Domain) {
+	customDomain := h.customDomain(c)
+	if customDomain == nil {
+		return c.Params("code"), nil
+	}
+
+	return domain.LinkKey(customDomain.Host, c.Params("code")), customDomain
+}
+
+// customDomain returns the registered domain of the request host, nil for the default domain.
+func (h *URLHandler) customDomain(c *fiber.Ctx) *domain.Domain {
+	host, _ := domain.NormalizeHost(c.Hostname())
+	if defaultHost, _ := domain.NormalizeHost(h.cfg.GetString("app.domain")); host == defaultHost {
+		return nil
+	}
+
+	customDomain, err := h.DomainService.ResolveHost(c.UserContext(), host)
+	if err != nil {
+		return nil
+	}
+
+	return customDomain
+}
+
+// notFound sends visitors of an unknown shortcode to the 404 page of the custom domain, if it has one.
+func (h *URLHandler) notFound(c *fiber.Ctx, customDomain *domain.Domain, err error) error {
+	if customDomain != nil && customDomain.NotFoundURL != "" {
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+		return c.Redirect(customDomain.NotFoundURL, 302)
+	}
+
+	return c.Status(404).JSON(dto.ApiResponse{
+		Error:   true,
+		Message: err.Error(),
+	})
+}
+
+// redirect sends the visitor to the destination the way the shortcode is configured to. The
+// responses must never be cached, otherwise the browser keeps going to the same destination.
+func (h *URLHandler) redirect(c *fiber.Ctx, redirect domain.Redirect, destination string) error {
+	// HTML redirects only make sense for web pages, anything else falls back to a plain redirect
+	if redirect.IsPage() && !strings.HasPrefix(destination, "http://") && !strings.HasPrefix(destination, "https://") {
+		redirect = domain.Redirect{Type: domain.DefaultRedirectType}
+	}
+
+	switch redirect.Type {
+	case domain.RedirectMovedPermanently, domain.RedirectPermanent:
+		// browsers keep permanent redirects forever unless they are told not to store them
+		c.Set(fiber.HeaderCacheControl, "no-store, max-age=0")
+		status, _ := strconv.Atoi(string(redirect.Type))
+		return c.Redirect(destination, status)
+	case domain.RedirectTemporary:
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+		return c.Redirect(destination, 307)
+	case domain.RedirectMetaRefresh, domain.RedirectJavaScript:
+		c.Set(fiber.HeaderCacheControl, "no-store, max-age=0")
+		return render(c, 200, "redirect.html", dto.RedirectPage{
+			URL:        destination,
+			Delay:      redirect.Delay,
+			JavaScript: redirect.Type == domain.RedirectJavaScript,
+		})
+	default:
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+		return c.Redirect(destination, 302)
+	}
+}
+
+// PreviewShortCode shows where a shortcode leads instead of redirecting, it is served on /:code+
+// and on /:code?preview=1. Previews don't count as hits.
+func (h *URLHandler) PreviewShortCode(c *fiber.Ctx) error {
+	code, customDomain := h.resolveLink(c)
+
+	shortcode, err := h.ShortenerService.GetShortCode(c.UserContext(), code)
+	if err != nil {
+		return h.notFound(c, customDomain, err)
+	}
+
+	return h.renderPreview(c, shortcode)
+}
+
+// renderPreview lists the destinations currently in rotation. Destinations of a protected
+// shortcode stay hidden until the visitor unlocked it.
+func (h *URLHandler) renderPreview(c *fiber.Ctx, shortcode *domain.ShortCode) error {
+	_, code := domain.SplitLinkKey(shortcode.Code)
+	page := dto.PreviewPage{
+		Code:      code,
+		URL:       shortLink(h.cfg, shortcode.Code),
+		Strategy:  string(shortcode.Strategy),
+		CreatedAt: shortcode.CreatedAt.In(config.AppLocation(h.cfg)).Format("02 Jan 2006 15:04 MST"),
+		Protected: shortcode.IsProtected() && !h.isUnlocked(c, shortcode.Code),
+	}
+
+	if !page.Protected {
+		links, err := h.ShortenerService.GetLinks(c.UserContext(), shortcode.Code)
+		if err != nil && !errors.Is(err, domain.ErrDataNotFound) {
+			return c.Status(500).JSON(dto.ApiResponse{
+				Error:   true,
+				Message: err.Error(),
+			})
+		}
+		for _, link := range links {
+			if link.Enabled {
+				page.Destinations = append(page.Destinations, link.Original)
+			}
+		}
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return render(c, 200, "preview.html", page)
+}
+
+func (h *URLHandler) UnlockShortCode(c *fiber.Ctx) error {
+	code, _ := h.resolveLink(c)
+	slug := c.Params("code")
+
+	err := h.ShortenerService.UnlockShortCode(c.UserContext(), code, c.FormValue("password"), c.IP())
+	switch {
+	case errors.Is(err, domain.ErrDataNotFound):
 		return c.Status(404).JSON(dto.ApiResponse{
 			Error:   true,
 			Message: err.Error(),
 		})
+	case errors.Is(err, domain.ErrTooManyAttempts):
+		return render(c, 429, "password.html", dto.PasswordForm{
+			Code:  slug,
+			Error: "too many failed attempts, please try again later",
+		})
+	case errors.Is(err, domain.ErrInvalidPassword):
+		return render(c, 401, "password.html", dto.PasswordForm{
+			Code:  slug,
+			Error: "invalid password",
+		})
+	case err != nil:
+		return c.Status(500).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
 	}
 
-	return c.Redirect(redirectUrl, 302)
+	ttl := h.cfg.GetDuration("security.unlock_ttl")
+	if ttl <= 0 {
+		ttl = 15 * time.Minute
+	}
+	expires := time.Now().Add(ttl)
+
+	// cookies are scoped to the host already, the signed value carries the key so that a cookie
+	// can't be replayed on another domain with the same code
+	c.Cookie(&fiber.Cookie{
+		Name:     unlockCookiePrefix + slug,
+		Value:    pkg.SignValue(h.cfg.GetString("security.secret"), code+"|"+strconv.FormatInt(expires.Unix(), 10)),
+		Path:     "/" + slug,
+		Expires:  expires,
+		Secure:   h.cfg.GetString("app.scheme") == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.Redirect("/"+slug, 303)
+}
+
+// isUnlocked checks the signed cookie issued by UnlockShortCode.
+func (h *URLHandler) isUnlocked(c *fiber.Ctx, code string) bool {
+	_, slug := domain.SplitLinkKey(code)
+	value, ok := pkg.VerifySignedValue(h.cfg.GetString("security.secret"), c.Cookies(unlockCookiePrefix+slug))
+	if !ok {
+		return false
+	}
+
+	cookieCode, expiresStr, found := strings.Cut(value, "|")
+	if !found || cookieCode != code {
+		return false
+	}
+
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return time.Now().Unix() < expires
 }
 
 func (h *URLHandler) ShortURL(c *fiber.Ctx) error {
@@ -48,33 +321,68 @@ func (h *URLHandler) ShortURL(c *fiber.Ctx) error {
 		response.Message = "url cannot be empty"
 		return c.JSON(response)
 	}
-	if len(request.URL) < 1 {
+
+	if err := validateShortURLRequest(&request); err != nil {
 		response.Error = true
-		response.Message = "url cannot be empty"
+		response.Message = err.Error()
 		return c.JSON(response)
 	}
-	if len(request.URL) > 100 {
-		response.Error = true
-		response.Message = "maximum 100 url per request!"
-		return c.JSON(response)
+
+	shortenRequest := request.ToDomain()
+	shortenRequest.Deduplicate = h.cfg.GetBool("shortener.deduplicate")
+	if request.Deduplicate != nil {
+		shortenRequest.Deduplicate = *request.Deduplicate
 	}
 
-	if err := pkg.ValidateRequest(&request); err != nil {
-		response.Error = true
-		response.Message = err.Error()
-		return c.JSON(response)
+	owner := domain.LinkScope{
+		OwnerID:     domain.PrincipalFromContext(c.UserContext()).UserID,
+		WorkspaceID: request.WorkspaceID,
 	}
 
-	result, err := h.ShortenerService.ShortURL(c.UserContext(), request.URL, request.Strategy)
+	result, err := h.ShortenerService.ShortURL(c.UserContext(), owner, shortenRequest)
 	if err != nil {
 		response.Error = true
 		response.Message = err.Error()
+		return c.JSON(response)
 	}
 
 	response.Data = dto.ResponseShortURL{
-		URL:       fmt.Sprintf("%s://%s/%s", h.cfg.GetString("app.scheme"), h.cfg.GetString("app.domain"), result.Code),
+		URL:       shortLink(h.cfg, result.Code),
 		Strategy:  string(result.Strategy),
+		Protected: result.IsProtected(),
 		CreatedAt: result.CreatedAt,
 	}
 	return c.JSON(response)
 }
+
+// shortLink builds the public URL of a shortcode key, on its custom domain if it has one.
+func shortLink(cfg *viper.Viper, key string) string {
+	host, code := domain.SplitLinkKey(key)
+	if host == "" {
+		host = cfg.GetString("app.domain")
+	}
+
+	return fmt.Sprintf("%s://%s/%s", cfg.GetString("app.scheme"), host, code)
+}
+
+// linkKey returns the key of the shortcode in the path, the domain query parameter selects the
+// shortcode of a custom domain.
+func linkKey(cfg *viper.Viper, c *fiber.Ctx) string {
+	host, _ := domain.NormalizeHost(c.Query("domain"))
+	if defaultHost, _ := domain.NormalizeHost(cfg.GetString("app.domain")); host == defaultHost {
+		host = ""
+	}
+
+	return domain.LinkKey(host, c.Params("code"))
+}
+
+func validateShortURLRequest(request *dto.RequestShortURL) error {
+	if len(request.URL) < 1 {
+		return errors.New("url cannot be empty")
+	}
+	if len(request.URL) > 100 {
+		return errors.New("maximum 100 url per request!")
+	}
+
+	return pkg.ValidateRequest(request)
+}
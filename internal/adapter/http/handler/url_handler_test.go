@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+type fakeShortenerService struct {
+	ports.ShortenerService
+
+	shortcodes map[string]*domain.ShortCode
+	links      map[string][]*domain.URL
+	redirects  int
+	visits     []domain.Visit
+}
+
+func (s *fakeShortenerService) GetShortCode(ctx context.Context, code string) (*domain.ShortCode, error) {
+	shortcode, ok := s.shortcodes[code]
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return shortcode, nil
+}
+
+func (s *fakeShortenerService) GetLinks(ctx context.Context, code string) ([]*domain.URL, error) {
+	return s.links[code], nil
+}
+
+func (s *fakeShortenerService) GetRedirectURL(ctx context.Context, code string, visit domain.Visit) (string, error) {
+	s.redirects++
+	s.visits = append(s.visits, visit)
+	return s.links[code][0].Original, nil
+}
+
+func newURLTestApp(service ports.ShortenerService) *fiber.App {
+	cfg := viper.New()
+	cfg.Set("app.scheme", "https")
+	cfg.Set("app.domain", "example.com")
+	cfg.Set("security.secret", "test-secret")
+	cfg.Set("analytics.country_header", "CF-IPCountry")
+	h := NewURLHandler(service, nil, cfg)
+
+	app := fiber.New()
+	app.Get("/:code\\+", h.PreviewShortCode)
+	app.Get("/:code", h.RedirectToOriginal)
+	return app
+}
+
+func TestPreviewShortCode(t *testing.T) {
+	service := &fakeShortenerService{
+		shortcodes: map[string]*domain.ShortCode{
+			"spring": {Code: "spring", Strategy: domain.RoundRobin, CreatedAt: time.Now()},
+			"secret": {Code: "secret", Strategy: domain.RoundRobin, PasswordHash: "hash", CreatedAt: time.Now()},
+		},
+		links: map[string][]*domain.URL{
+			"spring": {{Original: "https://a.example", Enabled: true}, {Original: "https://disabled.example"}},
+			"secret": {{Original: "https://hidden.example", Enabled: true}},
+		},
+	}
+	app := newURLTestApp(service)
+
+	tests := map[string]struct {
+		path    string
+		status  int
+		want    []string
+		notWant []string
+	}{
+		"plus suffix":   {"/spring+", 200, []string{"https://a.example"}, []string{"https://disabled.example"}},
+		"query":         {"/spring?preview=1", 200, []string{"https://a.example"}, []string{"https://disabled.example"}},
+		"protected":     {"/secret+", 200, nil, []string{"https://hidden.example"}},
+		"unknown":       {"/missing+", 404, nil, nil},
+		"unknown query": {"/missing?preview=1", 404, nil, nil},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			response, err := app.Test(httptest.NewRequest("GET", test.path, nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if response.StatusCode != test.status {
+				t.Fatalf("status = %d, want %d", response.StatusCode, test.status)
+			}
+
+			body, _ := io.ReadAll(response.Body)
+			for _, want := range test.want {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("preview doesn't show %s", want)
+				}
+			}
+			for _, notWant := range test.notWant {
+				if strings.Contains(string(body), notWant) {
+					t.Errorf("preview shows %s", notWant)
+				}
+			}
+		})
+	}
+
+	if service.redirects != 0 {
+		t.Errorf("previews counted %d hits, want none", service.redirects)
+	}
+}
+
+func TestRedirectServesSocialPreviewToUnfurlers(t *testing.T) {
+	service := &fakeShortenerService{
+		shortcodes: map[string]*domain.ShortCode{
+			"spring": {Code: "spring", Social: domain.SocialMeta{Title: "Spring <sale>", Image: "https://img.example/s.png"}},
+			"plain":  {Code: "plain"},
+		},
+		links: map[string][]*domain.URL{
+			"spring": {{Original: "https://a.example", Enabled: true}},
+			"plain":  {{Original: "https://b.example", Enabled: true}},
+		},
+	}
+	app := newURLTestApp(service)
+	slack := "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"
+
+	request := httptest.NewRequest("GET", "/spring", nil)
+	request.Header.Set(fiber.HeaderUserAgent, slack)
+	response, err := app.Test(request)
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != 200 {
+		t.Fatalf("status for an unfurler = %d, want the preview card", response.StatusCode)
+	}
+	for _, want := range []string{
+		`<meta property="og:title" content="Spring &lt;sale&gt;">`,
+		`<meta property="og:image" content="https://img.example/s.png">`,
+		`<meta property="og:url" content="https://example.com/spring">`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("preview card misses %s", want)
+		}
+	}
+	if service.redirects != 0 {
+		t.Error("the unfurler was counted as a hit")
+	}
+
+	for name, request := range map[string]func() *http.Request{
+		"browser": func() *http.Request {
+			request := httptest.NewRequest("GET", "/spring", nil)
+			request.Header.Set(fiber.HeaderUserAgent, "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0")
+			return request
+		},
+		"no social metadata": func() *http.Request {
+			request := httptest.NewRequest("GET", "/plain", nil)
+			request.Header.Set(fiber.HeaderUserAgent, slack)
+			return request
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			response, err := app.Test(request())
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if response.StatusCode != 302 {
+				t.Errorf("status = %d, want a redirect", response.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRedirectTypes(t *testing.T) {
+	tests := map[string]struct {
+		redirect     domain.Redirect
+		destination  string
+		status       int
+		cacheControl string
+		body         string
+	}{
+		"found":                {domain.Redirect{Type: domain.RedirectFound}, "https://a.example", 302, "private, no-cache", ""},
+		"moved permanently":    {domain.Redirect{Type: domain.RedirectMovedPermanently}, "https://a.example", 301, "no-store, max-age=0", ""},
+		"permanent":            {domain.Redirect{Type: domain.RedirectPermanent}, "https://a.example", 308, "no-store, max-age=0", ""},
+		"temporary":            {domain.Redirect{Type: domain.RedirectTemporary}, "https://a.example", 307, "private, no-cache", ""},
+		"meta refresh":         {domain.Redirect{Type: domain.RedirectMetaRefresh, Delay: 3}, "https://a.example", 200, "no-store, max-age=0", "https://a.example"},
+		"javascript":           {domain.Redirect{Type: domain.RedirectJavaScript}, "https://a.example", 200, "no-store, max-age=0", "https://a.example"},
+		"page to other scheme": {domain.Redirect{Type: domain.RedirectMetaRefresh}, "mailto:a@example.com", 302, "private, no-cache", ""},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := newURLTestApp(&fakeShortenerService{
+				shortcodes: map[string]*domain.ShortCode{"spring": {Code: "spring", Redirect: test.redirect}},
+				links:      map[string][]*domain.URL{"spring": {{Original: test.destination, Enabled: true}}},
+			})
+
+			response, err := app.Test(httptest.NewRequest("GET", "/spring", nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if response.StatusCode != test.status {
+				t.Errorf("status = %d, want %d", response.StatusCode, test.status)
+			}
+			if cacheControl := response.Header.Get(fiber.HeaderCacheControl); cacheControl != test.cacheControl {
+				t.Errorf("Cache-Control = %q, want %q", cacheControl, test.cacheControl)
+			}
+			if test.status != 200 && response.Header.Get(fiber.HeaderLocation) != test.destination {
+				t.Errorf("Location = %q, want %q", response.Header.Get(fiber.HeaderLocation), test.destination)
+			}
+			body, _ := io.ReadAll(response.Body)
+			if !strings.Contains(string(body), test.body) {
+				t.Errorf("redirect page misses %s", test.body)
+			}
+		})
+	}
+}
+
+func TestRedirectRecordsVisit(t *testing.T) {
+	tests := map[string]struct {
+		country string
+		want    string
+	}{
+		"country":   {"de", "DE"},
+		"unknown":   {"XX", ""},
+		"tor":       {"T1", ""},
+		"malformed": {"DEU", ""},
+		"missing":   {"", ""},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := &fakeShortenerService{
+				shortcodes: map[string]*domain.ShortCode{"spring": {Code: "spring"}},
+				links:      map[string][]*domain.URL{"spring": {{Original: "https://a.example", Enabled: true}}},
+			}
+			app := newURLTestApp(service)
+
+			request := httptest.NewRequest("GET", "/spring", nil)
+			request.Header.Set(fiber.HeaderUserAgent, "Mozilla/5.0 (X11; Linux x86_64) Firefox/130.0")
+			request.Header.Set(fiber.HeaderReferer, "https://news.example/")
+			request.Header.Set("CF-IPCountry", test.country)
+			if _, err := app.Test(request); err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+
+			if len(service.visits) != 1 {
+				t.Fatalf("recorded %d visits, want 1", len(service.visits))
+			}
+			visit := service.visits[0]
+			if visit.Country != test.want {
+				t.Errorf("Country = %q, want %q", visit.Country, test.want)
+			}
+			// only the hash of the address is kept
+			if visit.IPHash != pkg.HashIP("test-secret", "0.0.0.0") {
+				t.Errorf("IPHash = %q, want the hash of the address", visit.IPHash)
+			}
+			if visit.Referrer != "https://news.example/" || !strings.HasPrefix(visit.UserAgent, "Mozilla/5.0") {
+				t.Errorf("visit = %+v, want the referrer and user agent of the request", visit)
+			}
+		})
+	}
+}
+
+func TestRedirectClassifiesTraffic(t *testing.T) {
+	firefox := "Mozilla/5.0 (X11; Linux x86_64; rv:130.0) Gecko/20100101 Firefox/130.0"
+	browser := map[string]string{fiber.HeaderUserAgent: firefox, fiber.HeaderAccept: "text/html", fiber.HeaderAcceptLanguage: "en"}
+	with := func(headers map[string]string, key, value string) map[string]string {
+		changed := map[string]string{key: value}
+		for k, v := range headers {
+			if k != key {
+				changed[k] = v
+			}
+		}
+		return changed
+	}
+
+	tests := map[string]struct {
+		headers     map[string]string
+		datacenters string
+		want        domain.Traffic
+	}{
+		"browser":            {browser, "", domain.TrafficHuman},
+		"prefetch":           {with(browser, "Sec-Purpose", "prefetch"), "", domain.TrafficPrefetch},
+		"crawler":            {with(browser, fiber.HeaderUserAgent, "Mozilla/5.0 (compatible; Googlebot/2.1)"), "", domain.TrafficBot},
+		"script":             {with(browser, fiber.HeaderUserAgent, "curl/8.4.0"), "", domain.TrafficBot},
+		"headless browser":   {with(browser, fiber.HeaderUserAgent, "Mozilla/5.0 HeadlessChrome/120.0"), "", domain.TrafficSuspectedBot},
+		"no accept-language": {with(browser, fiber.HeaderAcceptLanguage, ""), "", domain.TrafficSuspectedBot},
+		"no user agent":      {with(browser, fiber.HeaderUserAgent, ""), "", domain.TrafficSuspectedBot},
+		"datacenter address": {browser, "0.0.0.0/8\n", domain.TrafficSuspectedBot},
+		"other datacenters":  {browser, "3.0.0.0/9\n", domain.TrafficHuman},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := viper.New()
+			cfg.Set("app.domain", "example.com")
+			cfg.Set("security.secret", "test-secret")
+			if test.datacenters != "" {
+				path := filepath.Join(t.TempDir(), "ranges.txt")
+				if err := os.WriteFile(path, []byte(test.datacenters), 0o600); err != nil {
+					t.Fatalf("WriteFile() error = %v", err)
+				}
+				cfg.Set("analytics.datacenter_ranges", path)
+			}
+			service := &fakeShortenerService{
+				shortcodes: map[string]*domain.ShortCode{"spring": {Code: "spring"}},
+				links:      map[string][]*domain.URL{"spring": {{Original: "https://a.example", Enabled: true}}},
+			}
+			h := NewURLHandler(service, nil, cfg)
+			app := fiber.New()
+			app.Get("/:code", h.RedirectToOriginal)
+
+			request := httptest.NewRequest("GET", "/spring", nil)
+			for key, value := range test.headers {
+				request.Header.Set(key, value)
+			}
+			if _, err := app.Test(request); err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+
+			if len(service.visits) != 1 || service.visits[0].Traffic != test.want {
+				t.Errorf("visits = %+v, want traffic %q", service.visits, test.want)
+			}
+		})
+	}
+}
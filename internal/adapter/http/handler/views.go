@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"bytes"
+	"html/template"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	viewsOnce sync.Once
+	views     *template.Template
+	viewsErr  error
+)
+
+// render executes one of the HTML templates stored under ./public.
+func render(c *fiber.Ctx, status int, name string, data any) error {
+	viewsOnce.Do(func() {
+		views, viewsErr = template.ParseGlob("./public/*.html")
+	})
+	if viewsErr != nil {
+		return viewsErr
+	}
+
+	var buf bytes.Buffer
+	if err := views.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(status).Send(buf.Bytes())
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookHandler struct {
+	WebhookService ports.WebhookService
+}
+
+func NewWebhookHandler(WebhookService ports.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		WebhookService: WebhookService,
+	}
+}
+
+func (h *WebhookHandler) RegisterWebhook(c *fiber.Ctx) error {
+	var request dto.RequestRegisterWebhook
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err := pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	webhook, err := h.WebhookService.RegisterWebhook(c.UserContext(), request.ToDomain())
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(dto.ApiResponse{
+		Data: dto.ResponseRegisterWebhook{
+			Webhook: dto.NewWebhook(webhook),
+			Secret:  webhook.Secret,
+		},
+		Message: "store this secret now, it can't be shown again",
+	})
+}
+
+// ListWebhooks returns the webhooks of the workspace given by the workspace_id query parameter.
+func (h *WebhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	workspaceID := c.QueryInt("workspace_id")
+	if workspaceID < 0 {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid workspace_id",
+		})
+	}
+
+	webhooks, err := h.WebhookService.ListWebhooks(c.UserContext(), workspaceID)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	items := make([]dto.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		items = append(items, dto.NewWebhook(webhook))
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: items,
+	})
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid webhook id",
+		})
+	}
+
+	var request dto.RequestUpdateWebhook
+	if err = c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err = pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	webhook, err := h.WebhookService.UpdateWebhook(c.UserContext(), id, request.ToDomain())
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: dto.NewWebhook(webhook),
+	})
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid webhook id",
+		})
+	}
+
+	if err = h.WebhookService.DeleteWebhook(c.UserContext(), id); err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Message: "webhook deleted",
+	})
+}
+
+// ListDeliveries returns the delivery log of a webhook newest first. The before query parameter
+// continues the listing below the delivery with the given id, limit sets the page size.
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid webhook id",
+		})
+	}
+
+	var before int64
+	if value := c.Query("before"); value != "" {
+		if before, err = strconv.ParseInt(value, 10, 64); err != nil || before < 0 {
+			return c.Status(400).JSON(dto.ApiResponse{
+				Error:   true,
+				Message: "invalid before",
+			})
+		}
+	}
+
+	deliveries, err := h.WebhookService.ListDeliveries(c.UserContext(), id, before, c.QueryInt("limit"))
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	items := make([]dto.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		items = append(items, dto.NewWebhookDelivery(delivery))
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: items,
+	})
+}
+
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid webhook id",
+		})
+	}
+	deliveryID, err := strconv.ParseInt(c.Params("delivery"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid delivery id",
+		})
+	}
+
+	delivery, err := h.WebhookService.Redeliver(c.UserContext(), id, deliveryID)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.Status(202).JSON(dto.ApiResponse{
+		Data: dto.NewWebhookDelivery(delivery),
+	})
+}
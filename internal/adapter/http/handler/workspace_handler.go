@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WorkspaceHandler struct {
+	WorkspaceService ports.WorkspaceService
+}
+
+func NewWorkspaceHandler(WorkspaceService ports.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		WorkspaceService: WorkspaceService,
+	}
+}
+
+func (h *WorkspaceHandler) CreateWorkspace(c *fiber.Ctx) error {
+	var request dto.RequestCreateWorkspace
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err := pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	workspace, err := h.WorkspaceService.CreateWorkspace(c.UserContext(), request.Name)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(dto.ApiResponse{
+		Data: dto.NewWorkspace(workspace),
+	})
+}
+
+func (h *WorkspaceHandler) ListWorkspaces(c *fiber.Ctx) error {
+	workspaces, err := h.WorkspaceService.ListWorkspaces(c.UserContext())
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	items := make([]dto.Workspace, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		items = append(items, dto.NewWorkspace(workspace))
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: items,
+	})
+}
+
+func (h *WorkspaceHandler) ListMembers(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid workspace id",
+		})
+	}
+
+	members, err := h.WorkspaceService.ListMembers(c.UserContext(), id)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	items := make([]dto.Member, 0, len(members))
+	for _, member := range members {
+		items = append(items, dto.NewMember(member))
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: items,
+	})
+}
+
+func (h *WorkspaceHandler) SetMemberRole(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid workspace id",
+		})
+	}
+	userID, err := c.ParamsInt("user")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid user id",
+		})
+	}
+
+	var request dto.RequestSetMemberRole
+	if err = c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err = pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	if err = h.WorkspaceService.SetMemberRole(c.UserContext(), id, userID, request.Role); err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Message: "role updated",
+	})
+}
+
+func (h *WorkspaceHandler) RemoveMember(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid workspace id",
+		})
+	}
+	userID, err := c.ParamsInt("user")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid user id",
+		})
+	}
+
+	if err = h.WorkspaceService.RemoveMember(c.UserContext(), id, userID); err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Message: "member removed",
+	})
+}
+
+func (h *WorkspaceHandler) InviteMember(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid workspace id",
+		})
+	}
+
+	var request dto.RequestInviteMember
+	if err = c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err = pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	invitation, token, err := h.WorkspaceService.InviteMember(c.UserContext(), id, request.Email, request.Role)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(dto.ApiResponse{
+		Data: dto.ResponseInviteMember{
+			Invitation: dto.NewInvitation(invitation),
+			Token:      token,
+		},
+		Message: "share this token with the invited user, it can't be shown again",
+	})
+}
+
+func (h *WorkspaceHandler) ListInvitations(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid workspace id",
+		})
+	}
+
+	invitations, err := h.WorkspaceService.ListInvitations(c.UserContext(), id)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	items := make([]dto.Invitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		items = append(items, dto.NewInvitation(invitation))
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data: items,
+	})
+}
+
+func (h *WorkspaceHandler) RevokeInvitation(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid workspace id",
+		})
+	}
+	invitationID, err := c.ParamsInt("invitation")
+	if err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid invitation id",
+		})
+	}
+
+	if err = h.WorkspaceService.RevokeInvitation(c.UserContext(), id, invitationID); err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Message: "invitation revoked",
+	})
+}
+
+func (h *WorkspaceHandler) AcceptInvitation(c *fiber.Ctx) error {
+	var request dto.RequestAcceptInvitation
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "invalid request body",
+		})
+	}
+	if err := pkg.ValidateRequest(&request); err != nil {
+		return c.Status(400).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	invitation, err := h.WorkspaceService.AcceptInvitation(c.UserContext(), request.Token)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(dto.ApiResponse{
+		Data:    dto.NewInvitation(invitation),
+		Message: "invitation accepted",
+	})
+}
@@ -0,0 +1,273 @@
+package http
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/internal/adapter/http/handler"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+var (
+	IdempotencyHeader    = "Idempotency-Key"
+	IdempotencyTTL       = 24 * time.Hour
+	IdempotencyLockTTL   = time.Minute
+	MaxIdempotencyKeyLen = 255
+)
+
+// withActor records who is making the request, so that revisions and other records know who made
+// a change. Until requests are authenticated the client IP is the best we have. The IP and the
+// request id are kept for the audit log as well.
+func withActor(c *fiber.Ctx) error {
+	requestID, _ := c.Locals(requestid.ConfigDefault.ContextKey).(string)
+
+	ctx := domain.WithActor(c.UserContext(), "ip:"+c.IP())
+	c.SetUserContext(domain.WithRequestInfo(ctx, domain.RequestInfo{IP: c.IP(), RequestID: requestID}))
+	return c.Next()
+}
+
+// authenticate resolves the Authorization: Bearer api key or session token, or the session cookie,
+// into the principal of the request. Requests without credentials get the anonymous scopes,
+// requests with invalid credentials are rejected.
+func authenticate(service ports.APIKeyService, secret string, anonymousScopes []domain.Scope) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		if header == "" {
+			token := c.Cookies(handler.SessionCookie)
+			if token == "" {
+				c.SetUserContext(domain.WithPrincipal(c.UserContext(), &domain.Principal{Scopes: anonymousScopes}))
+				return c.Next()
+			}
+			// the cookie is SameSite=Lax, so it is not sent along with cross-site writes
+			return withSession(c, secret, token)
+		}
+
+		scheme, key, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return unauthorized(c, "invalid authorization header, use: Bearer <key>")
+		}
+		key = strings.TrimSpace(key)
+		if !strings.HasPrefix(key, pkg.APIKeyPrefix) {
+			return withSession(c, secret, key)
+		}
+
+		principal, err := service.Authenticate(c.UserContext(), key)
+		if err != nil {
+			if errors.Is(err, domain.ErrUnauthorized) {
+				return unauthorized(c, "invalid or revoked api key")
+			}
+			return c.Status(500).JSON(dto.ApiResponse{
+				Error:   true,
+				Message: domain.ErrInternalServerError.Error(),
+			})
+		}
+
+		ctx := domain.WithPrincipal(c.UserContext(), principal)
+		c.SetUserContext(domain.WithActor(ctx, principal.Subject))
+		return c.Next()
+	}
+}
+
+// withSession continues the request as the user of the session token.
+func withSession(c *fiber.Ctx, secret, token string) error {
+	userID, ok := pkg.VerifySessionToken(secret, token)
+	if !ok {
+		c.ClearCookie(handler.SessionCookie)
+		return unauthorized(c, "invalid or expired session")
+	}
+
+	principal := &domain.Principal{
+		Subject: "user:" + strconv.Itoa(userID),
+		UserID:  userID,
+		Scopes:  domain.UserScopes,
+	}
+	ctx := domain.WithPrincipal(c.UserContext(), principal)
+	c.SetUserContext(domain.WithActor(ctx, principal.Subject))
+	return c.Next()
+}
+
+// requireUser rejects requests that are not made by a logged in user.
+func requireUser(c *fiber.Ctx) error {
+	if domain.PrincipalFromContext(c.UserContext()).UserID == 0 {
+		return unauthorized(c, "login is required")
+	}
+
+	return c.Next()
+}
+
+// requireAuthenticated rejects anonymous requests, even if the anonymous scopes would allow them.
+func requireAuthenticated(c *fiber.Ctx) error {
+	if !domain.PrincipalFromContext(c.UserContext()).IsAuthenticated() {
+		return unauthorized(c, "login or an api key is required")
+	}
+
+	return c.Next()
+}
+
+// requireScope rejects requests whose principal is not allowed to perform actions of the scope.
+func requireScope(scope domain.Scope) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := domain.PrincipalFromContext(c.UserContext())
+		if principal.HasScope(scope) {
+			return c.Next()
+		}
+		if !principal.IsAuthenticated() {
+			return unauthorized(c, "login or an api key with the "+string(scope)+" scope is required")
+		}
+
+		return c.Status(403).JSON(dto.ApiResponse{
+			Error:   true,
+			Message: "the " + string(scope) + " scope is required",
+		})
+	}
+}
+
+func unauthorized(c *fiber.Ctx, message string) error {
+	c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+	return c.Status(401).JSON(dto.ApiResponse{
+		Error:   true,
+		Message: message,
+	})
+}
+
+// idempotency replays the stored response when a write is retried with the same Idempotency-Key.
+// The key is bound to a fingerprint of the method, path and body, reusing it for a different
+// request is rejected. Server errors and rate limited responses are not stored so they can be retried.
+func idempotency(cache ports.CacheRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > MaxIdempotencyKeyLen {
+			return c.Status(400).JSON(dto.ApiResponse{
+				Error:   true,
+				Message: "idempotency key is too long",
+			})
+		}
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Method() + " " + c.Path() + "\n"))
+		hash.Write(c.Body())
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+		defer cancel()
+
+		// keys are scoped to the principal, so one client can't replay the responses of another
+		subject := domain.PrincipalFromContext(c.UserContext()).Subject
+		if subject == "" {
+			subject = domain.AnonymousActor
+		}
+		key = subject + ":" + key
+
+		stored, err := cache.ReserveIdempotencyKey(ctx, key, fingerprint, IdempotencyLockTTL)
+		if err != nil {
+			return c.Status(500).JSON(dto.ApiResponse{
+				Error:   true,
+				Message: domain.ErrInternalServerError.Error(),
+			})
+		}
+		if stored != nil {
+			switch {
+			case stored.Fingerprint != fingerprint:
+				return c.Status(422).JSON(dto.ApiResponse{
+					Error:   true,
+					Message: "idempotency key was already used for a different request",
+				})
+			case !stored.Completed:
+				return c.Status(409).JSON(dto.ApiResponse{
+					Error:   true,
+					Message: "a request with this idempotency key is still in progress",
+				})
+			}
+
+			c.Set("Idempotent-Replayed", "true")
+			c.Set(fiber.HeaderContentType, stored.ContentType)
+			return c.Status(stored.Status).Send(stored.Body)
+		}
+
+		if err = c.Next(); err != nil {
+			// let the error handler write the response first, so it is the one we decide on
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				return handlerErr
+			}
+		}
+
+		// use a fresh context, the request context may already be cancelled by the handler timeout
+		myctx, mycancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer mycancel()
+
+		status := c.Response().StatusCode()
+		if status >= 500 || status == 429 {
+			_ = cache.DeleteIdempotencyKey(myctx, key)
+			return nil
+		}
+
+		if err = cache.SaveIdempotentResponse(myctx, key, &domain.IdempotentResponse{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        append([]byte(nil), c.Response().Body()...),
+		}, IdempotencyTTL); err != nil {
+			logger.L.Errorw("failed to store idempotent response", "error", err.Error())
+		}
+
+		return nil
+	}
+}
+
+// rateLimit limits requests of a route group in Redis, so the limits hold across instances and
+// prefork children. Requests with an api key or a session count against the limit of their
+// principal, anonymous requests against the limit of their IP, which is only taken from the proxy
+// header of service.http.trusted_proxies. The state of the limit is reported in the RateLimit-*
+// headers.
+func rateLimit(cache ports.CacheRepository, name string, perIP, perKey domain.RateLimit) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit, key := perIP, name+":ip:"+c.IP()
+		if subject := domain.PrincipalFromContext(c.UserContext()).Subject; subject != "" {
+			limit, key = perKey, name+":"+subject
+		}
+		if limit.Limit <= 0 {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), time.Second*5)
+		defer cancel()
+
+		result, err := cache.AllowRequest(ctx, key, limit)
+		if err != nil {
+			// an unavailable limiter shouldn't take the service down with it
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			return c.Status(429).JSON(dto.ApiResponse{
+				Error:   true,
+				Message: "too many requests",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,402 @@
+package http
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// withPrincipal makes the request as the given principal, like authenticate does.
+func withPrincipal(principal *domain.Principal) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(domain.WithPrincipal(c.UserContext(), principal))
+		return c.Next()
+	}
+}
+
+func TestRequireAuthenticated(t *testing.T) {
+	tests := map[string]struct {
+		principal *domain.Principal
+		want      int
+	}{
+		"anonymous with read_stats": {&domain.Principal{Scopes: []domain.Scope{domain.ScopeReadStats}}, 401},
+		"api key":                   {&domain.Principal{Subject: "key:1", Scopes: []domain.Scope{domain.ScopeReadStats}}, 200},
+		"user":                      {&domain.Principal{Subject: "user:1", UserID: 1, Scopes: domain.UserScopes}, 200},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/export/links", withPrincipal(test.principal), requireAuthenticated, requireScope(domain.ScopeReadStats), func(c *fiber.Ctx) error {
+				return c.SendStatus(200)
+			})
+
+			response, err := app.Test(httptest.NewRequest("GET", "/export/links", nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if response.StatusCode != test.want {
+				t.Errorf("status = %d, want %d", response.StatusCode, test.want)
+			}
+		})
+	}
+}
+
+// fakeCache keeps idempotency keys in memory, like redis a key is only reserved if it is unused.
+type fakeCache struct {
+	ports.CacheRepository
+
+	responses map[string]*domain.IdempotentResponse
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{responses: make(map[string]*domain.IdempotentResponse)}
+}
+
+func (c *fakeCache) ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, ttl time.Duration) (*domain.IdempotentResponse, error) {
+	if stored, ok := c.responses[key]; ok {
+		return stored, nil
+	}
+	c.responses[key] = &domain.IdempotentResponse{Fingerprint: fingerprint}
+
+	return nil, nil
+}
+
+func (c *fakeCache) SaveIdempotentResponse(ctx context.Context, key string, response *domain.IdempotentResponse, ttl time.Duration) error {
+	c.responses[key] = response
+	return nil
+}
+
+func (c *fakeCache) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	delete(c.responses, key)
+	return nil
+}
+
+// newIdempotentApp counts how often the handler really ran and answers with status.
+func newIdempotentApp(cache ports.CacheRepository, calls *int, status *int) *fiber.App {
+	app := fiber.New()
+	app.Post("/shorten", withPrincipal(&domain.Principal{Subject: "key:1"}), idempotency(cache), func(c *fiber.Ctx) error {
+		*calls++
+		return c.Status(*status).JSON(fiber.Map{"call": *calls})
+	})
+
+	return app
+}
+
+func idempotentRequest(path, key, body string) *http.Request {
+	request := httptest.NewRequest("POST", path, strings.NewReader(body))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	request.Header.Set(IdempotencyHeader, key)
+	return request
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	calls, status := 0, 201
+	app := newIdempotentApp(newFakeCache(), &calls, &status)
+
+	var bodies []string
+	for i := 0; i < 2; i++ {
+		response, err := app.Test(idempotentRequest("/shorten", "retry-1", `{"urls":["https://a.example"]}`))
+		if err != nil {
+			t.Fatalf("app.Test() error = %v", err)
+		}
+		if response.StatusCode != 201 {
+			t.Errorf("status = %d, want 201", response.StatusCode)
+		}
+		body, _ := io.ReadAll(response.Body)
+		bodies = append(bodies, string(body))
+
+		if replayed := response.Header.Get("Idempotent-Replayed"); (i == 1) != (replayed == "true") {
+			t.Errorf("request %d Idempotent-Replayed = %q", i, replayed)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want once", calls)
+	}
+	if bodies[0] != bodies[1] {
+		t.Errorf("replayed body %s, want %s", bodies[1], bodies[0])
+	}
+}
+
+func TestIdempotencyRejectsReuse(t *testing.T) {
+	cache := newFakeCache()
+	calls, status := 0, 201
+	app := newIdempotentApp(cache, &calls, &status)
+
+	if _, err := app.Test(idempotentRequest("/shorten", "retry-1", `{"urls":["https://a.example"]}`)); err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	response, err := app.Test(idempotentRequest("/shorten", "retry-1", `{"urls":["https://b.example"]}`))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if response.StatusCode != 422 {
+		t.Errorf("status for a different body = %d, want 422", response.StatusCode)
+	}
+
+	// a request that is still running holds the key
+	sum := sha256.Sum256([]byte("POST /shorten\n{}"))
+	cache.responses["key:1:retry-2"] = &domain.IdempotentResponse{Fingerprint: hex.EncodeToString(sum[:])}
+	if response, err = app.Test(idempotentRequest("/shorten", "retry-2", `{}`)); err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if response.StatusCode != 409 {
+		t.Errorf("status while in progress = %d, want 409", response.StatusCode)
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want once", calls)
+	}
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	calls, status := 0, 500
+	app := newIdempotentApp(newFakeCache(), &calls, &status)
+
+	for _, want := range []int{500, 201} {
+		response, err := app.Test(idempotentRequest("/shorten", "retry-1", `{}`))
+		if err != nil {
+			t.Fatalf("app.Test() error = %v", err)
+		}
+		if response.StatusCode != want {
+			t.Errorf("status = %d, want %d", response.StatusCode, want)
+		}
+		status = 201
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want the failed request to be retried", calls)
+	}
+}
+
+func TestIdempotencyKeysArePerPrincipal(t *testing.T) {
+	cache := newFakeCache()
+	calls := 0
+	app := fiber.New()
+	for path, subject := range map[string]string{"/one": "key:1", "/two": "key:2"} {
+		app.Post(path, withPrincipal(&domain.Principal{Subject: subject}), idempotency(cache), func(c *fiber.Ctx) error {
+			calls++
+			return c.SendStatus(201)
+		})
+	}
+
+	for _, path := range []string{"/one", "/two"} {
+		if _, err := app.Test(idempotentRequest(path, "retry-1", `{}`)); err != nil {
+			t.Fatalf("app.Test() error = %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want once per principal", calls)
+	}
+}
+
+type fakeAPIKeyService struct {
+	ports.APIKeyService
+
+	keys map[string]*domain.Principal
+}
+
+func (s *fakeAPIKeyService) Authenticate(ctx context.Context, key string) (*domain.Principal, error) {
+	principal, ok := s.keys[key]
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+
+	return principal, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	const secret = "test-secret"
+	service := &fakeAPIKeyService{keys: map[string]*domain.Principal{
+		"rk_valid": {Subject: "key:1", Scopes: []domain.Scope{domain.ScopeCreate}},
+	}}
+
+	app := fiber.New()
+	app.Get("/whoami", authenticate(service, secret, []domain.Scope{domain.ScopeCreate}), func(c *fiber.Ctx) error {
+		principal := domain.PrincipalFromContext(c.UserContext())
+		return c.JSON(fiber.Map{"subject": principal.Subject, "scopes": principal.Scopes, "actor": domain.ActorFromContext(c.UserContext())})
+	})
+
+	token := pkg.SignSessionToken(secret, 7, time.Now().Add(time.Hour))
+	tests := map[string]struct {
+		header string
+		status int
+		want   string
+	}{
+		"anonymous":       {"", 200, `{"actor":"anonymous","scopes":["create"],"subject":""}`},
+		"api key":         {"Bearer rk_valid", 200, `{"actor":"key:1","scopes":["create"],"subject":"key:1"}`},
+		"session token":   {"Bearer " + token, 200, `{"actor":"user:7","scopes":["create","read-stats","manage"],"subject":"user:7"}`},
+		"unknown key":     {"Bearer rk_unknown", 401, ""},
+		"invalid session": {"Bearer not-a-token", 401, ""},
+		"other scheme":    {"Basic dXNlcjpwYXNz", 401, ""},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/whoami", nil)
+			if test.header != "" {
+				request.Header.Set(fiber.HeaderAuthorization, test.header)
+			}
+
+			response, err := app.Test(request)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if response.StatusCode != test.status {
+				t.Fatalf("status = %d, want %d", response.StatusCode, test.status)
+			}
+			if test.status == 401 && response.Header.Get(fiber.HeaderWWWAuthenticate) != "Bearer" {
+				t.Error("401 without WWW-Authenticate: Bearer")
+			}
+			if body, _ := io.ReadAll(response.Body); test.want != "" && string(body) != test.want {
+				t.Errorf("principal = %s, want %s", body, test.want)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	tests := map[string]struct {
+		principal *domain.Principal
+		want      int
+	}{
+		"scope":                {&domain.Principal{Subject: "key:1", Scopes: []domain.Scope{domain.ScopeManage}}, 200},
+		"admin":                {&domain.Principal{Subject: "key:1", Scopes: []domain.Scope{domain.ScopeAdmin}}, 200},
+		"missing scope":        {&domain.Principal{Subject: "key:1", Scopes: []domain.Scope{domain.ScopeCreate}}, 403},
+		"anonymous":            {&domain.Principal{}, 401},
+		"anonymous with scope": {&domain.Principal{Scopes: []domain.Scope{domain.ScopeManage}}, 200},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", withPrincipal(test.principal), requireScope(domain.ScopeManage), func(c *fiber.Ctx) error {
+				return c.SendStatus(200)
+			})
+
+			response, err := app.Test(httptest.NewRequest("GET", "/", nil))
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			if response.StatusCode != test.want {
+				t.Errorf("status = %d, want %d", response.StatusCode, test.want)
+			}
+		})
+	}
+}
+
+// fakeLimiter allows the first Limit requests of every key and records the limits it was asked for.
+type fakeLimiter struct {
+	ports.CacheRepository
+
+	counts map[string]int
+	limits map[string]domain.RateLimit
+	err    error
+}
+
+func newFakeLimiter() *fakeLimiter {
+	return &fakeLimiter{counts: make(map[string]int), limits: make(map[string]domain.RateLimit)}
+}
+
+func (l *fakeLimiter) AllowRequest(ctx context.Context, key string, limit domain.RateLimit) (*domain.RateLimitResult, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	l.limits[key] = limit
+	l.counts[key]++
+
+	interval := limit.Window / time.Duration(limit.Limit)
+	if l.counts[key] > limit.Limit {
+		return &domain.RateLimitResult{Limit: limit.Limit, RetryAfter: interval, ResetAfter: limit.Window}, nil
+	}
+	return &domain.RateLimitResult{
+		Allowed:    true,
+		Limit:      limit.Limit,
+		Remaining:  limit.Limit - l.counts[key],
+		ResetAfter: time.Duration(l.counts[key]) * interval,
+	}, nil
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := newFakeLimiter()
+	perIP := domain.RateLimit{Limit: 2, Window: time.Minute}
+	perKey := domain.RateLimit{Limit: 5, Window: time.Minute}
+	app := fiber.New()
+	app.Get("/anonymous", rateLimit(limiter, "create", perIP, perKey), func(c *fiber.Ctx) error {
+		return c.SendStatus(200)
+	})
+	app.Get("/key", withPrincipal(&domain.Principal{Subject: "key:1"}), rateLimit(limiter, "create", perIP, perKey), func(c *fiber.Ctx) error {
+		return c.SendStatus(200)
+	})
+
+	tests := []struct {
+		path      string
+		status    int
+		remaining string
+	}{
+		{"/anonymous", 200, "1"},
+		{"/anonymous", 200, "0"},
+		{"/anonymous", 429, "0"},
+		// the key has its own, higher limit
+		{"/key", 200, "4"},
+	}
+	for i, test := range tests {
+		response, err := app.Test(httptest.NewRequest("GET", test.path, nil))
+		if err != nil {
+			t.Fatalf("app.Test() error = %v", err)
+		}
+		if response.StatusCode != test.status || response.Header.Get("RateLimit-Remaining") != test.remaining {
+			t.Errorf("request %d = %d with %s remaining, want %d with %s", i+1, response.StatusCode,
+				response.Header.Get("RateLimit-Remaining"), test.status, test.remaining)
+		}
+		if test.status == 429 && response.Header.Get(fiber.HeaderRetryAfter) != "30" {
+			t.Errorf("Retry-After = %q, want 30", response.Header.Get(fiber.HeaderRetryAfter))
+		}
+	}
+
+	if limiter.limits["create:ip:0.0.0.0"] != perIP || limiter.limits["create:key:1"] != perKey {
+		t.Errorf("limits = %+v, want the ip limit per ip and the key limit per key", limiter.limits)
+	}
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	limiter := newFakeLimiter()
+	limiter.err = errors.New("connection refused")
+	app := fiber.New()
+	app.Get("/", rateLimit(limiter, "create", domain.RateLimit{Limit: 1, Window: time.Minute}, domain.RateLimit{}), func(c *fiber.Ctx) error {
+		return c.SendStatus(200)
+	})
+
+	response, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+	if response.StatusCode != 200 || response.Header.Get("RateLimit-Limit") != "" {
+		t.Errorf("status = %d, want the request through without headers", response.StatusCode)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	limiter := newFakeLimiter()
+	app := fiber.New()
+	app.Get("/", withPrincipal(&domain.Principal{Subject: "key:1"}), rateLimit(limiter, "redirect", domain.RateLimit{Limit: 1, Window: time.Minute}, domain.RateLimit{}), func(c *fiber.Ctx) error {
+		return c.SendStatus(200)
+	})
+
+	for i := 0; i < 3; i++ {
+		if response, err := app.Test(httptest.NewRequest("GET", "/", nil)); err != nil || response.StatusCode != 200 {
+			t.Fatalf("request %d = %v, %v, want 200", i+1, response.StatusCode, err)
+		}
+	}
+	if len(limiter.counts) != 0 {
+		t.Errorf("counted %v, want no limit for keys", limiter.counts)
+	}
+}
@@ -1,46 +1,185 @@
 package http
 
 import (
-	"URLRotatorGo/internal/adapter/http/dto"
+	"URLRotatorGo/infra/logger"
 	"URLRotatorGo/internal/adapter/http/handler"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/etag"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/spf13/viper"
 	"time"
 )
 
 type Router struct {
-	app        *fiber.App
-	urlHandler *handler.URLHandler
+	app              *fiber.App
+	cfg              *viper.Viper
+	urlHandler       *handler.URLHandler
+	exportHandler    *handler.ExportHandler
+	linkHandler      *handler.LinkHandler
+	qrHandler        *handler.QRHandler
+	apiKeyHandler    *handler.APIKeyHandler
+	authHandler      *handler.AuthHandler
+	workspaceHandler *handler.WorkspaceHandler
+	domainHandler    *handler.DomainHandler
+	auditHandler     *handler.AuditHandler
+	analyticsHandler *handler.AnalyticsHandler
+	webhookHandler   *handler.WebhookHandler
+	apiKeyService    ports.APIKeyService
+	cache            ports.CacheRepository
 }
 
 func NewRouter(
 	app *fiber.App,
+	cfg *viper.Viper,
 	urlHandler *handler.URLHandler,
+	exportHandler *handler.ExportHandler,
+	linkHandler *handler.LinkHandler,
+	qrHandler *handler.QRHandler,
+	apiKeyHandler *handler.APIKeyHandler,
+	authHandler *handler.AuthHandler,
+	workspaceHandler *handler.WorkspaceHandler,
+	domainHandler *handler.DomainHandler,
+	auditHandler *handler.AuditHandler,
+	analyticsHandler *handler.AnalyticsHandler,
+	webhookHandler *handler.WebhookHandler,
+	apiKeyService ports.APIKeyService,
+	cache ports.CacheRepository,
 ) *Router {
 	return &Router{
-		app:        app,
-		urlHandler: urlHandler,
+		app:              app,
+		cfg:              cfg,
+		urlHandler:       urlHandler,
+		exportHandler:    exportHandler,
+		linkHandler:      linkHandler,
+		qrHandler:        qrHandler,
+		apiKeyHandler:    apiKeyHandler,
+		authHandler:      authHandler,
+		workspaceHandler: workspaceHandler,
+		domainHandler:    domainHandler,
+		auditHandler:     auditHandler,
+		analyticsHandler: analyticsHandler,
+		webhookHandler:   webhookHandler,
+		apiKeyService:    apiKeyService,
+		cache:            cache,
 	}
 }
 
 func (r *Router) SetupRoutes() {
-	route := r.app.Group("")
+	route := r.app.Group("", withActor)
+	idempotent := idempotency(r.cache)
+
+	create := requireScope(domain.ScopeCreate)
+	readStats := requireScope(domain.ScopeReadStats)
+	manage := requireScope(domain.ScopeManage)
+	admin := requireScope(domain.ScopeAdmin)
+	manageLimit := r.rateLimit("manage")
+	redirectLimit := r.rateLimit("redirect")
 
 	route.Get("/", etag.New(etag.Config{
 		Weak: true,
 	}), r.urlHandler.Index)
-	route.Use("/api/shorten", limiter.New(limiter.Config{
-		Max:        15,
-		Expiration: time.Minute,
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(429).JSON(dto.ApiResponse{
-				Error:   true,
-				Message: "too many requests",
-			})
-		},
-		LimiterMiddleware: limiter.SlidingWindow{},
-	}))
-	route.Post("/api/shorten", r.urlHandler.ShortURL)
-	route.Get("/:code", r.urlHandler.RedirectToOriginal)
+
+	api := route.Group("/api", authenticate(r.apiKeyService, r.cfg.GetString("security.secret"), r.anonymousScopes()))
+	api.Use("/shorten", r.rateLimit("create"))
+	api.Use("/auth/login", r.rateLimit("login"))
+	api.Post("/auth/signup", r.authHandler.Signup)
+	api.Post("/auth/login", r.authHandler.Login)
+	api.Post("/auth/logout", r.authHandler.Logout)
+	api.Get("/auth/me", requireUser, r.authHandler.Me)
+	api.Post("/shorten", create, idempotent, r.urlHandler.ShortURL)
+	api.Post("/shorten/bulk", create, r.urlHandler.BulkShortURL)
+	api.Get("/links", readStats, r.linkHandler.ListLinks)
+	api.Patch("/links/:code", manageLimit, manage, idempotent, r.linkHandler.UpdateLink)
+	api.Get("/links/:code/revisions", readStats, r.linkHandler.ListRevisions)
+	api.Get("/links/:code/analytics", readStats, r.analyticsHandler.GetAnalytics)
+	api.Get("/links/:code/live", readStats, r.analyticsHandler.StreamClicks)
+	api.Post("/links/:code/revisions/:revision/rollback", manageLimit, manage, idempotent, r.linkHandler.RollbackLink)
+	api.Put("/links/:code/tags", manageLimit, manage, idempotent, r.linkHandler.SetTags)
+	api.Get("/links/:code/qr", readStats, r.qrHandler.GetQRCode)
+	api.Get("/links/:code/destinations", readStats, r.linkHandler.GetDestinations)
+	api.Patch("/links/:code/destinations/:id", manageLimit, manage, idempotent, r.linkHandler.SetDestinationEnabled)
+	api.Get("/export/links", requireAuthenticated, readStats, r.exportHandler.ExportLinks)
+	api.Post("/keys", manageLimit, admin, r.apiKeyHandler.IssueKey)
+	api.Get("/keys", admin, r.apiKeyHandler.ListKeys)
+	api.Delete("/keys/:id", manageLimit, admin, r.apiKeyHandler.RevokeKey)
+	api.Post("/workspaces", manageLimit, requireUser, r.workspaceHandler.CreateWorkspace)
+	api.Get("/workspaces", requireUser, r.workspaceHandler.ListWorkspaces)
+	api.Get("/workspaces/:id/members", readStats, r.workspaceHandler.ListMembers)
+	api.Patch("/workspaces/:id/members/:user", manageLimit, manage, r.workspaceHandler.SetMemberRole)
+	api.Delete("/workspaces/:id/members/:user", manageLimit, manage, r.workspaceHandler.RemoveMember)
+	api.Post("/workspaces/:id/invitations", manageLimit, manage, r.workspaceHandler.InviteMember)
+	api.Get("/workspaces/:id/invitations", manage, r.workspaceHandler.ListInvitations)
+	api.Delete("/workspaces/:id/invitations/:invitation", manageLimit, manage, r.workspaceHandler.RevokeInvitation)
+	api.Post("/invitations/accept", manageLimit, requireUser, r.workspaceHandler.AcceptInvitation)
+	api.Post("/domains", manageLimit, manage, r.domainHandler.RegisterDomain)
+	api.Get("/domains", readStats, r.domainHandler.ListDomains)
+	api.Patch("/domains/:id", manageLimit, manage, r.domainHandler.UpdateDomain)
+	api.Delete("/domains/:id", manageLimit, manage, r.domainHandler.DeleteDomain)
+	api.Get("/audit", readStats, r.auditHandler.ListEntries)
+	api.Get("/export/audit", readStats, r.auditHandler.ExportEntries)
+	api.Post("/webhooks", manageLimit, manage, r.webhookHandler.RegisterWebhook)
+	api.Get("/webhooks", manage, r.webhookHandler.ListWebhooks)
+	api.Patch("/webhooks/:id", manageLimit, manage, r.webhookHandler.UpdateWebhook)
+	api.Delete("/webhooks/:id", manageLimit, manage, r.webhookHandler.DeleteWebhook)
+	api.Get("/webhooks/:id/deliveries", manage, r.webhookHandler.ListDeliveries)
+	api.Post("/webhooks/:id/deliveries/:delivery/redeliver", manageLimit, manage, r.webhookHandler.Redeliver)
+
+	route.Get("/:code\\+", redirectLimit, r.urlHandler.PreviewShortCode)
+	route.Get("/:code", redirectLimit, r.urlHandler.RedirectToOriginal)
+	route.Post("/:code", redirectLimit, r.urlHandler.UnlockShortCode)
+}
+
+// rateLimitConfig is the limit of a route group, per IP for anonymous requests and per api key or
+// user for authenticated ones. A limit of 0 disables it.
+type rateLimitConfig struct {
+	IP     int
+	Key    int
+	Window time.Duration
+}
+
+// DefaultRateLimits apply to the route groups that are missing from rate_limit in the config.
+var DefaultRateLimits = map[string]rateLimitConfig{
+	"create":   {IP: 15, Key: 120, Window: time.Minute},
+	"manage":   {IP: 30, Key: 300, Window: time.Minute},
+	"login":    {IP: 10, Key: 10, Window: time.Minute},
+	"redirect": {IP: 600, Window: time.Minute},
+}
+
+// rateLimit builds the limiter of a route group configured in rate_limit.<name>, e.g.
+// {"ip": 15, "key": 120, "window": "1m"}.
+func (r *Router) rateLimit(name string) fiber.Handler {
+	limits := DefaultRateLimits[name]
+	prefix := "rate_limit." + name
+	if r.cfg.IsSet(prefix) {
+		limits.IP = r.cfg.GetInt(prefix + ".ip")
+		limits.Key = r.cfg.GetInt(prefix + ".key")
+		if window := r.cfg.GetDuration(prefix + ".window"); window > 0 {
+			limits.Window = window
+		}
+	}
+	if limits.Window <= 0 {
+		limits.Window = time.Minute
+	}
+
+	return rateLimit(r.cache, name,
+		domain.RateLimit{Limit: limits.IP, Window: limits.Window},
+		domain.RateLimit{Limit: limits.Key, Window: limits.Window},
+	)
+}
+
+// anonymousScopes are the scopes granted to requests without an api key, configured in
+// security.anonymous_scopes. By default only the public form may create links.
+func (r *Router) anonymousScopes() []domain.Scope {
+	var scopes []domain.Scope
+	for _, value := range r.cfg.GetStringSlice("security.anonymous_scopes") {
+		scope, ok := domain.ParseScope(value)
+		if !ok {
+			logger.L.Warnw("ignoring unknown anonymous scope", "scope", value)
+			continue
+		}
+		scopes = append(scopes, scope)
+	}
+
+	return scopes
 }
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+func TestRouterRateLimit(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		group  string
+		want   domain.RateLimit
+	}{
+		"default":        {`{}`, "create", domain.RateLimit{Limit: 15, Window: time.Minute}},
+		"configured":     {`{"rate_limit": {"create": {"ip": 5, "key": 50, "window": "10s"}}}`, "create", domain.RateLimit{Limit: 5, Window: 10 * time.Second}},
+		"default window": {`{"rate_limit": {"login": {"ip": 3}}}`, "login", domain.RateLimit{Limit: 3, Window: time.Minute}},
+		"other group":    {`{"rate_limit": {"create": {"ip": 5}}}`, "redirect", domain.RateLimit{Limit: 600, Window: time.Minute}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := viper.New()
+			cfg.SetConfigType("json")
+			if err := cfg.ReadConfig(strings.NewReader(test.config)); err != nil {
+				t.Fatalf("ReadConfig() error = %v", err)
+			}
+			limiter := newFakeLimiter()
+			router := &Router{cfg: cfg, cache: limiter}
+
+			app := fiber.New()
+			app.Get("/", router.rateLimit(test.group), func(c *fiber.Ctx) error {
+				return c.SendStatus(200)
+			})
+			if _, err := app.Test(httptest.NewRequest("GET", "/", nil)); err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+
+			if got := limiter.limits[test.group+":ip:0.0.0.0"]; got != test.want {
+				t.Errorf("limit = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -5,33 +5,42 @@ import (
 	"URLRotatorGo/infra/logger"
 	"URLRotatorGo/internal/core/domain"
 	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
 	"context"
+	"encoding/json"
+	"errors"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/bsm/redislock"
-	"github.com/bytedance/sonic"
+	"github.com/redis/go-redis/v9"
 )
 
 type RedisCache struct {
-	db     *database.Redis
-	locker *redislock.Client
+	db *database.Redis
 }
 
 var (
 	ShortCodePrefix   = "code:"
 	LinksPrefix       = "links:"
-	LockPrefix        = "lock:"
 	RotatePrefix      = "rotate-id:"
+	AttemptPrefix     = "attempt:"
+	IdempotencyPrefix = "idempotency:"
+	DomainPrefix      = "domain:"
+	RateLimitPrefix   = "ratelimit:"
+	VisitorsPrefix    = "visitors:"
+	LivePrefix        = "live:"
 	LockTimeout       = time.Second * 5
 	DefaultExpiration = 30 * 24 * time.Hour
+	// VisitorsExpiration keeps the sketches of a day long enough to be persisted after midnight
+	VisitorsExpiration = 3 * 24 * time.Hour
+	// LiveChannelSize is how many clicks a live subscription buffers before they are dropped
+	LiveChannelSize = 100
 )
 
 func NewRedisCache(db *database.Redis) ports.CacheRepository {
-	locker := redislock.New(db.Client)
 	return &RedisCache{
-		db:     db,
-		locker: locker,
+		db: db,
 	}
 }
 
@@ -44,13 +53,14 @@ func (r *RedisCache) SaveLinks(ctx context.Context, links []*domain.URL) error {
 			"id":         link.ID,
 			"shortcode":  link.ShortCode,
 			"original":   link.Original,
+			"enabled":    link.Enabled,
 			"total_hit":  link.TotalHit,
 			"created_at": link.CreatedAt,
 			"updated_at": link.UpdatedAt,
 		}
 
 		pipe.HSet(ctx, id, data)
-		pipe.Expire(ctx, LinksPrefix+link.ShortCode, DefaultExpiration)
+		pipe.Expire(ctx, id, DefaultExpiration)
 	}
 
 	_, err := pipe.Exec(ctx)
@@ -62,27 +72,25 @@ func (r *RedisCache) SaveLinks(ctx context.Context, links []*domain.URL) error {
 	return nil
 }
 
-func (r *RedisCache) IncrLink(ctx context.Context, shortCode, id string) error {
-	backoff := redislock.LimitRetry(redislock.LinearBackoff(100*time.Millisecond), 5)
-
-	l, err := r.locker.Obtain(ctx, LockPrefix+LinksPrefix+shortCode+":"+RotatePrefix+id, LockTimeout, &redislock.Options{
-		RetryStrategy: backoff,
-	})
-	if err != nil {
-		logger.L.Errorw("failed to obtain locker", "shortcode", shortCode, "error", err.Error())
-		return err
-	}
-	defer l.Release(ctx)
-
-	pipe := r.db.TxPipeline()
-	target := LinksPrefix + shortCode + ":" + RotatePrefix + id
-
-	pipe.HIncrBy(ctx, target, "total_hit", 1)
-	pipe.HSet(ctx, target, "updated_at", time.Now())
-
-	_, err = pipe.Exec(ctx)
-	if err != nil {
-		logger.L.Errorw("failed to incr link", "shortcode", shortCode, "error", err.Error())
+// incrHitsScript counts a hit in the hashes that still exist. A hash that expired or was deleted
+// since the redirect read it would otherwise come back with nothing but the hit and no expiration.
+var incrHitsScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	if redis.call("EXISTS", key) == 1 then
+		redis.call("HINCRBY", key, "total_hit", 1)
+		redis.call("HSET", key, "updated_at", ARGV[1])
+	end
+end
+return 0
+`)
+
+// IncrHits counts a hit of the destination in the cached shortcode and destination, both are
+// written in one round trip. The script runs atomically, so concurrent redirects need no lock.
+func (r *RedisCache) IncrHits(ctx context.Context, code, id string) error {
+	keys := []string{ShortCodePrefix + code, LinksPrefix + code + ":" + RotatePrefix + id}
+
+	if err := incrHitsScript.Run(ctx, r.db.Client, keys, time.Now().Format(time.RFC3339Nano)).Err(); err != nil {
+		logger.L.Errorw("failed to incr hits", "shortcode", code, "error", err.Error())
 		return err
 	}
 
@@ -95,7 +103,7 @@ func (r *RedisCache) GetLinks(ctx context.Context, code string) ([]*domain.URL,
 	var finalData []*domain.URL
 
 	for {
-		keys, nextCursor, err := r.db.Client.Scan(ctx, cursor, LinksPrefix+code+"*", 0).Result()
+		keys, nextCursor, err := r.db.Client.Scan(ctx, cursor, LinksPrefix+code+":*", 0).Result()
 		if err != nil {
 			logger.L.Errorw("failed to scan keys", "error", err.Error())
 			return nil, err
@@ -117,6 +125,12 @@ func (r *RedisCache) GetLinks(ctx context.Context, code string) ([]*domain.URL,
 	}
 
 	for _, value := range results {
+		// a hash without the original URL was only partially written, e.g. a hit counted after
+		// the key expired, so the whole set is treated as a cache miss
+		if value["original"] == "" {
+			return nil, domain.ErrDataNotFound
+		}
+
 		var url domain.URL
 
 		// Parse each field from string to the appropriate type
@@ -128,6 +142,7 @@ func (r *RedisCache) GetLinks(ctx context.Context, code string) ([]*domain.URL,
 		}
 		url.ShortCode = value["shortcode"]
 		url.Original = value["original"]
+		url.Enabled = value["enabled"] != "0"
 		url.CreatedAt, _ = time.Parse(time.RFC3339, value["created_at"])
 		url.UpdatedAt, _ = time.Parse(time.RFC3339, value["updated_at"])
 
@@ -137,16 +152,48 @@ func (r *RedisCache) GetLinks(ctx context.Context, code string) ([]*domain.URL,
 	return finalData, nil
 }
 
+// DeleteLinks removes every cached destination of a shortcode, the next lookup reloads them from postgres.
+func (r *RedisCache) DeleteLinks(ctx context.Context, code string) error {
+	var cursor uint64
+	for {
+		keys, nextCursor, err := r.db.Client.Scan(ctx, cursor, LinksPrefix+code+":*", 0).Result()
+		if err != nil {
+			logger.L.Errorw("failed to scan keys", "error", err.Error())
+			return err
+		}
+		cursor = nextCursor
+
+		if len(keys) > 0 {
+			if err = r.db.Client.Del(ctx, keys...).Err(); err != nil {
+				logger.L.Errorw("failed to delete links", "shortcode", code, "error", err.Error())
+				return err
+			}
+		}
+
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
 func (r *RedisCache) SaveShortCode(ctx context.Context, shortcode *domain.ShortCode) error {
 	pipe := r.db.TxPipeline()
 
 	pipe.HSet(ctx, ShortCodePrefix+shortcode.Code, map[string]interface{}{
-		"id":         shortcode.ID,
-		"code":       shortcode.Code,
-		"total_hit":  shortcode.TotalHit,
-		"strategy":   string(shortcode.Strategy),
-		"created_at": shortcode.CreatedAt,
-		"updated_at": shortcode.UpdatedAt,
+		"id":             shortcode.ID,
+		"code":           shortcode.Code,
+		"owner_id":       shortcode.OwnerID,
+		"workspace_id":   shortcode.WorkspaceID,
+		"total_hit":      shortcode.TotalHit,
+		"strategy":       string(shortcode.Strategy),
+		"password_hash":  shortcode.PasswordHash,
+		"og_title":       shortcode.Social.Title,
+		"og_description": shortcode.Social.Description,
+		"og_image":       shortcode.Social.Image,
+		"redirect_type":  string(shortcode.Redirect.Type),
+		"redirect_delay": shortcode.Redirect.Delay,
+		"created_at":     shortcode.CreatedAt,
+		"updated_at":     shortcode.UpdatedAt,
 	})
 	pipe.Expire(ctx, ShortCodePrefix+shortcode.Code, DefaultExpiration)
 
@@ -159,49 +206,432 @@ func (r *RedisCache) SaveShortCode(ctx context.Context, shortcode *domain.ShortC
 	return nil
 }
 
+func (r *RedisCache) DeleteShortCode(ctx context.Context, code string) error {
+	if err := r.db.Del(ctx, ShortCodePrefix+code).Err(); err != nil {
+		logger.L.Errorw("failed to delete short code from redis storage", "shortcode", code, "error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
 func (r *RedisCache) GetShortCode(ctx context.Context, code string) (*domain.ShortCode, error) {
-	jsonmap, err := r.db.HGetAll(ctx, ShortCodePrefix+code).Result()
-	if err != nil || len(jsonmap) < 1 {
+	value, err := r.db.HGetAll(ctx, ShortCodePrefix+code).Result()
+	if err != nil || len(value) < 1 {
 		return nil, domain.ErrDataNotFound
 	}
+	// like in GetLinks a hash without the code or strategy was only partially written, reading it
+	// would e.g. lose the password of the link
+	if value["code"] == "" || value["strategy"] == "" {
+		return nil, domain.ErrDataNotFound
+	}
+
+	var shortcode domain.ShortCode
+	shortcode.ID = value["id"]
+	shortcode.Code = value["code"]
+	shortcode.OwnerID, _ = strconv.Atoi(value["owner_id"])
+	shortcode.WorkspaceID, _ = strconv.Atoi(value["workspace_id"])
+	shortcode.TotalHit, _ = strconv.Atoi(value["total_hit"])
+	shortcode.Strategy = domain.Strategy(value["strategy"])
+	shortcode.PasswordHash = value["password_hash"]
+	shortcode.Social = domain.SocialMeta{
+		Title:       value["og_title"],
+		Description: value["og_description"],
+		Image:       value["og_image"],
+	}
+	shortcode.Redirect.Type = domain.RedirectType(value["redirect_type"])
+	shortcode.Redirect.Delay, _ = strconv.Atoi(value["redirect_delay"])
+	shortcode.CreatedAt, _ = time.Parse(time.RFC3339, value["created_at"])
+	shortcode.UpdatedAt, _ = time.Parse(time.RFC3339, value["updated_at"])
+
+	return &shortcode, nil
+}
+
+// IncrAttempts counts an attempt and returns how many were counted within the window, which starts
+// with the first attempt.
+func (r *RedisCache) IncrAttempts(ctx context.Context, key string, window time.Duration) (int, error) {
+	pipe := r.db.TxPipeline()
+	target := AttemptPrefix + key
 
-	jsonbyte, err := sonic.Marshal(jsonmap)
+	count := pipe.Incr(ctx, target)
+	pipe.ExpireNX(ctx, target, window)
+
+	_, err := pipe.Exec(ctx)
 	if err != nil {
-		logger.L.Errorw("failed to get short code from redis storage", "error", err.Error())
-		return nil, err
+		logger.L.Errorw("failed to incr attempts", "key", key, "error", err.Error())
+		return 0, err
 	}
 
-	var shortcode domain.ShortCode
-	if err = sonic.Unmarshal(jsonbyte, &shortcode); err != nil {
-		logger.L.Errorw("failed to get short code from redis storage", "error", err.Error())
+	return int(count.Val()), nil
+}
+
+// decrAttemptsScript only decrements counters that still exist, an expired counter would otherwise
+// come back as -1 without an expiration.
+var decrAttemptsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("DECR", KEYS[1])
+end
+return 0
+`)
+
+// DecrAttempts takes back an attempt that turned out not to be a failed one.
+func (r *RedisCache) DecrAttempts(ctx context.Context, key string) error {
+	if err := decrAttemptsScript.Run(ctx, r.db.Client, []string{AttemptPrefix + key}).Err(); err != nil {
+		logger.L.Errorw("failed to decr attempts", "key", key, "error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ReserveIdempotencyKey claims the key for a request with the given fingerprint. It returns nil when
+// the key was free, otherwise the record stored by the request that claimed it first.
+func (r *RedisCache) ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, ttl time.Duration) (*domain.IdempotentResponse, error) {
+	pipe := r.db.TxPipeline()
+	target := IdempotencyPrefix + key
+
+	reserved := pipe.HSetNX(ctx, target, "fingerprint", fingerprint)
+	pipe.ExpireNX(ctx, target, ttl)
+	fields := pipe.HGetAll(ctx, target)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		logger.L.Errorw("failed to reserve idempotency key", "key", key, "error", err.Error())
 		return nil, err
 	}
+	if reserved.Val() {
+		return nil, nil
+	}
 
-	return &shortcode, nil
+	data := fields.Val()
+	status, _ := strconv.Atoi(data["status"])
+	return &domain.IdempotentResponse{
+		Fingerprint: data["fingerprint"],
+		Completed:   data["completed"] == "1",
+		Status:      status,
+		ContentType: data["content_type"],
+		Body:        []byte(data["body"]),
+	}, nil
+}
+
+func (r *RedisCache) SaveIdempotentResponse(ctx context.Context, key string, response *domain.IdempotentResponse, ttl time.Duration) error {
+	pipe := r.db.TxPipeline()
+	target := IdempotencyPrefix + key
+
+	pipe.HSet(ctx, target, map[string]interface{}{
+		"fingerprint":  response.Fingerprint,
+		"completed":    response.Completed,
+		"status":       response.Status,
+		"content_type": response.ContentType,
+		"body":         response.Body,
+	})
+	pipe.Expire(ctx, target, ttl)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		logger.L.Errorw("failed to save idempotent response", "key", key, "error", err.Error())
+		return err
+	}
+
+	return nil
 }
 
-func (r *RedisCache) IncrShortCode(ctx context.Context, code string) error {
-	backoff := redislock.LimitRetry(redislock.LinearBackoff(100*time.Millisecond), 5)
+func (r *RedisCache) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	if err := r.db.Del(ctx, IdempotencyPrefix+key).Err(); err != nil {
+		logger.L.Errorw("failed to delete idempotency key", "key", key, "error", err.Error())
+		return err
+	}
 
-	l, err := r.locker.Obtain(ctx, LockPrefix+ShortCodePrefix+code, LockTimeout, &redislock.Options{
-		RetryStrategy: backoff,
+	return nil
+}
+
+// SaveDomain caches a domain by its host. Unknown hosts are cached too, with an ID of 0, so that
+// requests for them don't reach postgres every time.
+func (r *RedisCache) SaveDomain(ctx context.Context, customDomain *domain.Domain, ttl time.Duration) error {
+	pipe := r.db.TxPipeline()
+	target := DomainPrefix + customDomain.Host
+
+	pipe.HSet(ctx, target, map[string]interface{}{
+		"id":            customDomain.ID,
+		"host":          customDomain.Host,
+		"workspace_id":  customDomain.WorkspaceID,
+		"root_redirect": customDomain.RootRedirect,
+		"not_found_url": customDomain.NotFoundURL,
 	})
+	pipe.Expire(ctx, target, ttl)
+
+	_, err := pipe.Exec(ctx)
 	if err != nil {
-		logger.L.Errorw("failed to incr short code in redis storage", "error", err.Error())
+		logger.L.Errorw("failed to save domain to redis storage", "host", customDomain.Host, "error", err.Error())
 		return err
 	}
-	defer l.Release(ctx)
 
+	return nil
+}
+
+func (r *RedisCache) GetDomain(ctx context.Context, host string) (*domain.Domain, error) {
+	value, err := r.db.HGetAll(ctx, DomainPrefix+host).Result()
+	if err != nil || len(value) < 1 {
+		return nil, domain.ErrDataNotFound
+	}
+
+	var data domain.Domain
+	data.ID, _ = strconv.Atoi(value["id"])
+	data.Host = value["host"]
+	data.WorkspaceID, _ = strconv.Atoi(value["workspace_id"])
+	data.RootRedirect = value["root_redirect"]
+	data.NotFoundURL = value["not_found_url"]
+
+	return &data, nil
+}
+
+func (r *RedisCache) DeleteDomain(ctx context.Context, host string) error {
+	if err := r.db.Del(ctx, DomainPrefix+host).Err(); err != nil {
+		logger.L.Errorw("failed to delete domain from redis storage", "host", host, "error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// allowRequestScript implements GCRA: the key holds the theoretical arrival time of the next
+// request in milliseconds, every request pushes it one emission interval further. Requests that
+// would push it more than a window ahead of now are rejected. Redis' clock is used so that every
+// instance agrees on the time.
+var allowRequestScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local interval = window / limit
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+
+local newTat = tat + interval
+local allowAt = newTat - window
+if allowAt > now then
+	return {0, 0, math.ceil(allowAt - now), math.ceil(tat - now)}
+end
+
+redis.call("SET", KEYS[1], newTat, "PX", math.ceil(newTat - now))
+return {1, math.floor((window - (newTat - now)) / interval), 0, math.ceil(newTat - now)}
+`)
+
+// AllowRequest counts a request against the limit of the key, the limit is shared by every
+// instance and prefork child.
+func (r *RedisCache) AllowRequest(ctx context.Context, key string, limit domain.RateLimit) (*domain.RateLimitResult, error) {
+	values, err := allowRequestScript.Run(ctx, r.db.Client, []string{RateLimitPrefix + key}, limit.Limit, limit.Window.Milliseconds()).Int64Slice()
+	if err != nil {
+		logger.L.Errorw("failed to check rate limit", "key", key, "error", err.Error())
+		return nil, err
+	}
+
+	return &domain.RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit.Limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// visitorsKey is the sketch of a shortcode, next to its code: key, or of one of its destinations,
+// next to the links: key of the destination.
+func visitorsKey(code string, urlID int, day time.Time) string {
+	if urlID == 0 {
+		return VisitorsPrefix + ShortCodePrefix + code + ":" + day.Format(time.DateOnly)
+	}
+
+	return VisitorsPrefix + LinksPrefix + code + ":" + RotatePrefix + strconv.Itoa(urlID) + ":" + day.Format(time.DateOnly)
+}
+
+// visitorsDayKey is the set of the sketches that were added to on a day, members are url id:code.
+func visitorsDayKey(day time.Time) string {
+	return VisitorsPrefix + "day:" + day.Format(time.DateOnly)
+}
+
+// AddVisitor counts the visitor on the day, both for the shortcode and for the destination.
+func (r *RedisCache) AddVisitor(ctx context.Context, code string, urlID int, visitor string, day time.Time) error {
 	pipe := r.db.TxPipeline()
-	target := ShortCodePrefix + code
-	pipe.HIncrBy(ctx, target, "total_hit", 1)
-	pipe.HSet(ctx, target, "updated_at", time.Now())
+	for _, id := range []int{0, urlID} {
+		target := visitorsKey(code, id, day)
+		pipe.PFAdd(ctx, target, visitor)
+		pipe.Expire(ctx, target, VisitorsExpiration)
+		pipe.SAdd(ctx, visitorsDayKey(day), strconv.Itoa(id)+":"+code)
+	}
+	pipe.Expire(ctx, visitorsDayKey(day), VisitorsExpiration)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.L.Errorw("failed to add visitor", "shortcode", code, "error", err.Error())
+		return err
+	}
 
-	_, err = pipe.Exec(ctx)
+	return nil
+}
+
+// GetVisitorSketches returns every sketch that was added to on the day.
+func (r *RedisCache) GetVisitorSketches(ctx context.Context, day time.Time) ([]*domain.VisitorSketch, error) {
+	members, err := r.db.SMembers(ctx, visitorsDayKey(day)).Result()
 	if err != nil {
-		logger.L.Errorw("failed to incr short code in redis storage", "error", err.Error())
+		logger.L.Errorw("failed to get visitor sketches", "error", err.Error())
+		return nil, err
+	}
+
+	sketches := make([]*domain.VisitorSketch, 0, len(members))
+	for _, member := range members {
+		id, code, ok := strings.Cut(member, ":")
+		urlID, err := strconv.Atoi(id)
+		if !ok || err != nil {
+			continue
+		}
+		sketches = append(sketches, &domain.VisitorSketch{Day: day, ShortCode: code, URLID: urlID})
+	}
+
+	pipe := r.db.Pipeline()
+	raws := make([]*redis.StringCmd, len(sketches))
+	counts := make([]*redis.IntCmd, len(sketches))
+	for i, sketch := range sketches {
+		target := visitorsKey(sketch.ShortCode, sketch.URLID, day)
+		raws[i] = pipe.Get(ctx, target)
+		counts[i] = pipe.PFCount(ctx, target)
+	}
+	if _, err = pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		logger.L.Errorw("failed to get visitor sketches", "error", err.Error())
+		return nil, err
+	}
+
+	results := sketches[:0]
+	for i, sketch := range sketches {
+		// the sketch expired while the day is still listed
+		if sketch.Sketch, err = raws[i].Bytes(); err != nil {
+			continue
+		}
+		sketch.Visitors = counts[i].Val()
+		results = append(results, sketch)
+	}
+
+	return results, nil
+}
+
+// MergeVisitorSketches merges persisted sketches into the live ones, e.g. after redis lost them,
+// so that persisting the live sketches never loses visitors.
+func (r *RedisCache) MergeVisitorSketches(ctx context.Context, sketches []*domain.VisitorSketch) error {
+	pipe := r.db.TxPipeline()
+	for _, sketch := range sketches {
+		target := visitorsKey(sketch.ShortCode, sketch.URLID, sketch.Day)
+		restored := target + ":" + pkg.GenerateRandomID()
+		pipe.Set(ctx, restored, sketch.Sketch, LockTimeout)
+		pipe.PFMerge(ctx, target, target, restored)
+		pipe.Del(ctx, restored)
+		pipe.Expire(ctx, target, VisitorsExpiration)
+		pipe.SAdd(ctx, visitorsDayKey(sketch.Day), strconv.Itoa(sketch.URLID)+":"+sketch.ShortCode)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.L.Errorw("failed to merge visitor sketches", "error", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// CountVisitors returns the number of distinct visitors over all the sketches.
+func (r *RedisCache) CountVisitors(ctx context.Context, sketches []*domain.VisitorSketch) (int64, error) {
+	if len(sketches) == 0 {
+		return 0, nil
+	}
+
+	prefix := VisitorsPrefix + "count:" + pkg.GenerateRandomID() + ":"
+	keys := make([]string, len(sketches))
+
+	pipe := r.db.TxPipeline()
+	for i, sketch := range sketches {
+		keys[i] = prefix + strconv.Itoa(i)
+		pipe.Set(ctx, keys[i], sketch.Sketch, LockTimeout)
+	}
+	count := pipe.PFCount(ctx, keys...)
+	pipe.Del(ctx, keys...)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.L.Errorw("failed to count visitors", "error", err.Error())
+		return 0, err
+	}
+
+	return count.Val(), nil
+}
+
+// liveClick is what the live streams get to see of a click event.
+type liveClick struct {
+	ClickedAt time.Time      `json:"clicked_at"`
+	URLID     int            `json:"url_id"`
+	Country   string         `json:"country"`
+	Device    string         `json:"device"`
+	Traffic   domain.Traffic `json:"traffic"`
+}
+
+// PublishClicks sends the events to the subscribers of their shortcodes on every instance.
+func (r *RedisCache) PublishClicks(ctx context.Context, events []*domain.ClickEvent) error {
+	pipe := r.db.Pipeline()
+	for _, event := range events {
+		data, err := json.Marshal(liveClick{
+			ClickedAt: event.ClickedAt,
+			URLID:     event.URLID,
+			Country:   event.Country,
+			Device:    event.Device,
+			Traffic:   event.Traffic,
+		})
+		if err != nil {
+			continue
+		}
+		pipe.Publish(ctx, LivePrefix+event.ShortCode, data)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.L.Errorw("failed to publish clicks", "error", err.Error())
 		return err
 	}
 
 	return nil
 }
+
+// SubscribeClicks passes the clicks of the shortcode to fn as they are published, until ctx is
+// done or fn returns an error. Clicks are dropped while fn doesn't keep up.
+func (r *RedisCache) SubscribeClicks(ctx context.Context, code string, fn func(*domain.ClickEvent) error) error {
+	pubsub := r.db.Subscribe(ctx, LivePrefix+code)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		logger.L.Errorw("failed to subscribe to clicks", "shortcode", code, "error", err.Error())
+		return err
+	}
+
+	messages := pubsub.Channel(redis.WithChannelSize(LiveChannelSize), redis.WithChannelSendTimeout(time.Millisecond))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+
+			var click liveClick
+			if err := json.Unmarshal([]byte(message.Payload), &click); err != nil {
+				continue
+			}
+			event := &domain.ClickEvent{
+				ClickedAt: click.ClickedAt,
+				ShortCode: code,
+				URLID:     click.URLID,
+				Visit:     domain.Visit{Country: click.Country, Device: click.Device, Traffic: click.Traffic},
+			}
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+}
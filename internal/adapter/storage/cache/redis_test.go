@@ -0,0 +1,220 @@
+package cache
+
+import (
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func newTestCache(t *testing.T) (*RedisCache, *miniredis.Miniredis) {
+	logger.L = zap.NewNop().Sugar()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return &RedisCache{db: &database.Redis{Client: client}}, server
+}
+
+func TestAllowRequest(t *testing.T) {
+	cache, server := newTestCache(t)
+	now := time.Now()
+	server.SetTime(now)
+	limit := domain.RateLimit{Limit: 3, Window: time.Minute}
+
+	// a burst of the whole limit is allowed, each request takes one emission interval
+	for i, remaining := range []int{2, 1, 0} {
+		result, err := cache.AllowRequest(context.Background(), "create:ip:203.0.113.1", limit)
+		if err != nil {
+			t.Fatalf("AllowRequest() error = %v", err)
+		}
+		if !result.Allowed || result.Remaining != remaining {
+			t.Errorf("request %d = %+v, want allowed with %d remaining", i+1, result, remaining)
+		}
+		if want := time.Duration(i+1) * 20 * time.Second; result.ResetAfter != want {
+			t.Errorf("request %d ResetAfter = %v, want %v", i+1, result.ResetAfter, want)
+		}
+	}
+
+	result, err := cache.AllowRequest(context.Background(), "create:ip:203.0.113.1", limit)
+	if err != nil {
+		t.Fatalf("AllowRequest() error = %v", err)
+	}
+	if result.Allowed || result.RetryAfter != 20*time.Second {
+		t.Errorf("request over the limit = %+v, want rejected for 20s", result)
+	}
+
+	// other keys have their own limit
+	if result, _ = cache.AllowRequest(context.Background(), "create:key:1", limit); !result.Allowed {
+		t.Errorf("request of another key = %+v, want allowed", result)
+	}
+
+	// one interval later there is room for exactly one more request
+	server.SetTime(now.Add(20 * time.Second))
+	if result, _ = cache.AllowRequest(context.Background(), "create:ip:203.0.113.1", limit); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("request after an interval = %+v, want allowed with 0 remaining", result)
+	}
+	if result, _ = cache.AllowRequest(context.Background(), "create:ip:203.0.113.1", limit); result.Allowed {
+		t.Errorf("second request after an interval = %+v, want rejected", result)
+	}
+}
+
+func TestAddVisitor(t *testing.T) {
+	cache, server := newTestCache(t)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, visit := range []struct {
+		urlID   int
+		visitor string
+	}{{1, "a"}, {1, "b"}, {1, "a"}, {2, "a"}, {2, "c"}} {
+		if err := cache.AddVisitor(context.Background(), "spring", visit.urlID, visit.visitor, day); err != nil {
+			t.Fatalf("AddVisitor() error = %v", err)
+		}
+	}
+
+	for urlID, want := range map[int]int{0: 3, 1: 2, 2: 2} {
+		count, err := server.PfCount(visitorsKey("spring", urlID, day))
+		if err != nil || count != want {
+			t.Errorf("visitors of destination %d = %d, %v, want %d", urlID, count, err, want)
+		}
+		if ttl := server.TTL(visitorsKey("spring", urlID, day)); ttl != VisitorsExpiration {
+			t.Errorf("sketch of destination %d expires in %v, want %v", urlID, ttl, VisitorsExpiration)
+		}
+	}
+
+	members, err := server.Members(visitorsDayKey(day))
+	if err != nil || len(members) != 3 {
+		t.Errorf("sketches of the day = %v, %v, want the shortcode and both destinations", members, err)
+	}
+}
+
+func TestPublishClicks(t *testing.T) {
+	cache, _ := newTestCache(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clickedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	received := make(chan *domain.ClickEvent, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- cache.SubscribeClicks(ctx, "spring", func(event *domain.ClickEvent) error {
+			received <- event
+			return errors.New("enough")
+		})
+	}()
+
+	// the subscription may not be active yet, so publish until the click arrives
+	var event *domain.ClickEvent
+	for event == nil {
+		err := cache.PublishClicks(ctx, []*domain.ClickEvent{
+			{ClickedAt: clickedAt, ShortCode: "other", URLID: 9},
+			{ClickedAt: clickedAt, ShortCode: "spring", URLID: 1, Visit: domain.Visit{IPHash: "hash", Country: "DE", Device: "mobile", Traffic: domain.TrafficHuman}},
+		})
+		if err != nil {
+			t.Fatalf("PublishClicks() error = %v", err)
+		}
+
+		select {
+		case event = <-received:
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatalf("no click was received")
+		}
+	}
+
+	want := domain.ClickEvent{ClickedAt: clickedAt, ShortCode: "spring", URLID: 1, Visit: domain.Visit{Country: "DE", Device: "mobile", Traffic: domain.TrafficHuman}}
+	if !event.ClickedAt.Equal(want.ClickedAt) || event.ShortCode != want.ShortCode || event.URLID != want.URLID || event.Visit != want.Visit {
+		t.Errorf("received %+v, want %+v without the ip hash", event, want)
+	}
+	// an error of fn ends the subscription
+	if err := <-done; err == nil || err.Error() != "enough" {
+		t.Errorf("SubscribeClicks() error = %v, want the error of fn", err)
+	}
+}
+
+func TestAttempts(t *testing.T) {
+	cache, server := newTestCache(t)
+	ctx := context.Background()
+
+	for want := 1; want <= 3; want++ {
+		count, err := cache.IncrAttempts(ctx, "unlock:ip:203.0.113.1", 15*time.Minute)
+		if err != nil || count != want {
+			t.Fatalf("IncrAttempts() = %d, %v, want %d", count, err, want)
+		}
+	}
+	// the window starts with the first attempt
+	if ttl := server.TTL(AttemptPrefix + "unlock:ip:203.0.113.1"); ttl != 15*time.Minute {
+		t.Errorf("TTL = %v, want the window", ttl)
+	}
+
+	if err := cache.DecrAttempts(ctx, "unlock:ip:203.0.113.1"); err != nil {
+		t.Fatalf("DecrAttempts() error = %v", err)
+	}
+	if count, _ := server.Get(AttemptPrefix + "unlock:ip:203.0.113.1"); count != "2" {
+		t.Errorf("attempts = %s, want 2", count)
+	}
+
+	// an expired counter is not brought back without an expiration
+	server.FastForward(15 * time.Minute)
+	if err := cache.DecrAttempts(ctx, "unlock:ip:203.0.113.1"); err != nil {
+		t.Fatalf("DecrAttempts() error = %v", err)
+	}
+	if server.Exists(AttemptPrefix + "unlock:ip:203.0.113.1") {
+		t.Errorf("DecrAttempts() recreated the expired counter")
+	}
+}
+
+func TestIncrHits(t *testing.T) {
+	cache, server := newTestCache(t)
+	ctx := context.Background()
+
+	shortcode := &domain.ShortCode{Code: "spring", Strategy: domain.RoundRobin, PasswordHash: "hash", TotalHit: 5}
+	if err := cache.SaveShortCode(ctx, shortcode); err != nil {
+		t.Fatalf("SaveShortCode() error = %v", err)
+	}
+	if err := cache.SaveLinks(ctx, []*domain.URL{{ID: 1, ShortCode: "spring", Original: "https://a.example", Enabled: true, TotalHit: 2}}); err != nil {
+		t.Fatalf("SaveLinks() error = %v", err)
+	}
+
+	if err := cache.IncrHits(ctx, "spring", "1"); err != nil {
+		t.Fatalf("IncrHits() error = %v", err)
+	}
+	if hits := server.HGet(ShortCodePrefix+"spring", "total_hit"); hits != "6" {
+		t.Errorf("shortcode hits = %s, want 6", hits)
+	}
+	if hits := server.HGet(LinksPrefix+"spring:"+RotatePrefix+"1", "total_hit"); hits != "3" {
+		t.Errorf("destination hits = %s, want 3", hits)
+	}
+	cached, err := cache.GetShortCode(ctx, "spring")
+	if err != nil || cached.PasswordHash != "hash" || cached.UpdatedAt.IsZero() {
+		t.Errorf("GetShortCode() = %+v, %v, want the shortcode with the time of the hit", cached, err)
+	}
+
+	// a hit counted after the keys were deleted, e.g. by an update of the link, doesn't bring them back
+	server.Del(ShortCodePrefix + "spring")
+	server.Del(LinksPrefix + "spring:" + RotatePrefix + "1")
+	if err := cache.IncrHits(ctx, "spring", "1"); err != nil {
+		t.Fatalf("IncrHits() error = %v", err)
+	}
+	if server.Exists(ShortCodePrefix+"spring") || server.Exists(LinksPrefix+"spring:"+RotatePrefix+"1") {
+		t.Errorf("IncrHits() recreated the deleted keys")
+	}
+}
+
+func TestGetShortCodeIgnoresPartialHashes(t *testing.T) {
+	cache, server := newTestCache(t)
+
+	// what a hit counted after the key expired used to leave behind
+	server.HSet(ShortCodePrefix+"spring", "total_hit", "1", "updated_at", time.Now().Format(time.RFC3339Nano))
+
+	if _, err := cache.GetShortCode(context.Background(), "spring"); !errors.Is(err, domain.ErrDataNotFound) {
+		t.Errorf("GetShortCode() error = %v, want %v", err, domain.ErrDataNotFound)
+	}
+}
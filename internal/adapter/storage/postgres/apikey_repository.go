@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type APIKeyRepository struct {
+	db *database.Postgres
+}
+
+func NewAPIKeyRepository(db *database.Postgres) ports.APIKeyRepository {
+	return &APIKeyRepository{db}
+}
+
+func (r *APIKeyRepository) Save(ctx context.Context, key *domain.APIKey, hash string) (*domain.APIKey, error) {
+	query := r.db.QueryBuilder.Insert("api_keys").
+		Columns("name", "prefix", "key_hash", "scopes").
+		Values(key.Name, key.Prefix, hash, scopeNames(key.Scopes)).
+		Suffix("RETURNING id, created_at")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	if err = r.db.Pool.QueryRow(ctx, sql, args...).Scan(&key.ID, &key.CreatedAt); err != nil {
+		logger.L.Errorw("failed to insert api key", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return key, nil
+}
+
+// GetByHash returns the key with the given hash, revoked keys are never returned.
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	query := r.selectQuery().
+		Where(squirrel.Eq{"key_hash": hash, "revoked_at": nil}).
+		Limit(1)
+
+	keys, err := r.list(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return keys[0], nil
+}
+
+func (r *APIKeyRepository) List(ctx context.Context) ([]*domain.APIKey, error) {
+	return r.list(ctx, r.selectQuery().OrderBy("id"))
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int) error {
+	query := r.db.QueryBuilder.Update("api_keys").
+		Set("revoked_at", time.Now()).
+		Where(squirrel.Eq{"id": id, "revoked_at": nil})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	tag, err := r.db.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// TouchLastUsed records that the key was used. It is written at most once a minute per key so
+// that busy keys don't turn every request into a write.
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int) error {
+	_, err := r.db.Pool.Exec(ctx,
+		`UPDATE api_keys SET last_used_at = $1
+		WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $1 - INTERVAL '1 minute')`,
+		time.Now(), id,
+	)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) selectQuery() squirrel.SelectBuilder {
+	return r.db.QueryBuilder.Select("id", "name", "prefix", "scopes", "created_at", "last_used_at", "revoked_at").
+		From("api_keys")
+}
+
+func (r *APIKeyRepository) list(ctx context.Context, query squirrel.SelectBuilder) ([]*domain.APIKey, error) {
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	keys, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.APIKey, error) {
+		var key domain.APIKey
+		var scopes []string
+		if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &scopes, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt); err != nil {
+			return nil, err
+		}
+		for _, scope := range scopes {
+			key.Scopes = append(key.Scopes, domain.Scope(scope))
+		}
+		return &key, nil
+	})
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return keys, nil
+}
+
+func scopeNames(scopes []domain.Scope) []string {
+	names := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		names = append(names, string(scope))
+	}
+
+	return names
+}
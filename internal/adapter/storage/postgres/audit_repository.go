@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type AuditRepository struct {
+	db *database.Postgres
+}
+
+func NewAuditRepository(db *database.Postgres) ports.AuditRepository {
+	return &AuditRepository{db}
+}
+
+// execer is satisfied by both the pool and a transaction, so entries can be written as part of
+// the transaction that performs the audited change.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Record appends the entries to the audit log, the actor and the request are taken from ctx.
+func (r *AuditRepository) Record(ctx context.Context, entries ...*domain.AuditEntry) error {
+	return insertAuditEntries(ctx, r.db, r.db.Pool, entries)
+}
+
+func (r *AuditRepository) List(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	query := r.selectQuery(filter)
+	if filter.Limit > 0 {
+		query = query.Limit(uint64(filter.Limit))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	entries, err := pgx.CollectRows(rows, scanAuditEntry)
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return entries, nil
+}
+
+// Export reads every entry matching the filter through a server-side cursor and passes them one
+// by one to fn, so the log is never loaded into memory at once.
+func (r *AuditRepository) Export(ctx context.Context, filter domain.AuditFilter, fn func(*domain.AuditEntry) error) error {
+	sql, args, err := r.selectQuery(filter).ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	tx, err := r.db.Pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		logger.L.Errorw("failed to start transaction", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	// the transaction is read only, rolling it back also closes the cursor
+	defer tx.Rollback(context.Background())
+
+	if _, err = tx.Exec(ctx, "DECLARE audit_cursor NO SCROLL CURSOR FOR "+sql, args...); err != nil {
+		logger.L.Errorw("failed to declare cursor", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	for {
+		rows, err := tx.Query(ctx, fmt.Sprintf("FETCH FORWARD %d FROM audit_cursor", exportFetchSize))
+		if err != nil {
+			logger.L.Errorw("failed to fetch from cursor", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+
+		entries, err := pgx.CollectRows(rows, scanAuditEntry)
+		if err != nil {
+			logger.L.Errorw("failed to read cursor", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+		for _, entry := range entries {
+			if err = fn(entry); err != nil {
+				return err
+			}
+		}
+		if len(entries) < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+func (r *AuditRepository) selectQuery(filter domain.AuditFilter) squirrel.SelectBuilder {
+	query := r.db.QueryBuilder.Select("id", "action", "actor", "ip", "request_id", "target_type", "target_id",
+		"COALESCE(workspace_id, 0)", "before", "after", "created_at").
+		From("audit_log").
+		OrderBy("id DESC")
+
+	where := squirrel.Eq{}
+	for column, value := range map[string]string{
+		"action":      filter.Action,
+		"actor":       filter.Actor,
+		"target_type": filter.TargetType,
+		"target_id":   filter.TargetID,
+	} {
+		if value != "" {
+			where[column] = value
+		}
+	}
+	if filter.WorkspaceID != 0 {
+		where["workspace_id"] = filter.WorkspaceID
+	}
+	if len(where) > 0 {
+		query = query.Where(where)
+	}
+
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where(squirrel.GtOrEq{"created_at": filter.CreatedAfter})
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where(squirrel.Lt{"created_at": filter.CreatedBefore})
+	}
+	if filter.Before != 0 {
+		query = query.Where(squirrel.Lt{"id": filter.Before})
+	}
+
+	return query
+}
+
+func scanAuditEntry(row pgx.CollectableRow) (*domain.AuditEntry, error) {
+	var entry domain.AuditEntry
+	var before, after []byte
+	err := row.Scan(&entry.ID, &entry.Action, &entry.Actor, &entry.IP, &entry.RequestID, &entry.TargetType, &entry.TargetID,
+		&entry.WorkspaceID, &before, &after, &entry.CreatedAt)
+	entry.Before, entry.After = before, after
+
+	return &entry, err
+}
+
+// insertAuditEntries writes the entries with a single multi-row insert through exec, which may be
+// the pool or the transaction of the audited change.
+func insertAuditEntries(ctx context.Context, db *database.Postgres, exec execer, entries []*domain.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	actor := domain.ActorFromContext(ctx)
+	request := domain.RequestInfoFromContext(ctx)
+
+	query := db.QueryBuilder.Insert("audit_log").
+		Columns("action", "actor", "ip", "request_id", "target_type", "target_id", "workspace_id", "before", "after")
+	for _, entry := range entries {
+		query = query.Values(entry.Action, actor, request.IP, request.RequestID, entry.TargetType, entry.TargetID,
+			nullableID(entry.WorkspaceID), nullableJSON(entry.Before), nullableJSON(entry.After))
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	if _, err = exec.Exec(ctx, sql, args...); err != nil {
+		logger.L.Errorw("failed to insert audit entries", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func nullableJSON(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return string(data)
+}
@@ -0,0 +1,319 @@
+package postgres
+
+import (
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"strings"
+	"time"
+)
+
+type ClickRepository struct {
+	db *database.Postgres
+}
+
+func NewClickRepository(db *database.Postgres) ports.ClickRepository {
+	return &ClickRepository{db}
+}
+
+var clickEventColumns = []string{"clicked_at", "shortcode", "url_id", "ip_hash", "user_agent", "device", "referrer", "country", "request_id", "traffic"}
+
+// Save copies the events into click_events in one round trip, the partitions of their months must exist.
+func (r *ClickRepository) Save(ctx context.Context, events []*domain.ClickEvent) error {
+	rows := pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+		event := events[i]
+		traffic := event.Traffic
+		if traffic == "" {
+			traffic = domain.TrafficHuman
+		}
+
+		return []any{
+			event.ClickedAt,
+			event.ShortCode,
+			event.URLID,
+			event.IPHash,
+			truncate(event.UserAgent, 512),
+			event.Device,
+			truncate(event.Referrer, 1024),
+			truncate(event.Country, 2),
+			truncate(event.RequestID, 64),
+			string(traffic),
+		}, nil
+	})
+
+	if _, err := r.db.Pool.CopyFrom(ctx, pgx.Identifier{"click_events"}, clickEventColumns, rows); err != nil {
+		logger.L.Errorw("failed to copy click events", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// EnsurePartition creates the partition of click_events that holds the month, if it doesn't exist yet.
+func (r *ClickRepository) EnsurePartition(ctx context.Context, month time.Time) error {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	to := from.AddDate(0, 1, 0)
+
+	sql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS click_events_%s PARTITION OF click_events FOR VALUES FROM ('%s') TO ('%s')",
+		from.Format("200601"), from.Format(time.DateTime), to.Format(time.DateTime))
+	if _, err := r.db.Pool.Exec(ctx, sql); err != nil {
+		logger.L.Errorw("failed to create click events partition", "month", from.Format("2006-01"), "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// truncate cuts s to at most n characters, the columns of visitor supplied values have a limit.
+// Invalid UTF-8 is dropped as well, a single bad header would otherwise fail the whole batch.
+func truncate(s string, n int) string {
+	runes := []rune(strings.ToValidUTF8(s, ""))
+	if len(runes) <= n {
+		return string(runes)
+	}
+
+	return string(runes[:n])
+}
+
+// referrerHost extracts the lower cased host of the referrer, direct visits have an empty host.
+const referrerHost = `COALESCE(lower(substring(referrer from '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/]*@)?([^/:?#]+)')), '')`
+
+// rollupLock serializes the rollups of all instances, they are idempotent but not free.
+const rollupLock = "SELECT pg_advisory_xact_lock(hashtext('click_rollup'))"
+
+// RollupHourly recounts the clicks of every hour in [from, to) from the click events. Counts are
+// replaced, not added to, so a range can be rolled up again any number of times. Without
+// countBots only humans are counted, the traffic dimension always counts every visit.
+func (r *ClickRepository) RollupHourly(ctx context.Context, from, to time.Time, countBots bool) error {
+	return r.rollup(ctx,
+		rollupStatement{`INSERT INTO click_rollups_hourly (bucket, shortcode, url_id, clicks)
+			SELECT date_trunc('hour', clicked_at), shortcode, url_id, count(*)
+			FROM click_events
+			WHERE clicked_at >= $1 AND clicked_at < $2 AND ($3 OR traffic = '` + string(domain.TrafficHuman) + `')
+			GROUP BY 1, 2, 3
+			ON CONFLICT (shortcode, bucket, url_id) DO UPDATE SET clicks = EXCLUDED.clicks`, []any{from.UTC(), to.UTC(), countBots}},
+		rollupStatement{`INSERT INTO click_dimensions_hourly (bucket, shortcode, dimension, value, clicks)
+			SELECT date_trunc('hour', clicked_at), shortcode, d.dimension, left(d.value, 255), count(*)
+			FROM click_events, LATERAL (VALUES
+				('` + domain.DimensionReferrer + `', ` + referrerHost + `),
+				('` + domain.DimensionCountry + `', country),
+				('` + domain.DimensionDevice + `', device),
+				('` + domain.DimensionTraffic + `', traffic)
+			) AS d(dimension, value)
+			WHERE clicked_at >= $1 AND clicked_at < $2
+				AND ($3 OR traffic = '` + string(domain.TrafficHuman) + `' OR d.dimension = '` + domain.DimensionTraffic + `')
+			GROUP BY 1, 2, 3, 4
+			ON CONFLICT (shortcode, dimension, bucket, value) DO UPDATE SET clicks = EXCLUDED.clicks`, []any{from.UTC(), to.UTC(), countBots}},
+	)
+}
+
+// RollupDaily recounts the clicks of every day of loc in [from, to), the same way as RollupHourly.
+func (r *ClickRepository) RollupDaily(ctx context.Context, from, to time.Time, loc *time.Location, countBots bool) error {
+	return r.rollup(ctx,
+		rollupStatement{`INSERT INTO click_rollups_daily (timezone, day, shortcode, url_id, clicks)
+			SELECT $3::text, (clicked_at AT TIME ZONE 'UTC' AT TIME ZONE $3::text)::date, shortcode, url_id, count(*)
+			FROM click_events
+			WHERE clicked_at >= $1 AND clicked_at < $2 AND ($4 OR traffic = '` + string(domain.TrafficHuman) + `')
+			GROUP BY 2, 3, 4
+			ON CONFLICT (timezone, shortcode, day, url_id) DO UPDATE SET clicks = EXCLUDED.clicks`, []any{from.UTC(), to.UTC(), loc.String(), countBots}},
+	)
+}
+
+type rollupStatement struct {
+	sql  string
+	args []any
+}
+
+func (r *ClickRepository) rollup(ctx context.Context, statements ...rollupStatement) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		logger.L.Errorw("failed to start transaction", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	defer tx.Rollback(context.Background())
+
+	if _, err = tx.Exec(ctx, rollupLock); err != nil {
+		logger.L.Errorw("failed to acquire rollup lock", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(ctx, statement.sql, statement.args...); err != nil {
+			logger.L.Errorw("failed to roll up clicks", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.L.Errorw("failed to commit transaction", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// Series counts the clicks per destination from the hourly rollups, bucketed by the interval of
+// the query in its location.
+func (r *ClickRepository) Series(ctx context.Context, query domain.AnalyticsQuery) ([]domain.ClickCount, error) {
+	sql := `SELECT date_trunc($4::text, bucket AT TIME ZONE 'UTC' AT TIME ZONE $5::text) AS t, url_id, sum(clicks)
+		FROM click_rollups_hourly
+		WHERE shortcode = $1 AND bucket >= $2 AND bucket < $3
+		GROUP BY 1, 2
+		ORDER BY 1, 2`
+
+	return r.queryCounts(ctx, query.Location, sql, query.Code, query.From.UTC(), query.To.UTC(), string(query.Interval), query.Location.String())
+}
+
+// DailySeries reads the clicks per destination and day from the daily rollups of the query location.
+func (r *ClickRepository) DailySeries(ctx context.Context, query domain.AnalyticsQuery) ([]domain.ClickCount, error) {
+	sql := `SELECT day::timestamp, url_id, clicks
+		FROM click_rollups_daily
+		WHERE timezone = $1 AND shortcode = $2 AND day >= $3::date AND day < $4::date
+		ORDER BY 1, 2`
+
+	return r.queryCounts(ctx, query.Location, sql, query.Location.String(), query.Code,
+		query.From.In(query.Location).Format(time.DateOnly), query.To.In(query.Location).Format(time.DateOnly))
+}
+
+// queryCounts scans bucket, url_id and clicks rows, buckets are wall clock times of loc.
+func (r *ClickRepository) queryCounts(ctx context.Context, loc *time.Location, sql string, args ...any) ([]domain.ClickCount, error) {
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+	defer rows.Close()
+
+	var counts []domain.ClickCount
+	for rows.Next() {
+		var count domain.ClickCount
+		var bucket time.Time
+		if err = rows.Scan(&bucket, &count.URLID, &count.Clicks); err != nil {
+			logger.L.Errorw("failed to scan row", "error", err.Error())
+			return nil, domain.ErrInternalServerError
+		}
+		count.Bucket = time.Date(bucket.Year(), bucket.Month(), bucket.Day(), bucket.Hour(), 0, 0, 0, loc)
+		counts = append(counts, count)
+	}
+	if err = rows.Err(); err != nil {
+		logger.L.Errorw("failed to read rows", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return counts, nil
+}
+
+// TopValues returns the most frequent values of the dimension in the hours of [from, to).
+func (r *ClickRepository) TopValues(ctx context.Context, code, dimension string, from, to time.Time, limit int) ([]domain.DimensionCount, error) {
+	query := r.db.QueryBuilder.Select("value", "sum(clicks)").
+		From("click_dimensions_hourly").
+		Where(squirrel.Eq{"shortcode": code, "dimension": dimension}).
+		Where(squirrel.GtOrEq{"bucket": from.UTC()}).
+		Where(squirrel.Lt{"bucket": to.UTC()}).
+		GroupBy("value").
+		OrderBy("2 DESC", "value").
+		Limit(uint64(limit))
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	counts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.DimensionCount, error) {
+		var count domain.DimensionCount
+		err := row.Scan(&count.Value, &count.Clicks)
+		return count, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return counts, nil
+}
+
+// SaveVisitorSketches stores the sketches, replacing the ones of the same day. Live sketches only
+// ever grow, so the latest one always holds every visitor of the day.
+func (r *ClickRepository) SaveVisitorSketches(ctx context.Context, sketches []*domain.VisitorSketch) error {
+	if len(sketches) == 0 {
+		return nil
+	}
+
+	query := r.db.QueryBuilder.Insert("visitor_sketches").
+		Columns("day", "shortcode", "url_id", "sketch", "visitors", "updated_at").
+		Suffix("ON CONFLICT (shortcode, day, url_id) DO UPDATE SET sketch = EXCLUDED.sketch, visitors = EXCLUDED.visitors, updated_at = EXCLUDED.updated_at")
+
+	now := time.Now()
+	for _, sketch := range sketches {
+		query = query.Values(sketch.Day.Format(time.DateOnly), sketch.ShortCode, sketch.URLID, sketch.Sketch, sketch.Visitors, now)
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	if _, err = r.db.Pool.Exec(ctx, sql, args...); err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// GetVisitorSketches returns the sketches of the shortcode and its destinations for the days in [from, to).
+func (r *ClickRepository) GetVisitorSketches(ctx context.Context, code string, from, to time.Time) ([]*domain.VisitorSketch, error) {
+	return r.selectVisitorSketches(ctx, squirrel.And{
+		squirrel.Eq{"shortcode": code},
+		squirrel.GtOrEq{"day": from.Format(time.DateOnly)},
+		squirrel.Lt{"day": to.Format(time.DateOnly)},
+	})
+}
+
+// GetDaySketches returns the sketches of every shortcode for the day.
+func (r *ClickRepository) GetDaySketches(ctx context.Context, day time.Time) ([]*domain.VisitorSketch, error) {
+	return r.selectVisitorSketches(ctx, squirrel.Eq{"day": day.Format(time.DateOnly)})
+}
+
+func (r *ClickRepository) selectVisitorSketches(ctx context.Context, where squirrel.Sqlizer) ([]*domain.VisitorSketch, error) {
+	query := r.db.QueryBuilder.Select("day", "shortcode", "url_id", "sketch", "visitors").
+		From("visitor_sketches").
+		Where(where).
+		OrderBy("day", "url_id")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	sketches, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.VisitorSketch, error) {
+		var sketch domain.VisitorSketch
+		err := row.Scan(&sketch.Day, &sketch.ShortCode, &sketch.URLID, &sketch.Sketch, &sketch.Visitors)
+		return &sketch, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return sketches, nil
+}
@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+)
+
+type DomainRepository struct {
+	db *database.Postgres
+}
+
+func NewDomainRepository(db *database.Postgres) ports.DomainRepository {
+	return &DomainRepository{db}
+}
+
+func (r *DomainRepository) Save(ctx context.Context, customDomain *domain.Domain) (*domain.Domain, error) {
+	query := r.db.QueryBuilder.Insert("domains").
+		Columns("host", "workspace_id", "root_redirect", "not_found_url").
+		Values(customDomain.Host, customDomain.WorkspaceID, customDomain.RootRedirect, customDomain.NotFoundURL).
+		Suffix("RETURNING id, created_at, updated_at")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	err = r.db.Pool.QueryRow(ctx, sql, args...).Scan(&customDomain.ID, &customDomain.CreatedAt, &customDomain.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, domain.ErrDomainAlreadyExists
+		}
+		logger.L.Errorw("failed to insert domain", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return customDomain, nil
+}
+
+func (r *DomainRepository) GetByHost(ctx context.Context, host string) (*domain.Domain, error) {
+	return r.get(ctx, squirrel.Eq{"host": host})
+}
+
+func (r *DomainRepository) GetByID(ctx context.Context, id int) (*domain.Domain, error) {
+	return r.get(ctx, squirrel.Eq{"id": id})
+}
+
+// List returns the domains of a workspace, a workspace of 0 lists every domain.
+func (r *DomainRepository) List(ctx context.Context, workspaceID int) ([]*domain.Domain, error) {
+	query := r.selectQuery().OrderBy("id")
+	if workspaceID != 0 {
+		query = query.Where(squirrel.Eq{"workspace_id": workspaceID})
+	}
+
+	return r.list(ctx, query)
+}
+
+func (r *DomainRepository) Update(ctx context.Context, id int, update domain.DomainUpdate) (*domain.Domain, error) {
+	query := r.db.QueryBuilder.Update("domains").
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id})
+	if update.RootRedirect != nil {
+		query = query.Set("root_redirect", *update.RootRedirect)
+	}
+	if update.NotFoundURL != nil {
+		query = query.Set("not_found_url", *update.NotFoundURL)
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	tag, err := r.db.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// Delete removes a domain that has no shortcodes left, otherwise it fails with ErrDomainInUse.
+func (r *DomainRepository) Delete(ctx context.Context, id int) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		logger.L.Errorw("failed to start transaction", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	defer tx.Rollback(context.Background())
+
+	var host string
+	if err = tx.QueryRow(ctx, "DELETE FROM domains WHERE id = $1 RETURNING host", id).Scan(&host); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrDataNotFound
+		}
+		logger.L.Errorw("failed to delete domain", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	var inUse bool
+	if err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM shortcodes WHERE code LIKE $1)", domain.LinkKey(host, "%")).Scan(&inUse); err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	if inUse {
+		return domain.ErrDomainInUse
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.L.Errorw("failed to commit transaction", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (r *DomainRepository) get(ctx context.Context, where squirrel.Eq) (*domain.Domain, error) {
+	domains, err := r.list(ctx, r.selectQuery().Where(where).Limit(1))
+	if err != nil {
+		return nil, err
+	}
+	if len(domains) == 0 {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return domains[0], nil
+}
+
+func (r *DomainRepository) selectQuery() squirrel.SelectBuilder {
+	return r.db.QueryBuilder.Select("id", "host", "workspace_id", "root_redirect", "not_found_url", "created_at", "updated_at").
+		From("domains")
+}
+
+func (r *DomainRepository) list(ctx context.Context, query squirrel.SelectBuilder) ([]*domain.Domain, error) {
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	domains, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Domain, error) {
+		var data domain.Domain
+		err := row.Scan(&data.ID, &data.Host, &data.WorkspaceID, &data.RootRedirect, &data.NotFoundURL, &data.CreatedAt, &data.UpdatedAt)
+		return &data, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return domains, nil
+}
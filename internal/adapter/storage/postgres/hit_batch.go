@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// hitBatchSize is how many rows a single hit update changes at most, it keeps the number of
+// parameters far below the limit of postgres.
+var hitBatchSize = 1000
+
+// addHits adds the hits to total_hit of the rows of table whose key column matches, with one
+// UPDATE ... FROM (VALUES ...) per batch. All batches are written in one transaction, so that the
+// hit counter can keep every hit of a failed call without counting some of them twice. The keys
+// are sorted first so that the flushes of several instances lock the rows in the same order and
+// can't deadlock each other.
+func addHits[K cmp.Ordered](ctx context.Context, db *database.Postgres, table, column, keyType string, hits map[K]int64) error {
+	keys := make([]K, 0, len(hits))
+	for key := range hits {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.L.Errorw("failed to create transaction", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	defer tx.Rollback(context.Background())
+
+	for start := 0; start < len(keys); start += hitBatchSize {
+		batch := keys[start:min(start+hitBatchSize, len(keys))]
+
+		values := make([]string, 0, len(batch))
+		args := make([]any, 0, 2*len(batch)+1)
+		for i, key := range batch {
+			values = append(values, fmt.Sprintf("($%d::%s, $%d::bigint)", 2*i+1, keyType, 2*i+2))
+			args = append(args, key, hits[key])
+		}
+		args = append(args, time.Now())
+
+		sql := fmt.Sprintf(`UPDATE %[1]s SET total_hit = %[1]s.total_hit + v.hits, updated_at = $%[2]d
+			FROM (VALUES %[3]s) AS v(key, hits)
+			WHERE %[1]s.%[4]s = v.key`, table, len(args), strings.Join(values, ", "), column)
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			logger.L.Errorw("failed to add hits", "table", table, "rows", len(batch), "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.L.Errorw("failed to commit transaction", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
@@ -0,0 +1,373 @@
+package postgres
+
+import (
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"strings"
+	"time"
+)
+
+type RevisionRepository struct {
+	db *database.Postgres
+}
+
+func NewRevisionRepository(db *database.Postgres) ports.RevisionRepository {
+	return &RevisionRepository{db}
+}
+
+func (r *RevisionRepository) List(ctx context.Context, code string) ([]*domain.Revision, error) {
+	query := r.db.QueryBuilder.Select("r.id", "s.code", "r.revision", "r.action", "r.actor", "r.snapshot", "r.diff", "r.created_at").
+		From("shortcode_revisions r").
+		Join("shortcodes s ON s.id = r.shortcode_id").
+		Where(squirrel.Eq{"s.code": code}).
+		OrderBy("r.revision DESC")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+	defer rows.Close()
+
+	var results []*domain.Revision
+	for rows.Next() {
+		var row domain.Revision
+		if err = rows.Scan(&row.ID, &row.ShortCode, &row.Revision, &row.Action, &row.Actor, &row.Snapshot, &row.Diff, &row.CreatedAt); err != nil {
+			logger.L.Errorw("failed to scan row", "error", err.Error())
+			return nil, domain.ErrInternalServerError
+		}
+		results = append(results, &row)
+	}
+	if err = rows.Err(); err != nil {
+		logger.L.Errorw("failed to read rows", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return results, nil
+}
+
+// Rollback restores the destinations, strategy and settings stored in an earlier revision. The
+// restore and the new revision that records it are written in a single transaction.
+func (r *RevisionRepository) Rollback(ctx context.Context, code string, revision int) (*domain.Revision, error) {
+	action := fmt.Sprintf("%s:%d", domain.RevisionRollback, revision)
+
+	return withRevision(ctx, r.db, code, action, func(tx pgx.Tx, shortcodeID int, before *domain.LinkSnapshot) error {
+		var target domain.LinkSnapshot
+		err := tx.QueryRow(ctx, "SELECT snapshot FROM shortcode_revisions WHERE shortcode_id = $1 AND revision = $2", shortcodeID, revision).
+			Scan(&target)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrDataNotFound
+			}
+			logger.L.Errorw("failed to execute query", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+
+		_, err = tx.Exec(ctx,
+			`UPDATE shortcodes SET strategy = $1, password_hash = $2, og_title = $3, og_description = $4, og_image = $5,
+				redirect_type = $6, redirect_delay = $7, updated_at = $8
+			WHERE id = $9`,
+			target.Strategy, target.PasswordHash, target.Social.Title, target.Social.Description, target.Social.Image,
+			redirectType(target.Redirect.Type), target.Redirect.Delay, time.Now(), shortcodeID)
+		if err != nil {
+			logger.L.Errorw("failed to execute query", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+
+		if err = applyDestinations(ctx, tx, code, target.Destinations); err != nil {
+			return err
+		}
+
+		return replaceTags(ctx, tx, shortcodeID, target.Tags)
+	})
+}
+
+// withRevision locks the shortcode, runs fn and records the resulting changes as a new revision,
+// in the audit log and as webhook deliveries, all in one transaction. When fn changed nothing no
+// revision is written and nil is returned. Shortcodes created before revisions existed first get a
+// baseline revision of their old state.
+func withRevision(ctx context.Context, db *database.Postgres, code, action string, fn func(tx pgx.Tx, shortcodeID int, before *domain.LinkSnapshot) error) (*domain.Revision, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		logger.L.Errorw("failed to start transaction", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+	defer tx.Rollback(ctx)
+
+	var shortcodeID, workspaceID int
+	err = tx.QueryRow(ctx, "SELECT id, COALESCE(workspace_id, 0) FROM shortcodes WHERE code = $1 FOR UPDATE", code).Scan(&shortcodeID, &workspaceID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrDataNotFound
+		}
+		logger.L.Errorw("failed to lock shortcode", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	before, err := loadSnapshot(ctx, tx, shortcodeID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest int
+	err = tx.QueryRow(ctx, "SELECT COALESCE(MAX(revision), 0) FROM shortcode_revisions WHERE shortcode_id = $1", shortcodeID).Scan(&latest)
+	if err != nil {
+		logger.L.Errorw("failed to get latest revision", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	if latest == 0 {
+		// shortcodes created before revisions existed get a baseline of their state first
+		_, err = insertRevision(ctx, tx, shortcodeID, 1, domain.RevisionCreate, domain.AnonymousActor, before, domain.DiffSnapshots(&domain.LinkSnapshot{}, before))
+		if err != nil {
+			return nil, err
+		}
+		latest = 1
+	}
+
+	if err = fn(tx, shortcodeID, before); err != nil {
+		return nil, err
+	}
+
+	after, err := loadSnapshot(ctx, tx, shortcodeID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	var revision *domain.Revision
+	if diff := domain.DiffSnapshots(before, after); len(diff) > 0 {
+		revision, err = insertRevision(ctx, tx, shortcodeID, latest+1, action, domain.ActorFromContext(ctx), after, diff)
+		if err != nil {
+			return nil, err
+		}
+		revision.ShortCode = code
+
+		_, err = tx.Exec(ctx, "UPDATE shortcodes SET fingerprint = NULLIF($1, '') WHERE id = $2", after.Fingerprint(), shortcodeID)
+		if err != nil {
+			logger.L.Errorw("failed to update fingerprint", "error", err.Error())
+			return nil, domain.ErrInternalServerError
+		}
+
+		// rollbacks are recorded as "rollback:<revision>", the audit log only keeps the kind of change
+		kind, _, _ := strings.Cut(action, ":")
+		entry := domain.NewAuditEntry("link."+kind, domain.AuditTargetLink, code, workspaceID, domain.NewAuditLink(before), domain.NewAuditLink(after))
+		if err = insertAuditEntries(ctx, db, tx, []*domain.AuditEntry{entry}); err != nil {
+			return nil, err
+		}
+		event := domain.NewWebhookEvent(domain.WebhookLinkUpdated, code, domain.NewWebhookLink(code, kind, domain.NewAuditLink(after)))
+		if err = enqueueWebhookEvents(ctx, tx, []*domain.WebhookEvent{event}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.L.Errorw("failed to commit transaction", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return revision, nil
+}
+
+// recordCreate writes the baseline revision of a shortcode that was just inserted in tx, together
+// with its audit entry and webhook delivery, so that no link exists without its history.
+func recordCreate(ctx context.Context, db *database.Postgres, tx pgx.Tx, shortcodeID int, code string, workspaceID int) (*domain.Revision, error) {
+	snapshot, err := loadSnapshot(ctx, tx, shortcodeID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := insertRevision(ctx, tx, shortcodeID, 1, domain.RevisionCreate, domain.ActorFromContext(ctx), snapshot, domain.DiffSnapshots(&domain.LinkSnapshot{}, snapshot))
+	if err != nil {
+		return nil, err
+	}
+	revision.ShortCode = code
+
+	entry := domain.NewAuditEntry(domain.AuditLinkCreate, domain.AuditTargetLink, code, workspaceID, nil, domain.NewAuditLink(snapshot))
+	if err = insertAuditEntries(ctx, db, tx, []*domain.AuditEntry{entry}); err != nil {
+		return nil, err
+	}
+	event := domain.NewWebhookEvent(domain.WebhookLinkCreated, code, domain.NewWebhookLink(code, "", domain.NewAuditLink(snapshot)))
+	if err = enqueueWebhookEvents(ctx, tx, []*domain.WebhookEvent{event}); err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+func insertRevision(ctx context.Context, tx pgx.Tx, shortcodeID, number int, action, actor string, snapshot *domain.LinkSnapshot, diff []domain.RevisionChange) (*domain.Revision, error) {
+	if diff == nil {
+		diff = []domain.RevisionChange{}
+	}
+
+	revision := &domain.Revision{
+		Revision: number,
+		Action:   action,
+		Actor:    actor,
+		Snapshot: *snapshot,
+		Diff:     diff,
+	}
+
+	err := tx.QueryRow(ctx,
+		`INSERT INTO shortcode_revisions (shortcode_id, revision, action, actor, snapshot, diff)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		shortcodeID, number, action, actor, snapshot, diff,
+	).Scan(&revision.ID, &revision.CreatedAt)
+	if err != nil {
+		logger.L.Errorw("failed to insert revision", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return revision, nil
+}
+
+func loadSnapshot(ctx context.Context, tx pgx.Tx, shortcodeID int, code string) (*domain.LinkSnapshot, error) {
+	var snapshot domain.LinkSnapshot
+
+	err := tx.QueryRow(ctx,
+		`SELECT strategy, password_hash, og_title, og_description, og_image, redirect_type, redirect_delay
+		FROM shortcodes WHERE id = $1`, shortcodeID).
+		Scan(&snapshot.Strategy, &snapshot.PasswordHash, &snapshot.Social.Title, &snapshot.Social.Description, &snapshot.Social.Image,
+			&snapshot.Redirect.Type, &snapshot.Redirect.Delay)
+	if err != nil {
+		logger.L.Errorw("failed to load shortcode snapshot", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := tx.Query(ctx, "SELECT id, original, enabled FROM urls WHERE shortcode = $1 ORDER BY id", code)
+	if err != nil {
+		logger.L.Errorw("failed to load destinations snapshot", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+	snapshot.Destinations, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.SnapshotDestination, error) {
+		var destination domain.SnapshotDestination
+		err := row.Scan(&destination.ID, &destination.URL, &destination.Enabled)
+		return destination, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to load destinations snapshot", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err = tx.Query(ctx, "SELECT t.name FROM shortcode_tags st JOIN tags t ON t.id = st.tag_id WHERE st.shortcode_id = $1 ORDER BY t.name", shortcodeID)
+	if err != nil {
+		logger.L.Errorw("failed to load tags snapshot", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+	snapshot.Tags, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		logger.L.Errorw("failed to load tags snapshot", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return &snapshot, nil
+}
+
+// applyDestinations makes the destinations of a shortcode match the given list. Existing rows are
+// matched by id first and by URL second so they keep their hit counts, the rest is inserted or deleted.
+func applyDestinations(ctx context.Context, tx pgx.Tx, code string, destinations []domain.SnapshotDestination) error {
+	current, err := tx.Query(ctx, "SELECT id, original, enabled FROM urls WHERE shortcode = $1 ORDER BY id", code)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	existing, err := pgx.CollectRows(current, func(row pgx.CollectableRow) (domain.SnapshotDestination, error) {
+		var destination domain.SnapshotDestination
+		err := row.Scan(&destination.ID, &destination.URL, &destination.Enabled)
+		return destination, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	byID := make(map[int]domain.SnapshotDestination, len(existing))
+	byURL := make(map[string]domain.SnapshotDestination, len(existing))
+	for _, destination := range existing {
+		byID[destination.ID] = destination
+		if _, ok := byURL[destination.URL]; !ok {
+			byURL[destination.URL] = destination
+		}
+	}
+
+	keep := make(map[int]bool, len(destinations))
+	var inserts []domain.SnapshotDestination
+	for _, destination := range destinations {
+		match, ok := byID[destination.ID]
+		if !ok || match.URL != destination.URL || keep[match.ID] {
+			match, ok = byURL[destination.URL]
+		}
+		if !ok || keep[match.ID] {
+			inserts = append(inserts, destination)
+			continue
+		}
+
+		keep[match.ID] = true
+		if match.Enabled != destination.Enabled {
+			_, err = tx.Exec(ctx, "UPDATE urls SET enabled = $1, updated_at = $2 WHERE id = $3", destination.Enabled, time.Now(), match.ID)
+			if err != nil {
+				logger.L.Errorw("failed to execute query", "error", err.Error())
+				return domain.ErrInternalServerError
+			}
+		}
+	}
+
+	var remove []int
+	for _, destination := range existing {
+		if !keep[destination.ID] {
+			remove = append(remove, destination.ID)
+		}
+	}
+	if len(remove) > 0 {
+		if _, err = tx.Exec(ctx, "DELETE FROM urls WHERE id = ANY($1)", remove); err != nil {
+			logger.L.Errorw("failed to execute query", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+	}
+
+	for _, destination := range inserts {
+		_, err = tx.Exec(ctx, "INSERT INTO urls (shortcode, original, enabled) VALUES ($1, $2, $3)", code, destination.URL, destination.Enabled)
+		if err != nil {
+			logger.L.Errorw("failed to execute query", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+	}
+
+	return nil
+}
+
+// replaceTags replaces all tags of a shortcode, creating the tags that don't exist yet.
+func replaceTags(ctx context.Context, tx pgx.Tx, shortcodeID int, tags []string) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM shortcode_tags WHERE shortcode_id = $1", shortcodeID); err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, "INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING", tags); err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	_, err := tx.Exec(ctx, "INSERT INTO shortcode_tags (shortcode_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)", shortcodeID, tags)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
@@ -7,11 +7,51 @@ import (
 	"URLRotatorGo/internal/core/ports"
 	"context"
 	"errors"
+	"fmt"
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 )
 
+// uniqueViolation is the postgres error code raised when a unique constraint is violated.
+const uniqueViolation = "23505"
+
+// redirectType stores the default redirect type for shortcodes that don't set one.
+func redirectType(value domain.RedirectType) domain.RedirectType {
+	if value == "" {
+		return domain.DefaultRedirectType
+	}
+
+	return value
+}
+
+// nullableID stores a missing owner or workspace as NULL.
+func nullableID(id int) any {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+// scopeFilter limits a query on the shortcodes table, aliased as s, to the links of the scope.
+func scopeFilter(scope domain.LinkScope) squirrel.Sqlizer {
+	switch {
+	case scope.WorkspaceID != 0:
+		return squirrel.Eq{"s.workspace_id": scope.WorkspaceID}
+	case scope.OwnerID != 0:
+		return squirrel.Eq{"s.owner_id": scope.OwnerID, "s.workspace_id": nil}
+	case scope.Unowned:
+		return squirrel.Eq{"s.owner_id": nil, "s.workspace_id": nil}
+	default:
+		return nil
+	}
+}
+
 type ShortCodeRepository struct {
 	db *database.Postgres
 }
@@ -21,7 +61,8 @@ func NewShortCodeRepository(db *database.Postgres) ports.ShortCodeRepository {
 }
 
 func (r *ShortCodeRepository) GetShortCode(ctx context.Context, code string) (*domain.ShortCode, error) {
-	query := r.db.QueryBuilder.Select("id", "code", "total_hit", "strategy", "created_at", "updated_at").
+	query := r.db.QueryBuilder.Select("id", "code", "COALESCE(owner_id, 0)", "COALESCE(workspace_id, 0)", "total_hit", "strategy", "password_hash", "og_title", "og_description", "og_image",
+		"redirect_type", "redirect_delay", "created_at", "updated_at").
 		From("shortcodes").
 		Where(squirrel.Eq{"code": code}).
 		Limit(1)
@@ -37,8 +78,16 @@ func (r *ShortCodeRepository) GetShortCode(ctx context.Context, code string) (*d
 		Scan(
 			&data.ID,
 			&data.Code,
+			&data.OwnerID,
+			&data.WorkspaceID,
 			&data.TotalHit,
 			&data.Strategy,
+			&data.PasswordHash,
+			&data.Social.Title,
+			&data.Social.Description,
+			&data.Social.Image,
+			&data.Redirect.Type,
+			&data.Redirect.Delay,
 			&data.CreatedAt,
 			&data.UpdatedAt,
 		)
@@ -54,76 +103,508 @@ func (r *ShortCodeRepository) GetShortCode(ctx context.Context, code string) (*d
 	return &data, nil
 }
 
-func (r *ShortCodeRepository) UpdateHit(ctx context.Context, code string) error {
-	tx, err := r.db.Pool.Begin(ctx)
+// FindByFingerprint returns the oldest shortcode of the owner on the host whose destinations and
+// strategy match the fingerprint. A zero owner only matches anonymous shortcodes, an empty host the
+// default domain.
+func (r *ShortCodeRepository) FindByFingerprint(ctx context.Context, owner domain.LinkScope, host, fingerprint string) (*domain.ShortCode, error) {
+	where := squirrel.And{squirrel.Eq{"fingerprint": fingerprint, "workspace_id": nullableID(owner.WorkspaceID)}}
+	if owner.WorkspaceID == 0 {
+		where = append(where, squirrel.Eq{"owner_id": nullableID(owner.OwnerID)})
+	}
+	if host == "" {
+		where = append(where, squirrel.NotLike{"code": "%/%"})
+	} else {
+		where = append(where, squirrel.Like{"code": domain.LinkKey(host, "%")})
+	}
+
+	query := r.db.QueryBuilder.Select("id", "code", "COALESCE(owner_id, 0)", "COALESCE(workspace_id, 0)", "total_hit", "strategy", "password_hash", "og_title", "og_description", "og_image",
+		"redirect_type", "redirect_delay", "created_at", "updated_at").
+		From("shortcodes").
+		Where(where).
+		OrderBy("id").
+		Limit(1)
+
+	sql, args, err := query.ToSql()
 	if err != nil {
-		logger.L.Errorw("failed to start transaction", "error", err.Error())
-		return domain.ErrInternalServerError
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback(ctx)
+
+	data := domain.ShortCode{Fingerprint: fingerprint}
+	err = r.db.Pool.QueryRow(ctx, sql, args...).
+		Scan(
+			&data.ID,
+			&data.Code,
+			&data.OwnerID,
+			&data.WorkspaceID,
+			&data.TotalHit,
+			&data.Strategy,
+			&data.PasswordHash,
+			&data.Social.Title,
+			&data.Social.Description,
+			&data.Social.Image,
+			&data.Redirect.Type,
+			&data.Redirect.Delay,
+			&data.CreatedAt,
+			&data.UpdatedAt,
+		)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrDataNotFound
 		}
-	}()
 
-	query := r.db.QueryBuilder.Update("shortcodes").
-		Set("total_hit", squirrel.Expr("total_hit+1")).
-		Set("updated_at", time.Now()).
-		Where(squirrel.Eq{"code": code})
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return &data, nil
+}
+
+// AddHits adds the hits counted since the last flush to the shortcodes with the given keys.
+func (r *ShortCodeRepository) AddHits(ctx context.Context, hits map[string]int64) error {
+	return addHits(ctx, r.db, "shortcodes", "code", "varchar", hits)
+}
+
+// Save inserts the shortcode and its destinations and records its first revision, audit entry and
+// webhook delivery, all in one transaction.
+func (r *ShortCodeRepository) Save(ctx context.Context, url *domain.ShortCode, urls []*domain.URL) (*domain.ShortCode, []*domain.URL, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		logger.L.Errorw("failed to create transaction", "error", err.Error())
+		return nil, nil, domain.ErrInternalServerError
+	}
+	defer tx.Rollback(context.Background())
+
+	query := r.db.QueryBuilder.Insert("shortcodes").
+		Columns("code", "owner_id", "workspace_id", "strategy", "password_hash", "fingerprint", "og_title", "og_description", "og_image", "redirect_type", "redirect_delay").
+		Values(url.Code, nullableID(url.OwnerID), nullableID(url.WorkspaceID), url.Strategy, url.PasswordHash, squirrel.Expr("NULLIF(?, '')", url.Fingerprint),
+			url.Social.Title, url.Social.Description, url.Social.Image, redirectType(url.Redirect.Type), url.Redirect.Delay).
+		Suffix("RETURNING id, code, strategy, created_at")
 
 	sql, args, err := query.ToSql()
 	if err != nil {
 		logger.L.Errorw("failed to build query", "error", err.Error())
-		return domain.ErrInternalServerError
+		return nil, nil, domain.ErrInternalServerError
 	}
 
-	_, err = tx.Exec(ctx, sql, args...)
+	var shortcodeID int
+	err = tx.QueryRow(ctx, sql, args...).Scan(&shortcodeID, &url.Code, &url.Strategy, &url.CreatedAt)
 	if err != nil {
-		logger.L.Errorw("failed to execute query", "error", err.Error())
-		return domain.ErrInternalServerError
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, nil, domain.ErrCodeAlreadyExists
+		}
+		logger.L.Errorw("failed to insert shortcode", "error", err.Error())
+		return nil, nil, domain.ErrInternalServerError
+	}
+	url.ID = strconv.Itoa(shortcodeID)
+
+	links, err := insertURLs(ctx, r.db, tx, urls)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err = recordCreate(ctx, r.db, tx, shortcodeID, url.Code, url.WorkspaceID); err != nil {
+		return nil, nil, err
 	}
 
 	if err = tx.Commit(ctx); err != nil {
 		logger.L.Errorw("failed to commit transaction", "error", err.Error())
-		return domain.ErrInternalServerError
+		return nil, nil, domain.ErrInternalServerError
 	}
 
-	return nil
+	return url, links, nil
 }
 
-func (r *ShortCodeRepository) Save(ctx context.Context, url *domain.ShortCode) (*domain.ShortCode, error) {
+// SaveBatch inserts all shortcodes and their destinations in one transaction, with a single
+// statement each. Shortcodes whose code is already taken are skipped together with their
+// destinations, so the results only contain the rows that were actually inserted. The audit
+// entries and webhook deliveries of the new links are written in the same transaction, if anything
+// fails nothing is inserted.
+func (r *ShortCodeRepository) SaveBatch(ctx context.Context, shortcodes []*domain.ShortCode, urls []*domain.URL) ([]*domain.ShortCode, []*domain.URL, error) {
+	if len(shortcodes) == 0 {
+		return nil, nil, nil
+	}
+
 	tx, err := r.db.Pool.Begin(ctx)
 	if err != nil {
 		logger.L.Errorw("failed to create transaction", "error", err.Error())
-		return nil, domain.ErrInternalServerError
+		return nil, nil, domain.ErrInternalServerError
+	}
+	defer tx.Rollback(context.Background())
+
+	query := r.db.QueryBuilder.Insert("shortcodes").
+		Columns("code", "owner_id", "workspace_id", "strategy", "password_hash", "fingerprint", "redirect_type", "redirect_delay").
+		Suffix("ON CONFLICT (code) DO NOTHING RETURNING id, code, COALESCE(owner_id, 0), COALESCE(workspace_id, 0), strategy, password_hash, created_at")
+
+	for _, shortcode := range shortcodes {
+		query = query.Values(shortcode.Code, nullableID(shortcode.OwnerID), nullableID(shortcode.WorkspaceID), shortcode.Strategy, shortcode.PasswordHash, squirrel.Expr("NULLIF(?, '')", shortcode.Fingerprint),
+			redirectType(shortcode.Redirect.Type), shortcode.Redirect.Delay)
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, nil, domain.ErrInternalServerError
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, nil, domain.ErrInternalServerError
+	}
+
+	saved, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.ShortCode, error) {
+		var shortcode domain.ShortCode
+		err := row.Scan(&shortcode.ID, &shortcode.Code, &shortcode.OwnerID, &shortcode.WorkspaceID, &shortcode.Strategy, &shortcode.PasswordHash, &shortcode.CreatedAt)
+		return &shortcode, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to insert shortcodes", "error", err.Error())
+		return nil, nil, domain.ErrInternalServerError
+	}
+
+	inserted := make(map[string]bool, len(saved))
+	for _, shortcode := range saved {
+		inserted[shortcode.Code] = true
+	}
+	kept := make([]*domain.URL, 0, len(urls))
+	for _, url := range urls {
+		if inserted[url.ShortCode] {
+			kept = append(kept, url)
+		}
+	}
+
+	links, err := insertURLs(ctx, r.db, tx, kept)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = recordBatchCreate(ctx, r.db, tx, shortcodes, saved, links); err != nil {
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.L.Errorw("failed to commit transaction", "error", err.Error())
+		return nil, nil, domain.ErrInternalServerError
+	}
+
+	return saved, links, nil
+}
+
+// recordBatchCreate writes the audit entries and webhook deliveries of shortcodes inserted in tx by
+// SaveBatch. Bulk imports skip revisions, so the state of each link is taken from the rows instead.
+func recordBatchCreate(ctx context.Context, db *database.Postgres, tx pgx.Tx, requested, saved []*domain.ShortCode, links []*domain.URL) error {
+	if len(saved) == 0 {
+		return nil
+	}
+
+	redirects := make(map[string]domain.Redirect, len(requested))
+	for _, shortcode := range requested {
+		redirects[shortcode.Code] = shortcode.Redirect
+	}
+	destinations := make(map[string][]domain.SnapshotDestination, len(saved))
+	for _, link := range links {
+		destinations[link.ShortCode] = append(destinations[link.ShortCode], domain.SnapshotDestination{
+			ID:      link.ID,
+			URL:     link.Original,
+			Enabled: link.Enabled,
+		})
+	}
+
+	entries := make([]*domain.AuditEntry, 0, len(saved))
+	events := make([]*domain.WebhookEvent, 0, len(saved))
+	for _, shortcode := range saved {
+		link := &domain.AuditLink{
+			Strategy:     shortcode.Strategy,
+			Protected:    shortcode.PasswordHash != "",
+			Redirect:     redirects[shortcode.Code],
+			Destinations: destinations[shortcode.Code],
+		}
+		entries = append(entries, domain.NewAuditEntry(domain.AuditLinkCreate, domain.AuditTargetLink, shortcode.Code, shortcode.WorkspaceID, nil, link))
+		events = append(events, domain.NewWebhookEvent(domain.WebhookLinkCreated, shortcode.Code, domain.NewWebhookLink(shortcode.Code, "", link)))
+	}
+
+	if err := insertAuditEntries(ctx, db, tx, entries); err != nil {
+		return err
+	}
+
+	return enqueueWebhookEvents(ctx, tx, events)
+}
+
+// exportFetchSize is the number of rows fetched from the export cursor per round trip.
+const exportFetchSize = 1000
+
+type exportedURL struct {
+	ID        int       `json:"id"`
+	Original  string    `json:"original"`
+	Enabled   bool      `json:"enabled"`
+	TotalHit  int       `json:"total_hit"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Export reads every shortcode matching the filter through a server-side cursor and passes them
+// one by one to fn, so the result set is never loaded into memory at once.
+func (r *ShortCodeRepository) Export(ctx context.Context, scope domain.LinkScope, filter domain.ExportFilter, fn func(*domain.ShortCodeExport) error) error {
+	query := r.db.QueryBuilder.Select(
+		"s.id", "s.code", "s.total_hit", "s.strategy", "s.created_at", "s.updated_at",
+		`COALESCE(json_agg(json_build_object(
+			'id', u.id, 'original', u.original, 'enabled', u.enabled, 'total_hit', u.total_hit,
+			'created_at', u.created_at::timestamptz, 'updated_at', u.updated_at::timestamptz
+		) ORDER BY u.id) FILTER (WHERE u.id IS NOT NULL), '[]')`,
+	).
+		From("shortcodes s").
+		LeftJoin("urls u ON u.shortcode = s.code").
+		GroupBy("s.id").
+		OrderBy("s.id")
+
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where(squirrel.GtOrEq{"s.created_at": filter.CreatedAfter})
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where(squirrel.Lt{"s.created_at": filter.CreatedBefore})
+	}
+	if filter.Strategy != "" {
+		query = query.Where(squirrel.Eq{"s.strategy": filter.Strategy})
+	}
+	if where := scopeFilter(scope); where != nil {
+		query = query.Where(where)
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	tx, err := r.db.Pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		logger.L.Errorw("failed to start transaction", "error", err.Error())
+		return domain.ErrInternalServerError
 	}
-	defer func() {
+	// the transaction is read only, rolling it back also closes the cursor
+	defer tx.Rollback(context.Background())
+
+	if _, err = tx.Exec(ctx, "DECLARE export_cursor NO SCROLL CURSOR FOR "+sql, args...); err != nil {
+		logger.L.Errorw("failed to declare cursor", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	for {
+		rows, err := tx.Query(ctx, fmt.Sprintf("FETCH FORWARD %d FROM export_cursor", exportFetchSize))
 		if err != nil {
-			tx.Rollback(ctx)
+			logger.L.Errorw("failed to fetch from cursor", "error", err.Error())
+			return domain.ErrInternalServerError
 		}
-	}()
 
-	query := r.db.QueryBuilder.Insert("shortcodes").
-		Columns("code", "strategy").
-		Values(url.Code, url.Strategy).
-		Suffix("RETURNING id, code, strategy, created_at")
+		fetched := 0
+		for rows.Next() {
+			fetched++
 
+			var data domain.ShortCodeExport
+			var urls []exportedURL
+			if err = rows.Scan(&data.ID, &data.Code, &data.TotalHit, &data.Strategy, &data.CreatedAt, &data.UpdatedAt, &urls); err != nil {
+				rows.Close()
+				logger.L.Errorw("failed to scan row", "error", err.Error())
+				return domain.ErrInternalServerError
+			}
+
+			for _, url := range urls {
+				data.URLs = append(data.URLs, &domain.URL{
+					ID:        url.ID,
+					ShortCode: data.Code,
+					TotalHit:  url.TotalHit,
+					Original:  url.Original,
+					Enabled:   url.Enabled,
+					CreatedAt: url.CreatedAt,
+					UpdatedAt: url.UpdatedAt,
+				})
+			}
+
+			if err = fn(&data); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			logger.L.Errorw("failed to read cursor", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+		if fetched < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+// List returns a page of the shortcodes in the scope.
+func (r *ShortCodeRepository) List(ctx context.Context, scope domain.LinkScope, query domain.LinkQuery) ([]*domain.ShortCode, error) {
+	return r.list(ctx, r.listQuery(scope, query))
+}
+
+// Search works like List but only returns shortcodes that have at least one destination matching
+// every word of the term. The last word is matched as a prefix.
+func (r *ShortCodeRepository) Search(ctx context.Context, scope domain.LinkScope, term string, query domain.LinkQuery) ([]*domain.ShortCode, error) {
+	tsquery := searchTSQuery(term)
+	if tsquery == "" {
+		return nil, nil
+	}
+
+	return r.list(ctx, r.listQuery(scope, query).Where(
+		`EXISTS (SELECT 1 FROM urls u WHERE u.shortcode = s.code
+			AND to_tsvector('simple', regexp_replace(u.original, '[^[:alnum:]]+', ' ', 'g')) @@ to_tsquery('simple', ?))`,
+		tsquery,
+	))
+}
+
+func (r *ShortCodeRepository) listQuery(scope domain.LinkScope, query domain.LinkQuery) squirrel.SelectBuilder {
+	builder := r.db.QueryBuilder.Select(
+		"s.id", "s.code", "COALESCE(s.owner_id, 0)", "COALESCE(s.workspace_id, 0)", "s.total_hit", "s.strategy", "s.password_hash", "s.og_title", "s.og_description", "s.og_image",
+		"s.redirect_type", "s.redirect_delay", "s.created_at", "s.updated_at",
+		"ARRAY(SELECT t.name FROM shortcode_tags st JOIN tags t ON t.id = st.tag_id WHERE st.shortcode_id = s.id ORDER BY t.name)",
+	).
+		From("shortcodes s").
+		Limit(uint64(query.Limit))
+
+	if where := scopeFilter(scope); where != nil {
+		builder = builder.Where(where)
+	}
+	if query.Tag != "" {
+		builder = builder.Where(
+			"EXISTS (SELECT 1 FROM shortcode_tags st JOIN tags t ON t.id = st.tag_id WHERE st.shortcode_id = s.id AND t.name = ?)",
+			query.Tag,
+		)
+	}
+
+	column, value := "s.created_at", any(nil)
+	if query.Sort == domain.SortByHits {
+		column = "s.total_hit"
+	}
+	if query.After != nil {
+		value = query.After.CreatedAt
+		if query.Sort == domain.SortByHits {
+			value = query.After.TotalHit
+		}
+	}
+
+	direction, operator := "DESC", "<"
+	if query.Asc {
+		direction, operator = "ASC", ">"
+	}
+
+	if query.After != nil {
+		builder = builder.Where(fmt.Sprintf("(%s, s.id) %s (?, ?)", column, operator), value, query.After.ID)
+	}
+
+	return builder.OrderBy(column+" "+direction, "s.id "+direction)
+}
+
+func (r *ShortCodeRepository) list(ctx context.Context, query squirrel.SelectBuilder) ([]*domain.ShortCode, error) {
 	sql, args, err := query.ToSql()
 	if err != nil {
 		logger.L.Errorw("failed to build query", "error", err.Error())
 		return nil, domain.ErrInternalServerError
 	}
 
-	err = tx.QueryRow(ctx, sql, args...).Scan(&url.ID, &url.Code, &url.Strategy, &url.CreatedAt)
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
 	if err != nil {
-		logger.L.Errorw("failed to insert shortcode", "error", err.Error())
+		logger.L.Errorw("failed to execute query", "error", err.Error())
 		return nil, domain.ErrInternalServerError
 	}
+	defer rows.Close()
 
-	if err = tx.Commit(ctx); err != nil {
-		logger.L.Errorw("failed to commit transaction", "error", err.Error())
+	var results []*domain.ShortCode
+	for rows.Next() {
+		var data domain.ShortCode
+		if err = rows.Scan(&data.ID, &data.Code, &data.OwnerID, &data.WorkspaceID, &data.TotalHit, &data.Strategy, &data.PasswordHash,
+			&data.Social.Title, &data.Social.Description, &data.Social.Image, &data.Redirect.Type, &data.Redirect.Delay,
+			&data.CreatedAt, &data.UpdatedAt, &data.Tags); err != nil {
+			logger.L.Errorw("failed to scan row", "error", err.Error())
+			return nil, domain.ErrInternalServerError
+		}
+		results = append(results, &data)
+	}
+	if err = rows.Err(); err != nil {
+		logger.L.Errorw("failed to read rows", "error", err.Error())
 		return nil, domain.ErrInternalServerError
 	}
 
-	return url, nil
+	return results, nil
+}
+
+// searchTSQuery turns free text into a tsquery that requires every word, e.g. "example.com/promo"
+// becomes "example & com & promo:*".
+func searchTSQuery(term string) string {
+	words := strings.FieldsFunc(strings.ToLower(term), func(ch rune) bool {
+		return !unicode.IsLetter(ch) && !unicode.IsDigit(ch)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	words[len(words)-1] += ":*"
+
+	return strings.Join(words, " & ")
+}
+
+// Update changes the strategy, password and destinations of a shortcode. Destinations that are
+// kept keep their id, hit count and enabled state, new ones are enabled.
+func (r *ShortCodeRepository) Update(ctx context.Context, code string, update domain.LinkUpdate) (*domain.ShortCode, error) {
+	_, err := withRevision(ctx, r.db, code, domain.RevisionUpdate, func(tx pgx.Tx, shortcodeID int, before *domain.LinkSnapshot) error {
+		query := r.db.QueryBuilder.Update("shortcodes").
+			Set("updated_at", time.Now()).
+			Where(squirrel.Eq{"id": shortcodeID})
+		if update.Strategy != nil {
+			query = query.Set("strategy", *update.Strategy)
+		}
+		if update.PasswordHash != nil {
+			query = query.Set("password_hash", *update.PasswordHash)
+		}
+		if update.Social != nil {
+			query = query.Set("og_title", update.Social.Title).
+				Set("og_description", update.Social.Description).
+				Set("og_image", update.Social.Image)
+		}
+		if update.Redirect != nil {
+			query = query.Set("redirect_type", redirectType(update.Redirect.Type)).
+				Set("redirect_delay", update.Redirect.Delay)
+		}
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			logger.L.Errorw("failed to build query", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			logger.L.Errorw("failed to execute query", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+
+		if update.URLs == nil {
+			return nil
+		}
+
+		current := make(map[string]domain.SnapshotDestination, len(before.Destinations))
+		for _, destination := range before.Destinations {
+			current[destination.URL] = destination
+		}
+
+		destinations := make([]domain.SnapshotDestination, 0, len(update.URLs))
+		for _, url := range update.URLs {
+			destination, ok := current[url]
+			if !ok {
+				destination = domain.SnapshotDestination{URL: url, Enabled: true}
+			}
+			destinations = append(destinations, destination)
+		}
+
+		return applyDestinations(ctx, tx, code, destinations)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetShortCode(ctx, code)
 }
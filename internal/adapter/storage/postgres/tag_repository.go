@@ -0,0 +1,29 @@
+package postgres
+
+import (
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"github.com/jackc/pgx/v5"
+)
+
+type TagRepository struct {
+	db *database.Postgres
+}
+
+func NewTagRepository(db *database.Postgres) ports.TagRepository {
+	return &TagRepository{db}
+}
+
+// SetTags replaces all tags of a shortcode, creating the tags that don't exist yet.
+func (r *TagRepository) SetTags(ctx context.Context, code string, tags []string) ([]string, error) {
+	_, err := withRevision(ctx, r.db, code, domain.RevisionTags, func(tx pgx.Tx, shortcodeID int, before *domain.LinkSnapshot) error {
+		return replaceTags(ctx, tx, shortcodeID, tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
@@ -21,7 +21,7 @@ func NewURLRepository(db *database.Postgres) ports.URLRepository {
 }
 
 func (r *URLRepository) GetLinks(ctx context.Context, code string) ([]*domain.URL, error) {
-	query := r.db.QueryBuilder.Select("id", "shortcode", "total_hit", "original", "created_at", "updated_at").
+	query := r.db.QueryBuilder.Select("id", "shortcode", "total_hit", "original", "enabled", "created_at", "updated_at").
 		From("urls").
 		Where(squirrel.Eq{"shortcode": code}).
 		OrderBy("RANDOM()")
@@ -44,7 +44,7 @@ func (r *URLRepository) GetLinks(ctx context.Context, code string) ([]*domain.UR
 	var results []*domain.URL
 	for rows.Next() {
 		var row domain.URL
-		if err = rows.Scan(&row.ID, &row.ShortCode, &row.TotalHit, &row.Original, &row.CreatedAt, &row.UpdatedAt); err != nil {
+		if err = rows.Scan(&row.ID, &row.ShortCode, &row.TotalHit, &row.Original, &row.Enabled, &row.CreatedAt, &row.UpdatedAt); err != nil {
 			logger.L.Errorw("failed to scan row", "error", err.Error())
 			return results, domain.ErrInternalServerError
 		}
@@ -54,58 +54,42 @@ func (r *URLRepository) GetLinks(ctx context.Context, code string) ([]*domain.UR
 	return results, nil
 }
 
-func (r *URLRepository) UpdateHit(ctx context.Context, id string) error {
-	tx, err := r.db.Pool.Begin(ctx)
-	if err != nil {
-		logger.L.Errorw("failed to start transaction", "error", err.Error())
-		return domain.ErrInternalServerError
-	}
-	defer func() {
-		if err != nil {
-			tx.Rollback(ctx)
-		}
-	}()
-
-	query := r.db.QueryBuilder.Update("urls").
-		Set("total_hit", squirrel.Expr("total_hit+1")).
-		Set("updated_at", time.Now()).
-		Where(squirrel.Eq{"id": id})
+// AddHits adds the hits counted since the last flush to the destinations with the given ids.
+func (r *URLRepository) AddHits(ctx context.Context, hits map[int]int64) error {
+	return addHits(ctx, r.db, "urls", "id", "int", hits)
+}
 
-	sql, args, err := query.ToSql()
+func (r *URLRepository) Save(ctx context.Context, urls []*domain.URL) ([]*domain.URL, error) {
+	tx, err := r.db.Pool.Begin(ctx)
 	if err != nil {
-		logger.L.Errorw("failed to build query", "error", err.Error())
-		return domain.ErrInternalServerError
+		logger.L.Errorw("failed to create transaction", "error", err.Error())
+		return nil, domain.ErrInternalServerError
 	}
+	defer tx.Rollback(context.Background())
 
-	_, err = tx.Exec(ctx, sql, args...)
+	results, err := insertURLs(ctx, r.db, tx, urls)
 	if err != nil {
-		logger.L.Errorw("failed to execute query", "error", err.Error())
-		return domain.ErrInternalServerError
+		return nil, err
 	}
 
 	if err = tx.Commit(ctx); err != nil {
 		logger.L.Errorw("failed to commit transaction", "error", err.Error())
-		return domain.ErrInternalServerError
+		return nil, domain.ErrInternalServerError
 	}
 
-	return nil
+	return results, nil
 }
 
-func (r *URLRepository) Save(ctx context.Context, urls []*domain.URL) ([]*domain.URL, error) {
-	tx, err := r.db.Pool.Begin(ctx)
-	if err != nil {
-		logger.L.Errorw("failed to create transaction", "error", err.Error())
-		return nil, domain.ErrInternalServerError
+// insertURLs inserts the destinations with a single statement as part of tx, e.g. the transaction
+// that creates their shortcodes.
+func insertURLs(ctx context.Context, db *database.Postgres, tx pgx.Tx, urls []*domain.URL) ([]*domain.URL, error) {
+	if len(urls) == 0 {
+		return nil, nil
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback(ctx)
-		}
-	}()
 
-	query := r.db.QueryBuilder.Insert("urls").
+	query := db.QueryBuilder.Insert("urls").
 		Columns("shortcode", "original").
-		Suffix("RETURNING *")
+		Suffix("RETURNING id, shortcode, total_hit, original, enabled, created_at, updated_at")
 
 	for _, url := range urls {
 		query = query.Values(url.ShortCode, url.Original)
@@ -123,20 +107,54 @@ func (r *URLRepository) Save(ctx context.Context, urls []*domain.URL) ([]*domain
 		return nil, domain.ErrInternalServerError
 	}
 
-	var results []*domain.URL
-	for rows.Next() {
+	results, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.URL, error) {
 		var link domain.URL
-		if err = rows.Scan(&link.ID, &link.ShortCode, &link.TotalHit, &link.Original, &link.CreatedAt, &link.UpdatedAt); err != nil {
-			logger.L.Errorw("failed to scan row", "error", err.Error())
-			return nil, domain.ErrInternalServerError
-		}
-		results = append(results, &link)
-	}
-
-	if err = tx.Commit(ctx); err != nil {
-		logger.L.Errorw("failed to commit transaction", "error", err.Error())
+		err := row.Scan(&link.ID, &link.ShortCode, &link.TotalHit, &link.Original, &link.Enabled, &link.CreatedAt, &link.UpdatedAt)
+		return &link, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to insert destinations", "error", err.Error())
 		return nil, domain.ErrInternalServerError
 	}
 
 	return results, nil
 }
+
+func (r *URLRepository) SetEnabled(ctx context.Context, code string, id int, enabled bool) (*domain.URL, error) {
+	action := domain.RevisionDisable
+	if enabled {
+		action = domain.RevisionEnable
+	}
+
+	var link domain.URL
+	_, err := withRevision(ctx, r.db, code, action, func(tx pgx.Tx, shortcodeID int, before *domain.LinkSnapshot) error {
+		query := r.db.QueryBuilder.Update("urls").
+			Set("enabled", enabled).
+			Set("updated_at", time.Now()).
+			Where(squirrel.Eq{"id": id, "shortcode": code}).
+			Suffix("RETURNING id, shortcode, total_hit, original, enabled, created_at, updated_at")
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			logger.L.Errorw("failed to build query", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+
+		err = tx.QueryRow(ctx, sql, args...).
+			Scan(&link.ID, &link.ShortCode, &link.TotalHit, &link.Original, &link.Enabled, &link.CreatedAt, &link.UpdatedAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrDataNotFound
+			}
+			logger.L.Errorw("failed to execute query", "error", err.Error())
+			return domain.ErrInternalServerError
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
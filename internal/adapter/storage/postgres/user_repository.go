@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type UserRepository struct {
+	db *database.Postgres
+}
+
+func NewUserRepository(db *database.Postgres) ports.UserRepository {
+	return &UserRepository{db}
+}
+
+func (r *UserRepository) Save(ctx context.Context, user *domain.User) (*domain.User, error) {
+	query := r.db.QueryBuilder.Insert("users").
+		Columns("email", "password_hash").
+		Values(user.Email, user.PasswordHash).
+		Suffix("RETURNING id, created_at, updated_at")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	if err = r.db.Pool.QueryRow(ctx, sql, args...).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, domain.ErrEmailAlreadyExists
+		}
+		logger.L.Errorw("failed to insert user", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.get(ctx, squirrel.Eq{"email": email})
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*domain.User, error) {
+	return r.get(ctx, squirrel.Eq{"id": id})
+}
+
+func (r *UserRepository) get(ctx context.Context, where squirrel.Eq) (*domain.User, error) {
+	query := r.db.QueryBuilder.Select("id", "email", "password_hash", "created_at", "updated_at").
+		From("users").
+		Where(where).
+		Limit(1)
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	var user domain.User
+	err = r.db.Pool.QueryRow(ctx, sql, args...).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrDataNotFound
+		}
+
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,314 @@
+package postgres
+
+import (
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// webhookScope matches the webhooks, aliased as w, that receive the events of the shortcode s.
+const webhookScope = `((w.workspace_id IS NULL AND w.owner_id IS NULL)
+	OR w.workspace_id = s.workspace_id
+	OR (w.workspace_id IS NULL AND s.workspace_id IS NULL AND w.owner_id = s.owner_id))`
+
+const deliveryColumns = `d.id, d.delivery_id::text, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at,
+	d.last_status_code, d.last_error, d.created_at, d.delivered_at`
+
+type WebhookRepository struct {
+	db *database.Postgres
+}
+
+func NewWebhookRepository(db *database.Postgres) ports.WebhookRepository {
+	return &WebhookRepository{db}
+}
+
+func (r *WebhookRepository) Save(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+	query := r.db.QueryBuilder.Insert("webhooks").
+		Columns("workspace_id", "owner_id", "url", "secret", "events", "enabled").
+		Values(nullableID(webhook.WorkspaceID), nullableID(webhook.OwnerID), webhook.URL, webhook.Secret, webhook.Events, webhook.Enabled).
+		Suffix("RETURNING id, created_at, updated_at")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	err = r.db.Pool.QueryRow(ctx, sql, args...).Scan(&webhook.ID, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		logger.L.Errorw("failed to insert webhook", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return webhook, nil
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id int) (*domain.Webhook, error) {
+	webhooks, err := r.list(ctx, r.selectQuery().Where(squirrel.Eq{"id": id}).Limit(1))
+	if err != nil {
+		return nil, err
+	}
+	if len(webhooks) == 0 {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return webhooks[0], nil
+}
+
+// List returns the webhooks registered for exactly the scope, the zero scope lists every webhook.
+func (r *WebhookRepository) List(ctx context.Context, scope domain.LinkScope) ([]*domain.Webhook, error) {
+	query := r.selectQuery().OrderBy("id")
+	switch {
+	case scope.WorkspaceID != 0:
+		query = query.Where(squirrel.Eq{"workspace_id": scope.WorkspaceID})
+	case scope.OwnerID != 0:
+		query = query.Where(squirrel.Eq{"owner_id": scope.OwnerID, "workspace_id": nil})
+	}
+
+	return r.list(ctx, query)
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, id int, update domain.WebhookUpdate) (*domain.Webhook, error) {
+	query := r.db.QueryBuilder.Update("webhooks").
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id})
+	if update.URL != nil {
+		query = query.Set("url", *update.URL)
+	}
+	if update.Events != nil {
+		query = query.Set("events", update.Events)
+	}
+	if update.Enabled != nil {
+		query = query.Set("enabled", *update.Enabled)
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	tag, err := r.db.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// Delete removes the webhook together with its deliveries, including the pending ones.
+func (r *WebhookRepository) Delete(ctx context.Context, id int) error {
+	tag, err := r.db.Pool.Exec(ctx, "DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		logger.L.Errorw("failed to delete webhook", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// Enqueue queues a delivery of every event for the enabled webhooks of its shortcode that
+// subscribed to it. Events of shortcodes without webhooks are dropped.
+func (r *WebhookRepository) Enqueue(ctx context.Context, events ...*domain.WebhookEvent) error {
+	return enqueueWebhookEvents(ctx, r.db.Pool, events)
+}
+
+// ClaimDeliveries picks up to limit pending deliveries that are due and counts the attempt. They
+// are leased by moving their next attempt behind the lease, so that deliveries of an instance that
+// stops while sending are picked up again once it expired. Deliveries of disabled webhooks stay
+// queued until the webhook is enabled again.
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.db.Pool.Query(ctx,
+		`UPDATE webhook_deliveries d
+		SET attempts = d.attempts + 1, next_attempt_at = LOCALTIMESTAMP + make_interval(secs => $2)
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT q.id FROM webhook_deliveries q
+			JOIN webhooks qw ON qw.id = q.webhook_id AND qw.enabled
+			WHERE q.status = 'pending' AND q.next_attempt_at <= LOCALTIMESTAMP
+			ORDER BY q.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF q SKIP LOCKED
+		)
+		RETURNING `+deliveryColumns+`, w.url, w.secret`,
+		limit, lease.Seconds())
+	if err != nil {
+		logger.L.Errorw("failed to claim webhook deliveries", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	deliveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.WebhookDelivery, error) {
+		var delivery domain.WebhookDelivery
+		err := row.Scan(append(deliveryFields(&delivery), &delivery.URL, &delivery.Secret)...)
+		return &delivery, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return deliveries, nil
+}
+
+// CompleteDelivery stores the outcome of an attempt. Deliveries that are still pending are tried
+// again at NextAttemptAt, which is relative to the clock of the database like the claim. Every claim
+// counts an attempt, so once the lease ran out and the delivery was claimed again the outcome of the
+// earlier attempt is not stored and ErrDataNotFound returned.
+func (r *WebhookRepository) CompleteDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	retryIn := time.Until(delivery.NextAttemptAt).Seconds()
+
+	tag, err := r.db.Pool.Exec(ctx,
+		`UPDATE webhook_deliveries
+		SET status = $1, last_status_code = $2, last_error = $3,
+			next_attempt_at = LOCALTIMESTAMP + make_interval(secs => $4),
+			delivered_at = CASE WHEN $1 = 'succeeded' THEN LOCALTIMESTAMP END
+		WHERE id = $5 AND attempts = $6`,
+		delivery.Status, delivery.LastStatusCode, truncate(delivery.LastError, 1000), max(retryIn, 0), delivery.ID, delivery.Attempts)
+	if err != nil {
+		logger.L.Errorw("failed to update webhook delivery", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the deliveries of a webhook newest first, before continues a listing below
+// the given delivery.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID int, before int64, limit int) ([]*domain.WebhookDelivery, error) {
+	query := r.db.QueryBuilder.Select(deliveryColumns).
+		From("webhook_deliveries d").
+		Where(squirrel.Eq{"d.webhook_id": webhookID}).
+		OrderBy("d.id DESC").
+		Limit(uint64(limit))
+	if before != 0 {
+		query = query.Where(squirrel.Lt{"d.id": before})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	deliveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.WebhookDelivery, error) {
+		var delivery domain.WebhookDelivery
+		err := row.Scan(deliveryFields(&delivery)...)
+		return &delivery, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver queues the payload of an earlier delivery again as a new delivery with its own id,
+// the original delivery is kept in the log as it is.
+func (r *WebhookRepository) Redeliver(ctx context.Context, webhookID int, id int64) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	err := r.db.Pool.QueryRow(ctx,
+		`INSERT INTO webhook_deliveries AS d (webhook_id, event, payload)
+		SELECT webhook_id, event, payload FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2
+		RETURNING `+deliveryColumns,
+		id, webhookID).
+		Scan(deliveryFields(&delivery)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrDataNotFound
+		}
+		logger.L.Errorw("failed to redeliver webhook delivery", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return &delivery, nil
+}
+
+func (r *WebhookRepository) selectQuery() squirrel.SelectBuilder {
+	return r.db.QueryBuilder.Select("id", "COALESCE(workspace_id, 0)", "COALESCE(owner_id, 0)", "url", "secret", "events", "enabled", "created_at", "updated_at").
+		From("webhooks")
+}
+
+func (r *WebhookRepository) list(ctx context.Context, query squirrel.SelectBuilder) ([]*domain.Webhook, error) {
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	webhooks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Webhook, error) {
+		var data domain.Webhook
+		err := row.Scan(&data.ID, &data.WorkspaceID, &data.OwnerID, &data.URL, &data.Secret, &data.Events, &data.Enabled, &data.CreatedAt, &data.UpdatedAt)
+		return &data, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return webhooks, nil
+}
+
+func deliveryFields(delivery *domain.WebhookDelivery) []any {
+	return []any{&delivery.ID, &delivery.DeliveryID, &delivery.WebhookID, &delivery.Event, &delivery.Payload, &delivery.Status, &delivery.Attempts,
+		&delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt, &delivery.DeliveredAt}
+}
+
+// enqueueWebhookEvents turns the events into deliveries with a single insert through exec, which
+// may be a transaction so that the deliveries are only queued if the change is committed.
+func enqueueWebhookEvents(ctx context.Context, exec execer, events []*domain.WebhookEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(events))
+	codes := make([]string, 0, len(events))
+	payloads := make([]string, 0, len(events))
+	for _, event := range events {
+		names = append(names, event.Event)
+		codes = append(codes, event.ShortCode)
+		payloads = append(payloads, string(event.Payload))
+	}
+
+	_, err := exec.Exec(ctx,
+		`INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT w.id, e.event, e.payload::jsonb
+		FROM unnest($1::text[], $2::text[], $3::text[]) AS e(event, code, payload)
+		JOIN shortcodes s ON s.code = e.code
+		JOIN webhooks w ON w.enabled AND e.event = ANY(w.events) AND `+webhookScope,
+		names, codes, payloads)
+	if err != nil {
+		logger.L.Errorw("failed to enqueue webhook deliveries", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
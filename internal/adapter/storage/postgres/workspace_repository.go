@@ -0,0 +1,333 @@
+package postgres
+
+import (
+	"URLRotatorGo/infra/database"
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type WorkspaceRepository struct {
+	db *database.Postgres
+}
+
+func NewWorkspaceRepository(db *database.Postgres) ports.WorkspaceRepository {
+	return &WorkspaceRepository{db}
+}
+
+// Create stores the workspace and makes the user its first owner.
+func (r *WorkspaceRepository) Create(ctx context.Context, workspace *domain.Workspace, ownerID int) (*domain.Workspace, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		logger.L.Errorw("failed to start transaction", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(ctx, "INSERT INTO workspaces (name) VALUES ($1) RETURNING id, created_at, updated_at", workspace.Name).
+		Scan(&workspace.ID, &workspace.CreatedAt, &workspace.UpdatedAt)
+	if err != nil {
+		logger.L.Errorw("failed to insert workspace", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	_, err = tx.Exec(ctx, "INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)",
+		workspace.ID, ownerID, domain.RoleOwner)
+	if err != nil {
+		logger.L.Errorw("failed to insert workspace member", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.L.Errorw("failed to commit transaction", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	workspace.Role = domain.RoleOwner
+	return workspace, nil
+}
+
+// ListByUser returns the workspaces the user is a member of, together with the role of the user.
+func (r *WorkspaceRepository) ListByUser(ctx context.Context, userID int) ([]*domain.Workspace, error) {
+	query := r.db.QueryBuilder.Select("w.id", "w.name", "m.role", "w.created_at", "w.updated_at").
+		From("workspaces w").
+		Join("workspace_members m ON m.workspace_id = w.id").
+		Where(squirrel.Eq{"m.user_id": userID}).
+		OrderBy("w.id")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	workspaces, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Workspace, error) {
+		var workspace domain.Workspace
+		err := row.Scan(&workspace.ID, &workspace.Name, &workspace.Role, &workspace.CreatedAt, &workspace.UpdatedAt)
+		return &workspace, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return workspaces, nil
+}
+
+// GetRole returns the role of the user in the workspace, ErrDataNotFound if the user is not a member.
+func (r *WorkspaceRepository) GetRole(ctx context.Context, workspaceID, userID int) (domain.Role, error) {
+	var role domain.Role
+	err := r.db.Pool.QueryRow(ctx, "SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2",
+		workspaceID, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", domain.ErrDataNotFound
+		}
+
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return "", domain.ErrInternalServerError
+	}
+
+	return role, nil
+}
+
+func (r *WorkspaceRepository) ListMembers(ctx context.Context, workspaceID int) ([]*domain.Member, error) {
+	query := r.db.QueryBuilder.Select("m.user_id", "u.email", "m.role", "m.created_at").
+		From("workspace_members m").
+		Join("users u ON u.id = m.user_id").
+		Where(squirrel.Eq{"m.workspace_id": workspaceID}).
+		OrderBy("m.created_at", "m.user_id")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	members, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Member, error) {
+		var member domain.Member
+		err := row.Scan(&member.UserID, &member.Email, &member.Role, &member.CreatedAt)
+		return &member, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return members, nil
+}
+
+// SetMemberRole changes the role of a member. Demoting the last owner fails with ErrLastOwner.
+func (r *WorkspaceRepository) SetMemberRole(ctx context.Context, workspaceID, userID int, role domain.Role) error {
+	return r.changeMember(ctx, workspaceID, userID, func(tx pgx.Tx) (int64, error) {
+		tag, err := tx.Exec(ctx, "UPDATE workspace_members SET role = $1 WHERE workspace_id = $2 AND user_id = $3",
+			role, workspaceID, userID)
+		return tag.RowsAffected(), err
+	})
+}
+
+// RemoveMember removes the user from the workspace. Removing the last owner fails with ErrLastOwner.
+func (r *WorkspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID int) error {
+	return r.changeMember(ctx, workspaceID, userID, func(tx pgx.Tx) (int64, error) {
+		tag, err := tx.Exec(ctx, "DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2",
+			workspaceID, userID)
+		return tag.RowsAffected(), err
+	})
+}
+
+// changeMember runs fn while the members of the workspace are locked and checks that the
+// workspace still has an owner afterwards.
+func (r *WorkspaceRepository) changeMember(ctx context.Context, workspaceID, userID int, fn func(tx pgx.Tx) (int64, error)) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		logger.L.Errorw("failed to start transaction", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	defer tx.Rollback(context.Background())
+
+	// concurrent changes could otherwise each remove a different owner
+	if _, err = tx.Exec(ctx, "SELECT 1 FROM workspace_members WHERE workspace_id = $1 FOR UPDATE", workspaceID); err != nil {
+		logger.L.Errorw("failed to lock workspace members", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	affected, err := fn(tx)
+	if err != nil {
+		logger.L.Errorw("failed to update workspace member", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	if affected == 0 {
+		return domain.ErrDataNotFound
+	}
+
+	var owners int
+	err = tx.QueryRow(ctx, "SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1 AND role = $2",
+		workspaceID, domain.RoleOwner).Scan(&owners)
+	if err != nil {
+		logger.L.Errorw("failed to count workspace owners", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	if owners == 0 {
+		return domain.ErrLastOwner
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.L.Errorw("failed to commit transaction", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (r *WorkspaceRepository) SaveInvitation(ctx context.Context, invitation *domain.Invitation, tokenHash string) (*domain.Invitation, error) {
+	query := r.db.QueryBuilder.Insert("workspace_invitations").
+		Columns("workspace_id", "email", "role", "token_hash", "invited_by", "expires_at").
+		Values(invitation.WorkspaceID, invitation.Email, invitation.Role, tokenHash, nullableID(invitation.InvitedBy), invitation.ExpiresAt).
+		Suffix("RETURNING id, created_at")
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	if err = r.db.Pool.QueryRow(ctx, sql, args...).Scan(&invitation.ID, &invitation.CreatedAt); err != nil {
+		logger.L.Errorw("failed to insert invitation", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return invitation, nil
+}
+
+func (r *WorkspaceRepository) ListInvitations(ctx context.Context, workspaceID int) ([]*domain.Invitation, error) {
+	return r.listInvitations(ctx, r.invitationQuery().Where(squirrel.Eq{"workspace_id": workspaceID}).OrderBy("id"))
+}
+
+func (r *WorkspaceRepository) RevokeInvitation(ctx context.Context, workspaceID, id int) error {
+	query := r.db.QueryBuilder.Update("workspace_invitations").
+		Set("revoked_at", time.Now()).
+		Where(squirrel.Eq{"id": id, "workspace_id": workspaceID, "accepted_at": nil, "revoked_at": nil})
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	tag, err := r.db.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrDataNotFound
+	}
+
+	return nil
+}
+
+func (r *WorkspaceRepository) GetInvitationByHash(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+	invitations, err := r.listInvitations(ctx, r.invitationQuery().Where(squirrel.Eq{"token_hash": tokenHash}).Limit(1))
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return invitations[0], nil
+}
+
+// AcceptInvitation marks the invitation as used and adds the user to the workspace. Users who are
+// already a member keep their current role.
+func (r *WorkspaceRepository) AcceptInvitation(ctx context.Context, id, userID int) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		logger.L.Errorw("failed to start transaction", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+	defer tx.Rollback(context.Background())
+
+	var workspaceID int
+	var role domain.Role
+	err = tx.QueryRow(ctx,
+		`UPDATE workspace_invitations SET accepted_at = $1
+		WHERE id = $2 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > $1
+		RETURNING workspace_id, role`,
+		time.Now(), id,
+	).Scan(&workspaceID, &role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrInvitationExpired
+		}
+		logger.L.Errorw("failed to accept invitation", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (workspace_id, user_id) DO NOTHING`,
+		workspaceID, userID, role,
+	)
+	if err != nil {
+		logger.L.Errorw("failed to insert workspace member", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.L.Errorw("failed to commit transaction", "error", err.Error())
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (r *WorkspaceRepository) invitationQuery() squirrel.SelectBuilder {
+	return r.db.QueryBuilder.Select("id", "workspace_id", "email", "role", "COALESCE(invited_by, 0)", "created_at",
+		"expires_at", "accepted_at", "revoked_at").
+		From("workspace_invitations")
+}
+
+func (r *WorkspaceRepository) listInvitations(ctx context.Context, query squirrel.SelectBuilder) ([]*domain.Invitation, error) {
+	sql, args, err := query.ToSql()
+	if err != nil {
+		logger.L.Errorw("failed to build query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	rows, err := r.db.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		logger.L.Errorw("failed to execute query", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	invitations, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Invitation, error) {
+		var invitation domain.Invitation
+		err := row.Scan(&invitation.ID, &invitation.WorkspaceID, &invitation.Email, &invitation.Role, &invitation.InvitedBy,
+			&invitation.CreatedAt, &invitation.ExpiresAt, &invitation.AcceptedAt, &invitation.RevokedAt)
+		return &invitation, err
+	})
+	if err != nil {
+		logger.L.Errorw("failed to scan row", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return invitations, nil
+}
@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultTimeout is how long a receiver may take to respond when webhooks.timeout is not set.
+var DefaultTimeout = 10 * time.Second
+
+var errPrivateTarget = errors.New("webhook target resolves to a private address")
+
+// HTTPSender posts deliveries as JSON. Every request carries the event, the delivery id and a
+// signature header of the form "t=<unix time>,v1=<hex hmac>", see pkg.SignWebhook.
+type HTTPSender struct {
+	client *http.Client
+	agent  string
+}
+
+// NewHTTPSender builds the sender from the webhooks config. Unless webhooks.allow_private_targets
+// is set, receivers on loopback, private and link local addresses are refused, so webhooks can't
+// be used to reach services inside the network. The address is checked when connecting, after
+// the name was resolved, and redirects are not followed.
+func NewHTTPSender(cfg *viper.Viper) ports.WebhookSender {
+	timeout := cfg.GetDuration("webhooks.timeout")
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if !cfg.GetBool("webhooks.allow_private_targets") {
+		dialer.Control = func(network, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivate(ip) {
+				return errPrivateTarget
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &HTTPSender{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		agent: cfg.GetString("app.name") + "-Webhook/1.0",
+	}
+}
+
+// Send posts the delivery, any response other than 2xx is an error.
+func (s *HTTPSender) Send(ctx context.Context, delivery *domain.WebhookDelivery) (int, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", s.agent)
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", delivery.DeliveryID)
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(delivery.Attempts))
+	req.Header.Set("X-Webhook-Signature", fmt.Sprintf("t=%d,v1=%s", timestamp, pkg.SignWebhook(delivery.Secret, timestamp, delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// the body is not used, reading a bit of it lets the connection be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func isPrivate(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
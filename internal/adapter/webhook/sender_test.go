@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/pkg"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func newTestSender(allowPrivate bool, timeout time.Duration) *HTTPSender {
+	cfg := viper.New()
+	cfg.Set("app.name", "URLRotator")
+	cfg.Set("webhooks.allow_private_targets", allowPrivate)
+	cfg.Set("webhooks.timeout", timeout)
+
+	return NewHTTPSender(cfg).(*HTTPSender)
+}
+
+func newTestDelivery(url string) *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		DeliveryID: "d1",
+		Event:      domain.WebhookLinkCreated,
+		Payload:    []byte(`{"event":"link.created"}`),
+		Attempts:   2,
+		URL:        url,
+		Secret:     "secret",
+	}
+}
+
+func TestSendSignsDelivery(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	status, err := newTestSender(true, 0).Send(context.Background(), newTestDelivery(server.URL))
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("Send() = %d, %v, want 204", status, err)
+	}
+
+	if received.Method != http.MethodPost || string(body) != `{"event":"link.created"}` {
+		t.Errorf("received %s %s, want the payload posted", received.Method, body)
+	}
+	for header, want := range map[string]string{
+		"Content-Type":       "application/json",
+		"User-Agent":         "URLRotator-Webhook/1.0",
+		"X-Webhook-Event":    domain.WebhookLinkCreated,
+		"X-Webhook-Delivery": "d1",
+		"X-Webhook-Attempt":  "2",
+	} {
+		if got := received.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	// receivers recompute the signature from the timestamp and the body with the shared secret
+	var timestamp int64
+	var signature string
+	if _, err := fmt.Sscanf(strings.Replace(received.Header.Get("X-Webhook-Signature"), ",v1=", " ", 1), "t=%d %s", &timestamp, &signature); err != nil {
+		t.Fatalf("X-Webhook-Signature = %q, want t=<unix time>,v1=<hmac>", received.Header.Get("X-Webhook-Signature"))
+	}
+	if signature != pkg.SignWebhook("secret", timestamp, body) {
+		t.Errorf("signature %q does not match the body", signature)
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age < 0 || age > time.Minute {
+		t.Errorf("signed at %d, want the time of sending", timestamp)
+	}
+}
+
+func TestSendFailsOnErrorStatus(t *testing.T) {
+	tests := map[string]int{
+		"server error": http.StatusInternalServerError,
+		"gone":         http.StatusGone,
+		"redirect":     http.StatusFound,
+	}
+	for name, code := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// redirects are not followed, the location would be another target
+				w.Header().Set("Location", "http://169.254.169.254/")
+				w.WriteHeader(code)
+			}))
+			defer server.Close()
+
+			status, err := newTestSender(true, 0).Send(context.Background(), newTestDelivery(server.URL))
+			if err == nil || status != code || !strings.Contains(err.Error(), strconv.Itoa(code)) {
+				t.Errorf("Send() = %d, %v, want %d with an error", status, err, code)
+			}
+		})
+	}
+}
+
+func TestSendRefusesPrivateTargets(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	status, err := newTestSender(false, 0).Send(context.Background(), newTestDelivery(server.URL))
+	if !errors.Is(err, errPrivateTarget) || status != 0 || called {
+		t.Errorf("Send() to %s = %d, %v, want it refused", server.URL, status, err)
+	}
+}
+
+func TestSendTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	if _, err := newTestSender(true, 50*time.Millisecond).Send(context.Background(), newTestDelivery(server.URL)); err == nil {
+		t.Errorf("Send() to a stalled receiver succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send() took %v, want it to give up after the timeout", elapsed)
+	}
+}
+
+func TestIsPrivate(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":       true,
+		"10.1.2.3":        true,
+		"192.168.0.1":     true,
+		"169.254.169.254": true,
+		"0.0.0.0":         true,
+		"::1":             true,
+		"fd00::1":         true,
+		"224.0.0.1":       true,
+		"203.0.113.1":     false,
+		"2001:db8::1":     false,
+	}
+	for address, want := range tests {
+		if got := isPrivate(net.ParseIP(address)); got != want {
+			t.Errorf("isPrivate(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package domain
+
+import "context"
+
+const AnonymousActor = "anonymous"
+
+type actorKey struct{}
+
+type requestKey struct{}
+
+// RequestInfo identifies the HTTP request an action was performed in.
+type RequestInfo struct {
+	IP        string
+	RequestID string
+}
+
+// WithActor returns a copy of ctx that carries the identity of whoever is performing the request.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	return AnonymousActor
+}
+
+// WithRequestInfo returns a copy of ctx that carries the request the action is performed in.
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestKey{}, info)
+}
+
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestKey{}).(RequestInfo)
+	return info
+}
@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+type AnalyticsInterval string
+
+const (
+	IntervalHour AnalyticsInterval = "hour"
+	IntervalDay  AnalyticsInterval = "day"
+)
+
+// Dimensions the clicks are counted by besides the destination.
+const (
+	DimensionReferrer = "referrer"
+	DimensionCountry  = "country"
+	DimensionDevice   = "device"
+	DimensionTraffic  = "traffic"
+)
+
+// AnalyticsQuery selects the clicks of a shortcode in [From, To), bucketed by Interval in Location.
+type AnalyticsQuery struct {
+	Code     string
+	From     time.Time
+	To       time.Time
+	Interval AnalyticsInterval
+	Location *time.Location
+}
+
+// ClickCount is the number of clicks of a destination in the bucket starting at Bucket.
+type ClickCount struct {
+	Bucket time.Time
+	URLID  int
+	Clicks int64
+}
+
+// DimensionCount is the number of clicks with a referrer host, country or device.
+type DimensionCount struct {
+	Value  string
+	Clicks int64
+}
+
+type AnalyticsPoint struct {
+	Time   time.Time
+	Clicks int64
+}
+
+// DestinationSeries are the clicks of a destination per bucket, buckets without clicks included.
+type DestinationSeries struct {
+	URLID    int
+	URL      string
+	Total    int64
+	Visitors int64
+	Points   []AnalyticsPoint
+}
+
+type Analytics struct {
+	Query     AnalyticsQuery
+	Total     int64
+	Visitors  int64
+	Series    []*DestinationSeries
+	Referrers []DimensionCount
+	Countries []DimensionCount
+	Devices   []DimensionCount
+	Traffic   []DimensionCount
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+type Scope string
+
+const (
+	ScopeCreate    Scope = "create"
+	ScopeReadStats Scope = "read-stats"
+	ScopeManage    Scope = "manage"
+	ScopeAdmin     Scope = "admin"
+)
+
+func ParseScope(value string) (Scope, bool) {
+	switch scope := Scope(value); scope {
+	case ScopeCreate, ScopeReadStats, ScopeManage, ScopeAdmin:
+		return scope, true
+	default:
+		return "", false
+	}
+}
+
+// APIKey is an issued key, the key itself is never stored, only its hash and a short prefix to
+// recognize it by.
+type APIKey struct {
+	ID         int
+	Name       string
+	Prefix     string
+	Scopes     []Scope
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
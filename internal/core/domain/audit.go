@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditLinkCreate       = "link.create"
+	AuditKeyIssue         = "api_key.issue"
+	AuditKeyRevoke        = "api_key.revoke"
+	AuditUserSignup       = "user.signup"
+	AuditUserLogin        = "user.login"
+	AuditUserLoginFailed  = "user.login_failed"
+	AuditWorkspaceCreate  = "workspace.create"
+	AuditMemberRole       = "member.role"
+	AuditMemberRemove     = "member.remove"
+	AuditInvitationCreate = "invitation.create"
+	AuditInvitationRevoke = "invitation.revoke"
+	AuditInvitationAccept = "invitation.accept"
+	AuditDomainCreate     = "domain.create"
+	AuditDomainUpdate     = "domain.update"
+	AuditDomainDelete     = "domain.delete"
+	AuditWebhookCreate    = "webhook.create"
+	AuditWebhookUpdate    = "webhook.update"
+	AuditWebhookDelete    = "webhook.delete"
+	AuditWebhookRedeliver = "webhook.redeliver"
+)
+
+const (
+	AuditTargetLink       = "link"
+	AuditTargetAPIKey     = "api_key"
+	AuditTargetUser       = "user"
+	AuditTargetWorkspace  = "workspace"
+	AuditTargetMember     = "member"
+	AuditTargetInvitation = "invitation"
+	AuditTargetDomain     = "domain"
+	AuditTargetWebhook    = "webhook"
+)
+
+// AuditEntry is an append-only record of a management action. Actor, IP and RequestID are taken
+// from the context of the request that performed the action when the entry is stored.
+type AuditEntry struct {
+	ID          int
+	Action      string
+	Actor       string
+	IP          string
+	RequestID   string
+	TargetType  string
+	TargetID    string
+	WorkspaceID int
+	// Before and After are JSON documents of the target's state, either may be empty.
+	Before    json.RawMessage
+	After     json.RawMessage
+	CreatedAt time.Time
+}
+
+// NewAuditEntry builds an entry, before and after are stored as JSON and may be nil.
+func NewAuditEntry(action, targetType, targetID string, workspaceID int, before, after any) *AuditEntry {
+	return &AuditEntry{
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		WorkspaceID: workspaceID,
+		Before:      auditJSON(before),
+		After:       auditJSON(after),
+	}
+}
+
+func auditJSON(value any) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// AuditLink is the state of a shortcode as recorded in the audit log, the password hash is left out.
+type AuditLink struct {
+	Strategy     Strategy              `json:"strategy"`
+	Protected    bool                  `json:"protected"`
+	Social       SocialMeta            `json:"social"`
+	Redirect     Redirect              `json:"redirect"`
+	Destinations []SnapshotDestination `json:"destinations"`
+	Tags         []string              `json:"tags,omitempty"`
+}
+
+func NewAuditLink(snapshot *LinkSnapshot) *AuditLink {
+	return &AuditLink{
+		Strategy:     snapshot.Strategy,
+		Protected:    snapshot.PasswordHash != "",
+		Social:       snapshot.Social,
+		Redirect:     snapshot.Redirect,
+		Destinations: snapshot.Destinations,
+		Tags:         snapshot.Tags,
+	}
+}
+
+// AuditFilter selects audit entries, zero values match everything. Entries are returned newest
+// first, Before continues a listing below the given entry id.
+type AuditFilter struct {
+	Action        string
+	Actor         string
+	TargetType    string
+	TargetID      string
+	WorkspaceID   int
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Before        int
+	Limit         int
+}
+
+type AuditPage struct {
+	Items      []*AuditEntry
+	NextCursor string
+}
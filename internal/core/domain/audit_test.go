@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNewAuditEntry(t *testing.T) {
+	entry := NewAuditEntry(AuditLinkCreate, AuditTargetLink, "spring", 3, nil, NewAuditLink(&LinkSnapshot{
+		Strategy:     RoundRobin,
+		PasswordHash: "$2a$10$secret",
+		Destinations: []SnapshotDestination{{ID: 1, URL: "https://a.example", Enabled: true}},
+	}))
+
+	if entry.Before != nil {
+		t.Errorf("Before = %s, want nothing for a create", entry.Before)
+	}
+	after := string(entry.After)
+	if !strings.Contains(after, `"protected":true`) || !strings.Contains(after, `"https://a.example"`) {
+		t.Errorf("After = %s, want the protected link with its destination", after)
+	}
+	// the audit log must never contain the password hash
+	if strings.Contains(after, "secret") {
+		t.Errorf("After = %s, want no password hash", after)
+	}
+}
+
+func TestActorFromContext(t *testing.T) {
+	if actor := ActorFromContext(context.Background()); actor != AnonymousActor {
+		t.Errorf("ActorFromContext() = %q, want %q", actor, AnonymousActor)
+	}
+	if actor := ActorFromContext(WithActor(context.Background(), "key:1")); actor != "key:1" {
+		t.Errorf("ActorFromContext() = %q, want key:1", actor)
+	}
+}
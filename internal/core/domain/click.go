@@ -0,0 +1,66 @@
+package domain
+
+import "time"
+
+type Traffic string
+
+const (
+	TrafficHuman Traffic = "human"
+	// TrafficBot are bots that identify themselves, e.g. crawlers and link scanners
+	TrafficBot Traffic = "bot"
+	// TrafficSuspectedBot are headless browsers, datacenter addresses and requests without the
+	// headers every browser sends
+	TrafficSuspectedBot Traffic = "suspected_bot"
+	// TrafficPrefetch are links the browser loaded ahead of a click that may never happen
+	TrafficPrefetch Traffic = "prefetch"
+)
+
+// Visit describes the visitor of a redirect, the ip address is only ever kept hashed.
+type Visit struct {
+	IPHash    string
+	UserAgent string
+	Device    string
+	Referrer  string
+	Country   string
+	RequestID string
+	Traffic   Traffic
+}
+
+// IsHuman reports whether the visit counts as a click, visits without a classification do.
+func (v Visit) IsHuman() bool {
+	return v.Traffic == "" || v.Traffic == TrafficHuman
+}
+
+// VisitorID identifies the visitor for unique counting, visitors behind the same address with
+// different browsers are told apart.
+func (v Visit) VisitorID() string {
+	return v.IPHash + "|" + v.UserAgent
+}
+
+// ClickEvent is a single redirect of a visitor to one of the destinations of a shortcode, the
+// time is in UTC.
+type ClickEvent struct {
+	ClickedAt time.Time
+	ShortCode string
+	URLID     int
+	Visit
+}
+
+func NewClickEvent(code string, urlID int, visit Visit) *ClickEvent {
+	return &ClickEvent{
+		ClickedAt: time.Now().UTC(),
+		ShortCode: code,
+		URLID:     urlID,
+		Visit:     visit,
+	}
+}
+
+// VisitorSketch is the HyperLogLog of the visitors of a shortcode on a day of the app timezone,
+// URLID is 0 for the visitors of all destinations together.
+type VisitorSketch struct {
+	Day       time.Time
+	ShortCode string
+	URLID     int
+	Sketch    []byte
+	Visitors  int64
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "testing"
+
+func TestVisitorID(t *testing.T) {
+	firefox := Visit{IPHash: "h1", UserAgent: "Firefox"}
+
+	if firefox.VisitorID() != (Visit{IPHash: "h1", UserAgent: "Firefox", Referrer: "https://a.example"}).VisitorID() {
+		t.Errorf("VisitorID() differs between visits of the same browser")
+	}
+	// visitors behind the same address are told apart by their browser
+	if firefox.VisitorID() == (Visit{IPHash: "h1", UserAgent: "Chrome"}).VisitorID() {
+		t.Errorf("VisitorID() is the same for two browsers behind one address")
+	}
+}
+
+func TestIsHuman(t *testing.T) {
+	for traffic, want := range map[Traffic]bool{
+		"":                  true,
+		TrafficHuman:        true,
+		TrafficBot:          false,
+		TrafficSuspectedBot: false,
+		TrafficPrefetch:     false,
+	} {
+		if got := (Visit{Traffic: traffic}).IsHuman(); got != want {
+			t.Errorf("IsHuman() of %q = %v, want %v", traffic, got, want)
+		}
+	}
+}
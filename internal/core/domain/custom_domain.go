@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var hostPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// Domain is a custom domain that serves the shortcodes of a workspace next to the default domain.
+type Domain struct {
+	ID          int
+	Host        string
+	WorkspaceID int
+	// RootRedirect is where visitors of the bare domain are sent, empty shows the index page.
+	RootRedirect string
+	// NotFoundURL is where visitors of unknown shortcodes are sent, empty shows the default 404.
+	NotFoundURL string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type DomainUpdate struct {
+	RootRedirect *string
+	NotFoundURL  *string
+}
+
+// NormalizeHost lowercases the host and strips the port, it returns false if the result is not a
+// valid domain name.
+func NormalizeHost(host string) (string, bool) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if i := strings.LastIndex(host, ":"); i != -1 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	return host, len(host) <= 253 && hostPattern.MatchString(host)
+}
+
+// LinkKey identifies a shortcode across domains. Shortcodes of the default domain are stored under
+// their code, shortcodes of a custom domain under "host/code". Destinations, revisions and cache
+// entries all use the key, so the same code can exist once per domain.
+func LinkKey(host, code string) string {
+	if host == "" {
+		return code
+	}
+
+	return host + "/" + code
+}
+
+// SplitLinkKey returns the host and the code of a key built by LinkKey, the host is empty for the
+// default domain.
+func SplitLinkKey(key string) (string, string) {
+	host, code, found := strings.Cut(key, "/")
+	if !found {
+		return "", key
+	}
+
+	return host, code
+}
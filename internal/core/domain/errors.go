@@ -5,4 +5,26 @@ import "errors"
 var (
 	ErrInternalServerError error = errors.New("Internal Server Error")
 	ErrDataNotFound              = errors.New("Data Not Found")
+	ErrInvalidPassword           = errors.New("Invalid Password")
+	ErrPasswordTooLong           = errors.New("Password Must Not Be Longer Than 72 Bytes")
+	ErrTooManyAttempts           = errors.New("Too Many Attempts")
+	ErrInvalidAlias              = errors.New("Invalid Alias")
+	ErrCodeAlreadyExists         = errors.New("Short Code Already Exists")
+	ErrInvalidCursor             = errors.New("Invalid Cursor")
+	ErrInvalidTag                = errors.New("Invalid Tag")
+	ErrInvalidStrategy           = errors.New("Invalid Strategy")
+	ErrInvalidRedirect           = errors.New("Invalid Redirect Type")
+	ErrInvalidScope              = errors.New("Invalid Scope")
+	ErrUnauthorized              = errors.New("Unauthorized")
+	ErrForbidden                 = errors.New("Forbidden")
+	ErrEmailAlreadyExists        = errors.New("Email Already Registered")
+	ErrInvalidCredentials        = errors.New("Invalid Email Or Password")
+	ErrInvalidRole               = errors.New("Invalid Role")
+	ErrLastOwner                 = errors.New("Workspace Must Keep At Least One Owner")
+	ErrInvitationExpired         = errors.New("Invitation Expired Or Already Used")
+	ErrInvalidDomain             = errors.New("Invalid Domain")
+	ErrDomainAlreadyExists       = errors.New("Domain Already Registered")
+	ErrDomainInUse               = errors.New("Domain Still Has Short Codes")
+	ErrInvalidRange              = errors.New("Invalid Time Range")
+	ErrInvalidWebhook            = errors.New("Invalid Webhook")
 )
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+type ExportFilter struct {
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Strategy      Strategy
+	WorkspaceID   int
+}
+
+// ShortCodeExport is a shortcode together with all of its destinations.
+type ShortCodeExport struct {
+	ShortCode
+	URLs []*URL
+}
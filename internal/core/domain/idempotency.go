@@ -0,0 +1,11 @@
+package domain
+
+// IdempotentResponse is the response stored for an Idempotency-Key. Until the first request
+// finishes the record only holds the fingerprint and Completed is false.
+type IdempotentResponse struct {
+	Fingerprint string
+	Completed   bool
+	Status      int
+	ContentType string
+	Body        []byte
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+type LinkSort string
+
+const (
+	SortByCreatedAt LinkSort = "created_at"
+	SortByHits      LinkSort = "hits"
+)
+
+// LinkQuery describes a page of shortcodes to list.
+type LinkQuery struct {
+	Search string
+	Tag    string
+	Sort   LinkSort
+	Asc    bool
+	Limit  int
+	After  *LinkCursor
+	// WorkspaceID lists the links of a workspace instead of the personal links.
+	WorkspaceID int
+}
+
+// LinkCursor is the position of the last shortcode of the previous page.
+type LinkCursor struct {
+	CreatedAt time.Time
+	TotalHit  int
+	ID        int
+}
+
+type LinkPage struct {
+	Items      []*ShortCode
+	NextCursor string
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "context"
+
+// Principal is whoever is authenticated for a request. Subject identifies it, e.g. "key:12", and
+// is empty for anonymous requests. UserID is set when the principal acts on behalf of a user.
+type Principal struct {
+	Subject string
+	Name    string
+	UserID  int
+	Scopes  []Scope
+}
+
+// IsAuthenticated reports whether the request carried valid credentials.
+func (p *Principal) IsAuthenticated() bool {
+	return p.Subject != ""
+}
+
+// HasScope reports whether the principal may perform actions of the given scope, admin may do everything.
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx that carries the authenticated principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal of the request, an anonymous one without scopes if none was set.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	if principal, ok := ctx.Value(principalKey{}).(*Principal); ok && principal != nil {
+		return principal
+	}
+
+	return &Principal{}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseScope(t *testing.T) {
+	for _, value := range []string{"create", "read-stats", "manage", "admin"} {
+		if scope, ok := ParseScope(value); !ok || string(scope) != value {
+			t.Errorf("ParseScope(%q) = %q, %v", value, scope, ok)
+		}
+	}
+	for _, value := range []string{"", "Admin", "read_stats", "delete"} {
+		if _, ok := ParseScope(value); ok {
+			t.Errorf("ParseScope(%q) accepted an unknown scope", value)
+		}
+	}
+}
+
+func TestPrincipalHasScope(t *testing.T) {
+	principal := &Principal{Subject: "key:1", Scopes: []Scope{ScopeCreate, ScopeReadStats}}
+	for scope, want := range map[Scope]bool{ScopeCreate: true, ScopeReadStats: true, ScopeManage: false, ScopeAdmin: false} {
+		if got := principal.HasScope(scope); got != want {
+			t.Errorf("HasScope(%s) = %v, want %v", scope, got, want)
+		}
+	}
+
+	admin := &Principal{Subject: "key:2", Scopes: []Scope{ScopeAdmin}}
+	for _, scope := range []Scope{ScopeCreate, ScopeReadStats, ScopeManage, ScopeAdmin} {
+		if !admin.HasScope(scope) {
+			t.Errorf("admin HasScope(%s) = false, admin may do everything", scope)
+		}
+	}
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	anonymous := PrincipalFromContext(context.Background())
+	if anonymous.IsAuthenticated() || anonymous.HasScope(ScopeCreate) {
+		t.Errorf("PrincipalFromContext() without a principal = %+v, want an anonymous one without scopes", anonymous)
+	}
+
+	principal := &Principal{Subject: "key:1"}
+	if got := PrincipalFromContext(WithPrincipal(context.Background(), principal)); got != principal || !got.IsAuthenticated() {
+		t.Errorf("PrincipalFromContext() = %+v, want %+v", got, principal)
+	}
+}
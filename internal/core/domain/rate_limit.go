@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// RateLimit allows Limit requests per Window, a limit of 0 disables it.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitResult is the state of a rate limit after a request was counted against it.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long a rejected client has to wait until the next request is allowed.
+	RetryAfter time.Duration
+	// ResetAfter is how long it takes until the full limit is available again.
+	ResetAfter time.Duration
+}
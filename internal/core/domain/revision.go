@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionTags     = "tags"
+	RevisionEnable   = "enable"
+	RevisionDisable  = "disable"
+	RevisionRollback = "rollback"
+)
+
+// Revision is an immutable record of a shortcode's state right after a change.
+type Revision struct {
+	ID        int
+	ShortCode string
+	Revision  int
+	Action    string
+	Actor     string
+	Snapshot  LinkSnapshot
+	Diff      []RevisionChange
+	CreatedAt time.Time
+}
+
+// LinkSnapshot is everything a rollback restores: destinations, strategy and settings.
+type LinkSnapshot struct {
+	Strategy     Strategy              `json:"strategy"`
+	PasswordHash string                `json:"password_hash"`
+	Social       SocialMeta            `json:"social"`
+	Redirect     Redirect              `json:"redirect"`
+	Destinations []SnapshotDestination `json:"destinations"`
+	Tags         []string              `json:"tags"`
+}
+
+type SnapshotDestination struct {
+	ID      int    `json:"id"`
+	URL     string `json:"url"`
+	Enabled bool   `json:"enabled"`
+}
+
+// RevisionChange describes a single changed field. Target names the destination or tag the
+// change applies to, an empty From means it was added and an empty To means it was removed.
+type RevisionChange struct {
+	Field  string `json:"field"`
+	Target string `json:"target,omitempty"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// UpdateLinkRequest is a change requested by a user, nil fields are left untouched and an empty
+// password removes the protection.
+type UpdateLinkRequest struct {
+	Strategy *string
+	URLs     []string
+	Password *string
+	Social   *SocialMeta
+	Redirect *Redirect
+}
+
+// LinkUpdate holds the fields to change on a shortcode, nil fields are left untouched.
+type LinkUpdate struct {
+	Strategy     *Strategy
+	URLs         []string
+	PasswordHash *string
+	Social       *SocialMeta
+	Redirect     *Redirect
+}
+
+// DiffSnapshots lists what changed between two snapshots. Password hashes are never included.
+func DiffSnapshots(prev, next *LinkSnapshot) []RevisionChange {
+	var changes []RevisionChange
+
+	if prev.Strategy != next.Strategy {
+		changes = append(changes, RevisionChange{Field: "strategy", From: string(prev.Strategy), To: string(next.Strategy)})
+	}
+	if prev.PasswordHash != next.PasswordHash {
+		changes = append(changes, RevisionChange{
+			Field: "password",
+			From:  strconv.FormatBool(prev.PasswordHash != ""),
+			To:    strconv.FormatBool(next.PasswordHash != ""),
+		})
+	}
+
+	for _, field := range []struct{ name, from, to string }{
+		{"social.title", prev.Social.Title, next.Social.Title},
+		{"social.description", prev.Social.Description, next.Social.Description},
+		{"social.image", prev.Social.Image, next.Social.Image},
+		{"redirect.type", string(prev.Redirect.Type), string(next.Redirect.Type)},
+		{"redirect.delay", strconv.Itoa(prev.Redirect.Delay), strconv.Itoa(next.Redirect.Delay)},
+	} {
+		if field.from != field.to {
+			changes = append(changes, RevisionChange{Field: field.name, From: field.from, To: field.to})
+		}
+	}
+
+	prevDestinations := make(map[string]SnapshotDestination, len(prev.Destinations))
+	for _, destination := range prev.Destinations {
+		prevDestinations[destination.URL] = destination
+	}
+	nextDestinations := make(map[string]SnapshotDestination, len(next.Destinations))
+	for _, destination := range next.Destinations {
+		nextDestinations[destination.URL] = destination
+
+		old, ok := prevDestinations[destination.URL]
+		switch {
+		case !ok:
+			changes = append(changes, RevisionChange{Field: "destination", Target: destination.URL, To: destination.URL})
+		case old.Enabled != destination.Enabled:
+			changes = append(changes, RevisionChange{
+				Field:  "destination.enabled",
+				Target: destination.URL,
+				From:   strconv.FormatBool(old.Enabled),
+				To:     strconv.FormatBool(destination.Enabled),
+			})
+		}
+	}
+	for _, destination := range prev.Destinations {
+		if _, ok := nextDestinations[destination.URL]; !ok {
+			changes = append(changes, RevisionChange{Field: "destination", Target: destination.URL, From: destination.URL})
+		}
+	}
+
+	prevTags := make(map[string]bool, len(prev.Tags))
+	for _, tag := range prev.Tags {
+		prevTags[tag] = true
+	}
+	nextTags := make(map[string]bool, len(next.Tags))
+	for _, tag := range next.Tags {
+		nextTags[tag] = true
+		if !prevTags[tag] {
+			changes = append(changes, RevisionChange{Field: "tag", Target: tag, To: tag})
+		}
+	}
+	for _, tag := range prev.Tags {
+		if !nextTags[tag] {
+			changes = append(changes, RevisionChange{Field: "tag", Target: tag, From: tag})
+		}
+	}
+
+	return changes
+}
+
+// Fingerprint returns the fingerprint of the snapshot's destinations, or an empty string when the
+// link is password protected or has its own social metadata and must never be handed out as a duplicate.
+func (s *LinkSnapshot) Fingerprint() string {
+	if s.PasswordHash != "" || s.Social.IsSet() {
+		return ""
+	}
+
+	urls := make([]string, 0, len(s.Destinations))
+	for _, destination := range s.Destinations {
+		urls = append(urls, destination.URL)
+	}
+
+	return Fingerprint(s.Strategy, s.Redirect, urls)
+}
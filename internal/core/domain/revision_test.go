@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	prev := &LinkSnapshot{
+		Strategy: RoundRobin,
+		Destinations: []SnapshotDestination{
+			{ID: 1, URL: "https://a.example", Enabled: true},
+			{ID: 2, URL: "https://b.example", Enabled: true},
+		},
+		Tags: []string{"sale"},
+	}
+	next := &LinkSnapshot{
+		Strategy:     Random,
+		PasswordHash: "hash",
+		Social:       SocialMeta{Title: "Spring"},
+		Destinations: []SnapshotDestination{
+			{ID: 1, URL: "https://a.example", Enabled: false},
+			{ID: 3, URL: "https://c.example", Enabled: true},
+		},
+		Tags: []string{"spring"},
+	}
+
+	want := []RevisionChange{
+		{Field: "strategy", From: string(RoundRobin), To: string(Random)},
+		{Field: "password", From: "false", To: "true"},
+		{Field: "social.title", From: "", To: "Spring"},
+		{Field: "destination.enabled", Target: "https://a.example", From: "true", To: "false"},
+		{Field: "destination", Target: "https://c.example", To: "https://c.example"},
+		{Field: "destination", Target: "https://b.example", From: "https://b.example"},
+		{Field: "tag", Target: "spring", To: "spring"},
+		{Field: "tag", Target: "sale", From: "sale"},
+	}
+	if diff := DiffSnapshots(prev, next); !reflect.DeepEqual(diff, want) {
+		t.Errorf("DiffSnapshots() = %+v, want %+v", diff, want)
+	}
+}
+
+func TestDiffSnapshotsNeverContainsPasswordHashes(t *testing.T) {
+	diff := DiffSnapshots(&LinkSnapshot{PasswordHash: "old"}, &LinkSnapshot{PasswordHash: "new"})
+
+	want := []RevisionChange{{Field: "password", From: "true", To: "true"}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("DiffSnapshots() = %+v, want %+v", diff, want)
+	}
+}
+
+func TestDiffSnapshotsBaseline(t *testing.T) {
+	snapshot := &LinkSnapshot{Destinations: []SnapshotDestination{{ID: 1, URL: "https://a.example", Enabled: true}}}
+
+	diff := DiffSnapshots(&LinkSnapshot{}, snapshot)
+	want := []RevisionChange{{Field: "destination", Target: "https://a.example", To: "https://a.example"}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("DiffSnapshots() of the baseline = %+v, want %+v", diff, want)
+	}
+	if diff = DiffSnapshots(snapshot, snapshot); len(diff) != 0 {
+		t.Errorf("DiffSnapshots() of an unchanged snapshot = %+v, want none", diff)
+	}
+}
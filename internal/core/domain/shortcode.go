@@ -1,6 +1,14 @@
 package domain
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
 
 type Strategy string
 
@@ -9,11 +17,143 @@ const (
 	Random     Strategy = "RNDM"
 )
 
+// ParseStrategy converts user input such as "rr" into a Strategy.
+func ParseStrategy(value string) (Strategy, bool) {
+	switch Strategy(strings.ToUpper(value)) {
+	case Random:
+		return Random, true
+	case RoundRobin:
+		return RoundRobin, true
+	default:
+		return "", false
+	}
+}
+
 type ShortCode struct {
-	ID        string
-	Code      string
-	TotalHit  int
-	Strategy  Strategy
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           string
+	Code         string
+	OwnerID      int
+	WorkspaceID  int
+	TotalHit     int
+	Strategy     Strategy
+	PasswordHash string
+	Fingerprint  string
+	Social       SocialMeta
+	Redirect     Redirect
+	Tags         []string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// IsProtected reports whether visitors must enter a password before being redirected.
+func (s *ShortCode) IsProtected() bool {
+	return s.PasswordHash != ""
+}
+
+type RedirectType string
+
+const (
+	RedirectMovedPermanently RedirectType = "301"
+	RedirectFound            RedirectType = "302"
+	RedirectTemporary        RedirectType = "307"
+	RedirectPermanent        RedirectType = "308"
+	RedirectMetaRefresh      RedirectType = "meta"
+	RedirectJavaScript       RedirectType = "js"
+
+	DefaultRedirectType = RedirectFound
+)
+
+// MaxRedirectDelay is the longest delay in seconds an HTML redirect page may wait.
+const MaxRedirectDelay = 30
+
+// ParseRedirectType converts user input such as "META" into a RedirectType.
+func ParseRedirectType(value string) (RedirectType, bool) {
+	switch redirectType := RedirectType(strings.ToLower(value)); redirectType {
+	case RedirectMovedPermanently, RedirectFound, RedirectTemporary, RedirectPermanent, RedirectMetaRefresh, RedirectJavaScript:
+		return redirectType, true
+	default:
+		return "", false
+	}
+}
+
+// Redirect is how visitors are sent to the destination. Delay is in seconds and only applies to
+// the HTML based redirects.
+type Redirect struct {
+	Type  RedirectType `json:"type"`
+	Delay int          `json:"delay"`
+}
+
+// IsPage reports whether the redirect is done by an HTML page instead of a status code.
+func (r Redirect) IsPage() bool {
+	return r.Type == RedirectMetaRefresh || r.Type == RedirectJavaScript
+}
+
+// SocialMeta is the OpenGraph and Twitter Card metadata shown when a link is unfurled in chat apps
+// and social networks.
+type SocialMeta struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Image       string `json:"image"`
+}
+
+// IsSet reports whether any metadata was configured.
+func (m SocialMeta) IsSet() bool {
+	return m.Title != "" || m.Description != "" || m.Image != ""
+}
+
+// Fingerprint identifies a set of destinations rotated with a strategy, regardless of the order
+// the destinations were given in or how their scheme and host are written.
+func Fingerprint(strategy Strategy, redirect Redirect, urls []string) string {
+	normalized := make([]string, 0, len(urls))
+	seen := make(map[string]bool, len(urls))
+	for _, raw := range urls {
+		value := normalizeURL(raw)
+		if !seen[value] {
+			seen[value] = true
+			normalized = append(normalized, value)
+		}
+	}
+	sort.Strings(normalized)
+
+	header := fmt.Sprintf("%s %s %d", strategy, redirect.Type, redirect.Delay)
+	hash := sha256.Sum256([]byte(header + "\n" + strings.Join(normalized, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+func normalizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	if parsed.Path == "/" {
+		parsed.Path = ""
+	}
+
+	return parsed.String()
+}
+
+// ShortenRequest holds everything needed to create a new shortcode.
+type ShortenRequest struct {
+	URLs     []string
+	Strategy string
+	Password string
+	Alias    string
+	Social   SocialMeta
+	// Redirect is left empty to use the default redirect type.
+	Redirect Redirect
+	// Deduplicate returns an existing shortcode with the same destinations and strategy instead
+	// of creating a new one. It is ignored when an alias, a password or social metadata is given.
+	Deduplicate bool
+	// Domain is the host of a registered custom domain, empty creates the shortcode on the default domain.
+	Domain string
+}
+
+// BulkShortenResult reports the outcome of a single ShortenRequest within a bulk import.
+type BulkShortenResult struct {
+	ShortCode *ShortCode
+	Err       error
 }
@@ -0,0 +1,55 @@
+package domain
+
+import "testing"
+
+func TestFingerprintIgnoresOrderAndSpelling(t *testing.T) {
+	want := Fingerprint(RoundRobin, Redirect{}, []string{"https://a.example/x", "https://b.example"})
+
+	for name, urls := range map[string][]string{
+		"other order":   {"https://b.example", "https://a.example/x"},
+		"upper case":    {"HTTPS://A.EXAMPLE/x", "https://B.example"},
+		"trailing /":    {"https://a.example/x", "https://b.example/"},
+		"whitespace":    {" https://a.example/x ", "https://b.example"},
+		"duplicate url": {"https://a.example/x", "https://b.example", "https://b.example/"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := Fingerprint(RoundRobin, Redirect{}, urls); got != want {
+				t.Errorf("Fingerprint(%v) = %s, want %s", urls, got, want)
+			}
+		})
+	}
+}
+
+func TestFingerprintDistinguishesLinks(t *testing.T) {
+	urls := []string{"https://a.example/x", "https://b.example"}
+	base := Fingerprint(RoundRobin, Redirect{}, urls)
+
+	for name, got := range map[string]string{
+		"strategy":       Fingerprint(Random, Redirect{}, urls),
+		"redirect type":  Fingerprint(RoundRobin, Redirect{Type: RedirectMovedPermanently}, urls),
+		"redirect delay": Fingerprint(RoundRobin, Redirect{Delay: 5}, urls),
+		"path case":      Fingerprint(RoundRobin, Redirect{}, []string{"https://a.example/X", "https://b.example"}),
+		"other url":      Fingerprint(RoundRobin, Redirect{}, []string{"https://a.example/x"}),
+	} {
+		if got == base {
+			t.Errorf("Fingerprint() with a different %s matches the original", name)
+		}
+	}
+}
+
+func TestSnapshotFingerprint(t *testing.T) {
+	snapshot := &LinkSnapshot{Strategy: RoundRobin, Destinations: []SnapshotDestination{{URL: "https://a.example"}}}
+	if got, want := snapshot.Fingerprint(), Fingerprint(RoundRobin, Redirect{}, []string{"https://a.example"}); got != want {
+		t.Errorf("Fingerprint() = %s, want %s", got, want)
+	}
+
+	// protected links and links with their own preview must never be handed out as duplicates
+	for name, snapshot := range map[string]*LinkSnapshot{
+		"password": {PasswordHash: "hash", Destinations: snapshot.Destinations},
+		"social":   {Social: SocialMeta{Title: "Spring"}, Destinations: snapshot.Destinations},
+	} {
+		if got := snapshot.Fingerprint(); got != "" {
+			t.Errorf("Fingerprint() of a link with %s = %s, want none", name, got)
+		}
+	}
+}
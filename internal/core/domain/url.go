@@ -9,6 +9,7 @@ type URL struct {
 	ShortCode string
 	TotalHit  int
 	Original  string
+	Enabled   bool
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
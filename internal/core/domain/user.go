@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+type User struct {
+	ID           int
+	Email        string
+	PasswordHash string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// UserScopes are the scopes of a signed in user, limited to the user's own links.
+var UserScopes = []Scope{ScopeCreate, ScopeReadStats, ScopeManage}
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookLinkCreated = "link.created"
+	WebhookLinkUpdated = "link.updated"
+	WebhookLinkClicked = "link.clicked"
+)
+
+// WebhookEvents are the events a webhook may subscribe to.
+var WebhookEvents = []string{WebhookLinkCreated, WebhookLinkUpdated, WebhookLinkClicked}
+
+func IsWebhookEvent(event string) bool {
+	switch event {
+	case WebhookLinkCreated, WebhookLinkUpdated, WebhookLinkClicked:
+		return true
+	default:
+		return false
+	}
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed deliveries ran out of attempts, they are only sent again when redelivered
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// Webhook receives the events of the links of a workspace or, without a workspace, of the
+// personal links of its owner. Webhooks without both receive the events of every link.
+type Webhook struct {
+	ID          int
+	WorkspaceID int
+	OwnerID     int
+	URL         string
+	Secret      string
+	Events      []string
+	Enabled     bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type WebhookUpdate struct {
+	URL     *string
+	Events  []string
+	Enabled *bool
+}
+
+// WebhookEvent is something that happened to a link, it becomes a delivery for every enabled
+// webhook of the link that subscribed to it.
+type WebhookEvent struct {
+	Event     string
+	ShortCode string
+	Payload   json.RawMessage
+}
+
+// NewWebhookEvent builds the event of the shortcode with the given key, data is sent as the
+// "data" field of the payload.
+func NewWebhookEvent(event, code string, data any) *WebhookEvent {
+	payload, _ := json.Marshal(struct {
+		Event     string    `json:"event"`
+		CreatedAt time.Time `json:"created_at"`
+		Data      any       `json:"data"`
+	}{event, time.Now().UTC(), data})
+
+	return &WebhookEvent{
+		Event:     event,
+		ShortCode: code,
+		Payload:   payload,
+	}
+}
+
+// WebhookLink is the data of the link events, Change is the kind of change of link.updated.
+type WebhookLink struct {
+	Code   string     `json:"code"`
+	Domain string     `json:"domain,omitempty"`
+	Change string     `json:"change,omitempty"`
+	Link   *AuditLink `json:"link"`
+}
+
+func NewWebhookLink(key, change string, link *AuditLink) *WebhookLink {
+	host, code := SplitLinkKey(key)
+	return &WebhookLink{
+		Code:   code,
+		Domain: host,
+		Change: change,
+		Link:   link,
+	}
+}
+
+// WebhookClick is the data of link.clicked, the visitor itself is left out.
+type WebhookClick struct {
+	Code          string    `json:"code"`
+	Domain        string    `json:"domain,omitempty"`
+	DestinationID int       `json:"destination_id"`
+	Country       string    `json:"country,omitempty"`
+	Device        string    `json:"device,omitempty"`
+	Referrer      string    `json:"referrer,omitempty"`
+	Traffic       Traffic   `json:"traffic"`
+	ClickedAt     time.Time `json:"clicked_at"`
+}
+
+func NewWebhookClick(event *ClickEvent) *WebhookClick {
+	host, code := SplitLinkKey(event.ShortCode)
+	traffic := event.Traffic
+	if traffic == "" {
+		traffic = TrafficHuman
+	}
+
+	return &WebhookClick{
+		Code:          code,
+		Domain:        host,
+		DestinationID: event.URLID,
+		Country:       event.Country,
+		Device:        event.Device,
+		Referrer:      event.Referrer,
+		Traffic:       traffic,
+		ClickedAt:     event.ClickedAt,
+	}
+}
+
+// WebhookDelivery is a single event queued for a webhook. Every attempt to send it reuses the
+// DeliveryID, so receivers can drop duplicates. URL and Secret are only loaded for sending.
+type WebhookDelivery struct {
+	ID             int64
+	DeliveryID     string
+	WebhookID      int
+	Event          string
+	Payload        json.RawMessage
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+	URL            string
+	Secret         string
+}
@@ -0,0 +1,79 @@
+package domain
+
+import "time"
+
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+func ParseRole(value string) (Role, bool) {
+	switch role := Role(value); role {
+	case RoleOwner, RoleAdmin, RoleEditor, RoleViewer:
+		return role, true
+	default:
+		return "", false
+	}
+}
+
+// Action is something a member of a workspace may be allowed to do, the policy decides which
+// roles may perform which actions.
+type Action string
+
+const (
+	ActionReadLinks      Action = "links:read"
+	ActionCreateLinks    Action = "links:create"
+	ActionManageLinks    Action = "links:manage"
+	ActionManageMembers  Action = "members:manage"
+	ActionManageDomains  Action = "domains:manage"
+	ActionManageOwners   Action = "owners:manage"
+	ActionReadAudit      Action = "audit:read"
+	ActionManageWebhooks Action = "webhooks:manage"
+)
+
+type Workspace struct {
+	ID   int
+	Name string
+	// Role is the role of the user the workspace was loaded for.
+	Role      Role
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Member struct {
+	UserID    int
+	Email     string
+	Role      Role
+	CreatedAt time.Time
+}
+
+// Invitation lets whoever signs in with Email join the workspace. Only the hash of its token is stored.
+type Invitation struct {
+	ID          int
+	WorkspaceID int
+	Email       string
+	Role        Role
+	InvitedBy   int
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	AcceptedAt  *time.Time
+	RevokedAt   *time.Time
+}
+
+// IsPending reports whether the invitation can still be accepted.
+func (i *Invitation) IsPending() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && time.Now().Before(i.ExpiresAt)
+}
+
+// LinkScope is whom links belong to: a workspace or, without a workspace, a user personally.
+// Queries use it to limit their results, the zero value matches every link and Unowned only the
+// links of neither a user nor a workspace.
+type LinkScope struct {
+	OwnerID     int
+	WorkspaceID int
+	Unowned     bool
+}
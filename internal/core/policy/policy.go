@@ -0,0 +1,126 @@
+package policy
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+)
+
+// roleActions lists what every workspace role may do, each role may do everything of the role below it.
+var roleActions = map[domain.Role]map[domain.Action]bool{
+	domain.RoleViewer: {
+		domain.ActionReadLinks: true,
+	},
+	domain.RoleEditor: {
+		domain.ActionReadLinks:   true,
+		domain.ActionCreateLinks: true,
+		domain.ActionManageLinks: true,
+	},
+	domain.RoleAdmin: {
+		domain.ActionReadLinks:      true,
+		domain.ActionCreateLinks:    true,
+		domain.ActionManageLinks:    true,
+		domain.ActionManageMembers:  true,
+		domain.ActionManageDomains:  true,
+		domain.ActionReadAudit:      true,
+		domain.ActionManageWebhooks: true,
+	},
+	domain.RoleOwner: {
+		domain.ActionReadLinks:      true,
+		domain.ActionCreateLinks:    true,
+		domain.ActionManageLinks:    true,
+		domain.ActionManageMembers:  true,
+		domain.ActionManageDomains:  true,
+		domain.ActionManageOwners:   true,
+		domain.ActionReadAudit:      true,
+		domain.ActionManageWebhooks: true,
+	},
+}
+
+// Allows reports whether members with the role may perform the action.
+func Allows(role domain.Role, action domain.Action) bool {
+	return roleActions[role][action]
+}
+
+// Policy checks the workspace role of users. Admin principals may do everything, other principals
+// that don't act for a user, i.e. api keys and anonymous requests, are kept out of workspaces and
+// users' personal links, the routes limit what they may do with the remaining links.
+type Policy struct {
+	WorkspaceRepository ports.WorkspaceRepository
+}
+
+func NewPolicy(WorkspaceRepository ports.WorkspaceRepository) ports.Policy {
+	return &Policy{
+		WorkspaceRepository: WorkspaceRepository,
+	}
+}
+
+// Authorize checks that the principal may perform the action in the workspace. Anonymous requests
+// get ErrUnauthorized and api keys ErrForbidden, users who are not a member get ErrDataNotFound
+// and members whose role doesn't allow the action ErrForbidden.
+func (p *Policy) Authorize(ctx context.Context, workspaceID int, action domain.Action) error {
+	principal := domain.PrincipalFromContext(ctx)
+	if isUnrestricted(principal) {
+		return nil
+	}
+	if !principal.IsAuthenticated() {
+		return domain.ErrUnauthorized
+	}
+	if principal.UserID == 0 {
+		return domain.ErrForbidden
+	}
+
+	role, err := p.WorkspaceRepository.GetRole(ctx, workspaceID, principal.UserID)
+	if err != nil {
+		return err
+	}
+	if !Allows(role, action) {
+		return domain.ErrForbidden
+	}
+
+	return nil
+}
+
+// AuthorizeLink checks that the principal may perform the action on the shortcode. Links of a
+// workspace follow the roles of the workspace, personal links are only accessible by their owner
+// and links without an owner only by principals that don't act for a user.
+func (p *Policy) AuthorizeLink(ctx context.Context, shortcode *domain.ShortCode, action domain.Action) error {
+	principal := domain.PrincipalFromContext(ctx)
+	if isUnrestricted(principal) {
+		return nil
+	}
+
+	if shortcode.WorkspaceID != 0 {
+		return p.Authorize(ctx, shortcode.WorkspaceID, action)
+	}
+	if shortcode.OwnerID != principal.UserID {
+		return domain.ErrDataNotFound
+	}
+
+	return nil
+}
+
+// LinkScope returns the links the principal may list. Without a workspace users list their
+// personal links, admins every link and other principals the links without an owner.
+func (p *Policy) LinkScope(ctx context.Context, workspaceID int) (domain.LinkScope, error) {
+	if workspaceID != 0 {
+		if err := p.Authorize(ctx, workspaceID, domain.ActionReadLinks); err != nil {
+			return domain.LinkScope{}, err
+		}
+		return domain.LinkScope{WorkspaceID: workspaceID}, nil
+	}
+
+	principal := domain.PrincipalFromContext(ctx)
+	if isUnrestricted(principal) {
+		return domain.LinkScope{}, nil
+	}
+	if principal.UserID == 0 {
+		return domain.LinkScope{Unowned: true}, nil
+	}
+
+	return domain.LinkScope{OwnerID: principal.UserID}, nil
+}
+
+func isUnrestricted(principal *domain.Principal) bool {
+	return principal.HasScope(domain.ScopeAdmin)
+}
@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeWorkspaceRepository knows the roles of the members of workspace 1, user 2 is an editor
+// and user 3 a viewer.
+type fakeWorkspaceRepository struct {
+	ports.WorkspaceRepository
+}
+
+func (r *fakeWorkspaceRepository) GetRole(ctx context.Context, workspaceID, userID int) (domain.Role, error) {
+	roles := map[int]domain.Role{2: domain.RoleEditor, 3: domain.RoleViewer}
+	if role, ok := roles[userID]; ok && workspaceID == 1 {
+		return role, nil
+	}
+
+	return "", domain.ErrDataNotFound
+}
+
+var (
+	anonymous = &domain.Principal{Scopes: []domain.Scope{domain.ScopeCreate}}
+	manageKey = &domain.Principal{Subject: "key:1", Scopes: []domain.Scope{domain.ScopeCreate, domain.ScopeReadStats, domain.ScopeManage}}
+	adminKey  = &domain.Principal{Subject: "key:2", Scopes: []domain.Scope{domain.ScopeAdmin}}
+	editor    = &domain.Principal{Subject: "user:2", UserID: 2, Scopes: domain.UserScopes}
+	viewer    = &domain.Principal{Subject: "user:3", UserID: 3, Scopes: domain.UserScopes}
+	stranger  = &domain.Principal{Subject: "user:4", UserID: 4, Scopes: domain.UserScopes}
+)
+
+func TestAllows(t *testing.T) {
+	tests := map[string]struct {
+		role   domain.Role
+		action domain.Action
+		want   bool
+	}{
+		"viewer reads":            {domain.RoleViewer, domain.ActionReadLinks, true},
+		"viewer creates":          {domain.RoleViewer, domain.ActionCreateLinks, false},
+		"editor manages links":    {domain.RoleEditor, domain.ActionManageLinks, true},
+		"editor manages members":  {domain.RoleEditor, domain.ActionManageMembers, false},
+		"admin reads audit":       {domain.RoleAdmin, domain.ActionReadAudit, true},
+		"admin manages owners":    {domain.RoleAdmin, domain.ActionManageOwners, false},
+		"owner manages owners":    {domain.RoleOwner, domain.ActionManageOwners, true},
+		"unknown role reads":      {domain.Role("guest"), domain.ActionReadLinks, false},
+		"owner manages webhooks":  {domain.RoleOwner, domain.ActionManageWebhooks, true},
+		"viewer manages webhooks": {domain.RoleViewer, domain.ActionManageWebhooks, false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := Allows(test.role, test.action); got != test.want {
+				t.Errorf("Allows(%s, %s) = %v, want %v", test.role, test.action, got, test.want)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	policy := &Policy{WorkspaceRepository: &fakeWorkspaceRepository{}}
+
+	tests := map[string]struct {
+		principal *domain.Principal
+		action    domain.Action
+		err       error
+	}{
+		"anonymous":        {anonymous, domain.ActionCreateLinks, domain.ErrUnauthorized},
+		"key without user": {manageKey, domain.ActionReadLinks, domain.ErrForbidden},
+		"admin key":        {adminKey, domain.ActionManageOwners, nil},
+		"editor creates":   {editor, domain.ActionCreateLinks, nil},
+		"editor audits":    {editor, domain.ActionReadAudit, domain.ErrForbidden},
+		"viewer reads":     {viewer, domain.ActionReadLinks, nil},
+		"viewer creates":   {viewer, domain.ActionCreateLinks, domain.ErrForbidden},
+		"not a member":     {stranger, domain.ActionReadLinks, domain.ErrDataNotFound},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := domain.WithPrincipal(context.Background(), test.principal)
+			if err := policy.Authorize(ctx, 1, test.action); !errors.Is(err, test.err) {
+				t.Errorf("Authorize() error = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestAuthorizeLink(t *testing.T) {
+	policy := &Policy{WorkspaceRepository: &fakeWorkspaceRepository{}}
+	workspaceLink := &domain.ShortCode{Code: "team", WorkspaceID: 1}
+	personalLink := &domain.ShortCode{Code: "mine", OwnerID: 2}
+	unownedLink := &domain.ShortCode{Code: "anon"}
+
+	tests := map[string]struct {
+		principal *domain.Principal
+		shortcode *domain.ShortCode
+		err       error
+	}{
+		"anonymous on workspace link": {anonymous, workspaceLink, domain.ErrUnauthorized},
+		"key on workspace link":       {manageKey, workspaceLink, domain.ErrForbidden},
+		"admin on workspace link":     {adminKey, workspaceLink, nil},
+		"editor on workspace link":    {editor, workspaceLink, nil},
+		"viewer on workspace link":    {viewer, workspaceLink, domain.ErrForbidden},
+		"owner on personal link":      {editor, personalLink, nil},
+		"other user on personal link": {viewer, personalLink, domain.ErrDataNotFound},
+		"key on personal link":        {manageKey, personalLink, domain.ErrDataNotFound},
+		"admin on personal link":      {adminKey, personalLink, nil},
+		"key on unowned link":         {manageKey, unownedLink, nil},
+		"user on unowned link":        {editor, unownedLink, domain.ErrDataNotFound},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := domain.WithPrincipal(context.Background(), test.principal)
+			if err := policy.AuthorizeLink(ctx, test.shortcode, domain.ActionManageLinks); !errors.Is(err, test.err) {
+				t.Errorf("AuthorizeLink() error = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestLinkScope(t *testing.T) {
+	policy := &Policy{WorkspaceRepository: &fakeWorkspaceRepository{}}
+
+	tests := map[string]struct {
+		principal   *domain.Principal
+		workspaceID int
+		want        domain.LinkScope
+		err         error
+	}{
+		"admin":                  {adminKey, 0, domain.LinkScope{}, nil},
+		"user":                   {editor, 0, domain.LinkScope{OwnerID: 2}, nil},
+		"key":                    {manageKey, 0, domain.LinkScope{Unowned: true}, nil},
+		"anonymous":              {anonymous, 0, domain.LinkScope{Unowned: true}, nil},
+		"member of workspace":    {viewer, 1, domain.LinkScope{WorkspaceID: 1}, nil},
+		"stranger to workspace":  {stranger, 1, domain.LinkScope{}, domain.ErrDataNotFound},
+		"key on workspace":       {manageKey, 1, domain.LinkScope{}, domain.ErrForbidden},
+		"anonymous on workspace": {anonymous, 1, domain.LinkScope{}, domain.ErrUnauthorized},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := domain.WithPrincipal(context.Background(), test.principal)
+			got, err := policy.LinkScope(ctx, test.workspaceID)
+			if !errors.Is(err, test.err) || got != test.want {
+				t.Errorf("LinkScope() = %+v, %v, want %+v, %v", got, err, test.want, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+)
+
+type APIKeyRepository interface {
+	Save(ctx context.Context, key *domain.APIKey, hash string) (*domain.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	List(ctx context.Context) ([]*domain.APIKey, error)
+	Revoke(ctx context.Context, id int) error
+	TouchLastUsed(ctx context.Context, id int) error
+}
@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+)
+
+type AuditRepository interface {
+	Record(ctx context.Context, entries ...*domain.AuditEntry) error
+	List(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error)
+	Export(ctx context.Context, filter domain.AuditFilter, fn func(*domain.AuditEntry) error) error
+}
@@ -3,13 +3,30 @@ package ports
 import (
 	"URLRotatorGo/internal/core/domain"
 	"context"
+	"time"
 )
 
 type CacheRepository interface {
 	SaveShortCode(ctx context.Context, shortcode *domain.ShortCode) error
 	GetShortCode(ctx context.Context, code string) (*domain.ShortCode, error)
-	IncrShortCode(ctx context.Context, code string) error
+	DeleteShortCode(ctx context.Context, code string) error
 	SaveLinks(ctx context.Context, links []*domain.URL) error
 	GetLinks(ctx context.Context, code string) ([]*domain.URL, error)
-	IncrLink(ctx context.Context, code, id string) error
+	DeleteLinks(ctx context.Context, code string) error
+	IncrHits(ctx context.Context, code, id string) error
+	IncrAttempts(ctx context.Context, key string, window time.Duration) (int, error)
+	DecrAttempts(ctx context.Context, key string) error
+	ReserveIdempotencyKey(ctx context.Context, key, fingerprint string, ttl time.Duration) (*domain.IdempotentResponse, error)
+	SaveIdempotentResponse(ctx context.Context, key string, response *domain.IdempotentResponse, ttl time.Duration) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+	SaveDomain(ctx context.Context, customDomain *domain.Domain, ttl time.Duration) error
+	GetDomain(ctx context.Context, host string) (*domain.Domain, error)
+	DeleteDomain(ctx context.Context, host string) error
+	AllowRequest(ctx context.Context, key string, limit domain.RateLimit) (*domain.RateLimitResult, error)
+	AddVisitor(ctx context.Context, code string, urlID int, visitor string, day time.Time) error
+	GetVisitorSketches(ctx context.Context, day time.Time) ([]*domain.VisitorSketch, error)
+	MergeVisitorSketches(ctx context.Context, sketches []*domain.VisitorSketch) error
+	CountVisitors(ctx context.Context, sketches []*domain.VisitorSketch) (int64, error)
+	PublishClicks(ctx context.Context, events []*domain.ClickEvent) error
+	SubscribeClicks(ctx context.Context, code string, fn func(*domain.ClickEvent) error) error
 }
@@ -0,0 +1,33 @@
+package ports
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+	"time"
+)
+
+type ClickRepository interface {
+	Save(ctx context.Context, events []*domain.ClickEvent) error
+	EnsurePartition(ctx context.Context, month time.Time) error
+	RollupHourly(ctx context.Context, from, to time.Time, countBots bool) error
+	RollupDaily(ctx context.Context, from, to time.Time, loc *time.Location, countBots bool) error
+	Series(ctx context.Context, query domain.AnalyticsQuery) ([]domain.ClickCount, error)
+	DailySeries(ctx context.Context, query domain.AnalyticsQuery) ([]domain.ClickCount, error)
+	TopValues(ctx context.Context, code, dimension string, from, to time.Time, limit int) ([]domain.DimensionCount, error)
+	SaveVisitorSketches(ctx context.Context, sketches []*domain.VisitorSketch) error
+	GetVisitorSketches(ctx context.Context, code string, from, to time.Time) ([]*domain.VisitorSketch, error)
+	GetDaySketches(ctx context.Context, day time.Time) ([]*domain.VisitorSketch, error)
+}
+
+// ClickRecorder collects click events in the background, Record must never block a redirect.
+type ClickRecorder interface {
+	Record(event *domain.ClickEvent)
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// RollupJob keeps the click rollups up to date in the background.
+type RollupJob interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
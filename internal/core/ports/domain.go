@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+)
+
+type DomainRepository interface {
+	Save(ctx context.Context, customDomain *domain.Domain) (*domain.Domain, error)
+	GetByHost(ctx context.Context, host string) (*domain.Domain, error)
+	GetByID(ctx context.Context, id int) (*domain.Domain, error)
+	List(ctx context.Context, workspaceID int) ([]*domain.Domain, error)
+	Update(ctx context.Context, id int, update domain.DomainUpdate) (*domain.Domain, error)
+	Delete(ctx context.Context, id int) error
+}
@@ -0,0 +1,11 @@
+package ports
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+)
+
+type RevisionRepository interface {
+	List(ctx context.Context, code string) ([]*domain.Revision, error)
+	Rollback(ctx context.Context, code string, revision int) (*domain.Revision, error)
+}
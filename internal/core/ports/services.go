@@ -3,9 +3,85 @@ package ports
 import (
 	"URLRotatorGo/internal/core/domain"
 	"context"
+	"time"
 )
 
 type ShortenerService interface {
-	ShortURL(ctx context.Context, urls []string, strategy string) (*domain.ShortCode, error)
-	GetRedirectURL(ctx context.Context, code string) (string, error)
+	ShortURL(ctx context.Context, owner domain.LinkScope, request domain.ShortenRequest) (*domain.ShortCode, error)
+	BulkShortURL(ctx context.Context, owner domain.LinkScope, requests []domain.ShortenRequest) []domain.BulkShortenResult
+	GetShortCode(ctx context.Context, code string) (*domain.ShortCode, error)
+	UnlockShortCode(ctx context.Context, code, password, ip string) error
+	GetRedirectURL(ctx context.Context, code string, visit domain.Visit) (string, error)
+	GetLinks(ctx context.Context, code string) ([]*domain.URL, error)
+}
+
+type ExportService interface {
+	Authorize(ctx context.Context, filter domain.ExportFilter) error
+	ExportShortCodes(ctx context.Context, filter domain.ExportFilter, fn func(*domain.ShortCodeExport) error) error
+}
+
+type AuditService interface {
+	Authorize(ctx context.Context, filter domain.AuditFilter) error
+	ListEntries(ctx context.Context, filter domain.AuditFilter, cursor string) (*domain.AuditPage, error)
+	ExportEntries(ctx context.Context, filter domain.AuditFilter, fn func(*domain.AuditEntry) error) error
+}
+
+type AnalyticsService interface {
+	GetAnalytics(ctx context.Context, query domain.AnalyticsQuery) (*domain.Analytics, error)
+	RollupClicks(ctx context.Context, from, to time.Time) error
+	PersistVisitors(ctx context.Context, day time.Time) error
+	AuthorizeStream(ctx context.Context, code string) error
+	StreamClicks(ctx context.Context, code string, fn func(*domain.ClickEvent) error) error
+}
+
+type LinkService interface {
+	ListLinks(ctx context.Context, query domain.LinkQuery, cursor string) (*domain.LinkPage, error)
+	SetTags(ctx context.Context, code string, tags []string) ([]string, error)
+	GetDestinations(ctx context.Context, code string) ([]*domain.URL, error)
+	SetDestinationEnabled(ctx context.Context, code string, id int, enabled bool) (*domain.URL, error)
+	UpdateLink(ctx context.Context, code string, request domain.UpdateLinkRequest) (*domain.ShortCode, error)
+	ListRevisions(ctx context.Context, code string) ([]*domain.Revision, error)
+	RollbackLink(ctx context.Context, code string, revision int) (*domain.Revision, error)
+}
+
+type UserService interface {
+	Signup(ctx context.Context, email, password string) (*domain.User, error)
+	Login(ctx context.Context, email, password, ip string) (*domain.User, error)
+	GetUser(ctx context.Context, id int) (*domain.User, error)
+}
+
+type WorkspaceService interface {
+	CreateWorkspace(ctx context.Context, name string) (*domain.Workspace, error)
+	ListWorkspaces(ctx context.Context) ([]*domain.Workspace, error)
+	ListMembers(ctx context.Context, workspaceID int) ([]*domain.Member, error)
+	SetMemberRole(ctx context.Context, workspaceID, userID int, role string) error
+	RemoveMember(ctx context.Context, workspaceID, userID int) error
+	InviteMember(ctx context.Context, workspaceID int, email, role string) (*domain.Invitation, string, error)
+	ListInvitations(ctx context.Context, workspaceID int) ([]*domain.Invitation, error)
+	RevokeInvitation(ctx context.Context, workspaceID, id int) error
+	AcceptInvitation(ctx context.Context, token string) (*domain.Invitation, error)
+}
+
+type DomainService interface {
+	RegisterDomain(ctx context.Context, customDomain *domain.Domain) (*domain.Domain, error)
+	ListDomains(ctx context.Context, workspaceID int) ([]*domain.Domain, error)
+	UpdateDomain(ctx context.Context, id int, update domain.DomainUpdate) (*domain.Domain, error)
+	DeleteDomain(ctx context.Context, id int) error
+	ResolveHost(ctx context.Context, host string) (*domain.Domain, error)
+}
+
+type WebhookService interface {
+	RegisterWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error)
+	ListWebhooks(ctx context.Context, workspaceID int) ([]*domain.Webhook, error)
+	UpdateWebhook(ctx context.Context, id int, update domain.WebhookUpdate) (*domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	ListDeliveries(ctx context.Context, id int, before int64, limit int) ([]*domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id int, deliveryID int64) (*domain.WebhookDelivery, error)
+}
+
+type APIKeyService interface {
+	IssueKey(ctx context.Context, name string, scopes []string) (*domain.APIKey, string, error)
+	ListKeys(ctx context.Context) ([]*domain.APIKey, error)
+	RevokeKey(ctx context.Context, id int) error
+	Authenticate(ctx context.Context, key string) (*domain.Principal, error)
 }
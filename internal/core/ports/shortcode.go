@@ -6,7 +6,21 @@ import (
 )
 
 type ShortCodeRepository interface {
-	Save(ctx context.Context, url *domain.ShortCode) (*domain.ShortCode, error)
-	UpdateHit(ctx context.Context, code string) error
+	Save(ctx context.Context, shortcode *domain.ShortCode, urls []*domain.URL) (*domain.ShortCode, []*domain.URL, error)
+	SaveBatch(ctx context.Context, shortcodes []*domain.ShortCode, urls []*domain.URL) ([]*domain.ShortCode, []*domain.URL, error)
+	AddHits(ctx context.Context, hits map[string]int64) error
+	Update(ctx context.Context, code string, update domain.LinkUpdate) (*domain.ShortCode, error)
 	GetShortCode(ctx context.Context, code string) (*domain.ShortCode, error)
+	FindByFingerprint(ctx context.Context, owner domain.LinkScope, host, fingerprint string) (*domain.ShortCode, error)
+	List(ctx context.Context, scope domain.LinkScope, query domain.LinkQuery) ([]*domain.ShortCode, error)
+	Search(ctx context.Context, scope domain.LinkScope, term string, query domain.LinkQuery) ([]*domain.ShortCode, error)
+	Export(ctx context.Context, scope domain.LinkScope, filter domain.ExportFilter, fn func(*domain.ShortCodeExport) error) error
+}
+
+// HitCounter adds up the hits of redirects in memory and writes them to the database in batches,
+// Record must never block a redirect.
+type HitCounter interface {
+	Record(code string, urlID int)
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
 }
@@ -0,0 +1,7 @@
+package ports
+
+import "context"
+
+type TagRepository interface {
+	SetTags(ctx context.Context, code string, tags []string) ([]string, error)
+}
@@ -7,6 +7,7 @@ import (
 
 type URLRepository interface {
 	Save(ctx context.Context, urls []*domain.URL) ([]*domain.URL, error)
-	UpdateHit(ctx context.Context, id string) error
+	AddHits(ctx context.Context, hits map[int]int64) error
 	GetLinks(ctx context.Context, code string) ([]*domain.URL, error)
+	SetEnabled(ctx context.Context, code string, id int, enabled bool) (*domain.URL, error)
 }
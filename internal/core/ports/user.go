@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+)
+
+type UserRepository interface {
+	Save(ctx context.Context, user *domain.User) (*domain.User, error)
+	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetByID(ctx context.Context, id int) (*domain.User, error)
+}
@@ -0,0 +1,31 @@
+package ports
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+	"time"
+)
+
+type WebhookRepository interface {
+	Save(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error)
+	GetByID(ctx context.Context, id int) (*domain.Webhook, error)
+	List(ctx context.Context, scope domain.LinkScope) ([]*domain.Webhook, error)
+	Update(ctx context.Context, id int, update domain.WebhookUpdate) (*domain.Webhook, error)
+	Delete(ctx context.Context, id int) error
+	Enqueue(ctx context.Context, events ...*domain.WebhookEvent) error
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error)
+	CompleteDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID int, before int64, limit int) ([]*domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookID int, id int64) (*domain.WebhookDelivery, error)
+}
+
+// WebhookSender sends a single delivery, it returns the status code of the response if there was one.
+type WebhookSender interface {
+	Send(ctx context.Context, delivery *domain.WebhookDelivery) (int, error)
+}
+
+// WebhookDispatcher sends the queued deliveries in the background.
+type WebhookDispatcher interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+)
+
+type WorkspaceRepository interface {
+	Create(ctx context.Context, workspace *domain.Workspace, ownerID int) (*domain.Workspace, error)
+	ListByUser(ctx context.Context, userID int) ([]*domain.Workspace, error)
+	GetRole(ctx context.Context, workspaceID, userID int) (domain.Role, error)
+	ListMembers(ctx context.Context, workspaceID int) ([]*domain.Member, error)
+	SetMemberRole(ctx context.Context, workspaceID, userID int, role domain.Role) error
+	RemoveMember(ctx context.Context, workspaceID, userID int) error
+	SaveInvitation(ctx context.Context, invitation *domain.Invitation, tokenHash string) (*domain.Invitation, error)
+	ListInvitations(ctx context.Context, workspaceID int) ([]*domain.Invitation, error)
+	RevokeInvitation(ctx context.Context, workspaceID, id int) error
+	GetInvitationByHash(ctx context.Context, tokenHash string) (*domain.Invitation, error)
+	AcceptInvitation(ctx context.Context, id, userID int) error
+}
+
+// Policy decides whether the principal of a context may perform an action. Principals that are
+// not allowed to see a resource get ErrDataNotFound, so that its existence isn't revealed.
+type Policy interface {
+	Authorize(ctx context.Context, workspaceID int, action domain.Action) error
+	AuthorizeLink(ctx context.Context, shortcode *domain.ShortCode, action domain.Action) error
+	LinkScope(ctx context.Context, workspaceID int) (domain.LinkScope, error)
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	// RollupLocation is the timezone of the daily rollups, main sets it to app.timezone
+	RollupLocation = time.UTC
+	// CountBots counts the visits of bots as hits and clicks like those of humans, main sets it
+	// to analytics.count_bots
+	CountBots = false
+	// DefaultAnalyticsRange is the range of a query without from
+	DefaultAnalyticsRange = 7 * 24 * time.Hour
+	// MaxAnalyticsPoints limits the buckets of a single series
+	MaxAnalyticsPoints = 1000
+	TopValuesLimit     = 10
+	// VisitorSketchBatch is how many sketches are persisted in one statement
+	VisitorSketchBatch = 1000
+)
+
+type AnalyticsService struct {
+	ShortCodeRepository ports.ShortCodeRepository
+	URLRepository       ports.URLRepository
+	ClickRepository     ports.ClickRepository
+	CacheRepository     ports.CacheRepository
+	Policy              ports.Policy
+}
+
+func NewAnalyticsService(ShortCodeRepository ports.ShortCodeRepository, URLRepository ports.URLRepository, ClickRepository ports.ClickRepository, CacheRepository ports.CacheRepository, Policy ports.Policy) ports.AnalyticsService {
+	return &AnalyticsService{
+		ShortCodeRepository: ShortCodeRepository,
+		URLRepository:       URLRepository,
+		ClickRepository:     ClickRepository,
+		CacheRepository:     CacheRepository,
+		Policy:              Policy,
+	}
+}
+
+// GetAnalytics returns the clicks of a shortcode per destination and bucket, together with its top
+// referrers, countries and devices. The range is widened to whole buckets of the query location.
+func (s *AnalyticsService) GetAnalytics(ctx context.Context, query domain.AnalyticsQuery) (*domain.Analytics, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	shortcode, err := s.ShortCodeRepository.GetShortCode(ctx, query.Code)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.Policy.AuthorizeLink(ctx, shortcode, domain.ActionReadLinks); err != nil {
+		return nil, err
+	}
+
+	query, err = normalizeAnalyticsQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := analyticsBuckets(query)
+	if len(buckets) > MaxAnalyticsPoints {
+		return nil, domain.ErrInvalidRange
+	}
+
+	// the daily rollups only exist for one timezone, every other one is bucketed from the hourly rollups
+	var counts []domain.ClickCount
+	if query.Interval == domain.IntervalDay && query.Location.String() == RollupLocation.String() {
+		counts, err = s.ClickRepository.DailySeries(ctx, query)
+	} else {
+		counts, err = s.ClickRepository.Series(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.URLRepository.GetLinks(ctx, query.Code)
+	if err != nil && !errors.Is(err, domain.ErrDataNotFound) {
+		return nil, err
+	}
+
+	analytics := &domain.Analytics{Query: query}
+	series := make(map[int]*domain.DestinationSeries, len(links))
+	addSeries := func(id int, url string) *domain.DestinationSeries {
+		destination := &domain.DestinationSeries{
+			URLID:  id,
+			URL:    url,
+			Points: make([]domain.AnalyticsPoint, len(buckets)),
+		}
+		for i, bucket := range buckets {
+			destination.Points[i].Time = bucket
+		}
+		series[id] = destination
+		analytics.Series = append(analytics.Series, destination)
+		return destination
+	}
+	for _, link := range links {
+		addSeries(link.ID, link.Original)
+	}
+
+	index := make(map[int64]int, len(buckets))
+	for i, bucket := range buckets {
+		index[bucket.Unix()] = i
+	}
+	for _, count := range counts {
+		i, ok := index[count.Bucket.Unix()]
+		if !ok {
+			continue
+		}
+		// clicks of a destination that has been removed since are still counted
+		destination, ok := series[count.URLID]
+		if !ok {
+			destination = addSeries(count.URLID, "")
+		}
+		destination.Points[i].Clicks += count.Clicks
+		destination.Total += count.Clicks
+		analytics.Total += count.Clicks
+	}
+	sort.Slice(analytics.Series, func(i, j int) bool {
+		return analytics.Series[i].URLID < analytics.Series[j].URLID
+	})
+
+	if err = s.countVisitors(ctx, analytics, series); err != nil {
+		return nil, err
+	}
+
+	for dimension, values := range map[string]*[]domain.DimensionCount{
+		domain.DimensionReferrer: &analytics.Referrers,
+		domain.DimensionCountry:  &analytics.Countries,
+		domain.DimensionDevice:   &analytics.Devices,
+		domain.DimensionTraffic:  &analytics.Traffic,
+	} {
+		if *values, err = s.ClickRepository.TopValues(ctx, query.Code, dimension, query.From, query.To, TopValuesLimit); err != nil {
+			return nil, err
+		}
+	}
+
+	return analytics, nil
+}
+
+// AuthorizeStream checks that the principal may watch the clicks of the shortcode.
+func (s *AnalyticsService) AuthorizeStream(ctx context.Context, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	shortcode, err := s.ShortCodeRepository.GetShortCode(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	return s.Policy.AuthorizeLink(ctx, shortcode, domain.ActionReadLinks)
+}
+
+// StreamClicks passes the clicks of the shortcode on any instance to fn while they happen, until
+// ctx is done. Clicks are dropped instead of waiting for fn, slow consumers never hold up redirects.
+func (s *AnalyticsService) StreamClicks(ctx context.Context, code string, fn func(*domain.ClickEvent) error) error {
+	if err := s.AuthorizeStream(ctx, code); err != nil {
+		return err
+	}
+
+	if err := s.CacheRepository.SubscribeClicks(ctx, code, fn); err != nil {
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// RollupClicks recounts the hourly rollups of [from, to) and the daily rollups of the days it
+// touches. It is idempotent, so any range can be rolled up again, e.g. to backfill.
+func (s *AnalyticsService) RollupClicks(ctx context.Context, from, to time.Time) error {
+	from, to = from.UTC().Truncate(time.Hour), to.UTC()
+	if !to.Equal(to.Truncate(time.Hour)) {
+		to = to.Truncate(time.Hour).Add(time.Hour)
+	}
+	if err := s.ClickRepository.RollupHourly(ctx, from, to, CountBots); err != nil {
+		return err
+	}
+
+	day := domain.AnalyticsQuery{From: from, To: to, Interval: domain.IntervalDay, Location: RollupLocation}
+	from, to = bucketStart(day, from), bucketStart(day, to)
+	if to.Before(day.To) {
+		to = to.AddDate(0, 0, 1)
+	}
+
+	return s.ClickRepository.RollupDaily(ctx, from, to, RollupLocation, CountBots)
+}
+
+// countVisitors merges the daily sketches of the days the query touches into the unique visitors
+// of the shortcode and of every destination. Days are those of the app timezone and today's
+// sketches are only as recent as the last time they were persisted.
+func (s *AnalyticsService) countVisitors(ctx context.Context, analytics *domain.Analytics, series map[int]*domain.DestinationSeries) error {
+	day := domain.AnalyticsQuery{Interval: domain.IntervalDay, Location: RollupLocation}
+	from, to := bucketStart(day, analytics.Query.From), bucketStart(day, analytics.Query.To)
+	if to.Before(analytics.Query.To) {
+		to = nextBucket(day, to)
+	}
+
+	sketches, err := s.ClickRepository.GetVisitorSketches(ctx, analytics.Query.Code, from, to)
+	if err != nil {
+		return err
+	}
+
+	byURL := make(map[int][]*domain.VisitorSketch)
+	for _, sketch := range sketches {
+		byURL[sketch.URLID] = append(byURL[sketch.URLID], sketch)
+	}
+	for urlID, sketches := range byURL {
+		visitors := sketches[0].Visitors
+		if len(sketches) > 1 {
+			if visitors, err = s.CacheRepository.CountVisitors(ctx, sketches); err != nil {
+				return domain.ErrInternalServerError
+			}
+		}
+
+		if urlID == 0 {
+			analytics.Visitors = visitors
+		} else if destination, ok := series[urlID]; ok {
+			destination.Visitors = visitors
+		}
+	}
+
+	return nil
+}
+
+// PersistVisitors copies the sketches of the day from redis to postgres. Sketches that lost
+// visitors in redis, e.g. after a restart, get the persisted visitors merged back first.
+func (s *AnalyticsService) PersistVisitors(ctx context.Context, day time.Time) error {
+	live, err := s.CacheRepository.GetVisitorSketches(ctx, day)
+	if err != nil {
+		return domain.ErrInternalServerError
+	}
+	if len(live) == 0 {
+		return nil
+	}
+
+	persisted, err := s.ClickRepository.GetDaySketches(ctx, day)
+	if err != nil {
+		return err
+	}
+
+	visitors := make(map[string]int64, len(live))
+	for _, sketch := range live {
+		visitors[strconv.Itoa(sketch.URLID)+":"+sketch.ShortCode] = sketch.Visitors
+	}
+	var lost []*domain.VisitorSketch
+	for _, sketch := range persisted {
+		if count, ok := visitors[strconv.Itoa(sketch.URLID)+":"+sketch.ShortCode]; ok && count < sketch.Visitors {
+			sketch.Day = day
+			lost = append(lost, sketch)
+		}
+	}
+	if len(lost) > 0 {
+		if err = s.CacheRepository.MergeVisitorSketches(ctx, lost); err != nil {
+			return domain.ErrInternalServerError
+		}
+		if live, err = s.CacheRepository.GetVisitorSketches(ctx, day); err != nil {
+			return domain.ErrInternalServerError
+		}
+	}
+
+	for start := 0; start < len(live); start += VisitorSketchBatch {
+		end := min(start+VisitorSketchBatch, len(live))
+		if err = s.ClickRepository.SaveVisitorSketches(ctx, live[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func normalizeAnalyticsQuery(query domain.AnalyticsQuery) (domain.AnalyticsQuery, error) {
+	if query.Location == nil {
+		query.Location = RollupLocation
+	}
+	if query.To.IsZero() {
+		query.To = time.Now()
+	}
+	if query.From.IsZero() {
+		query.From = query.To.Add(-DefaultAnalyticsRange)
+	}
+	if !query.From.Before(query.To) {
+		return query, domain.ErrInvalidRange
+	}
+
+	switch query.Interval {
+	case "":
+		query.Interval = domain.IntervalHour
+		if query.To.Sub(query.From) > 48*time.Hour {
+			query.Interval = domain.IntervalDay
+		}
+	case domain.IntervalHour, domain.IntervalDay:
+	default:
+		return query, domain.ErrInvalidRange
+	}
+
+	// both ends are widened to whole buckets
+	to := bucketStart(query, query.To)
+	if to.Before(query.To) {
+		to = nextBucket(query, to)
+	}
+	query.From, query.To = bucketStart(query, query.From), to
+
+	return query, nil
+}
+
+// analyticsBuckets returns the start of every bucket of the query, it stops after one bucket
+// more than allowed so that huge ranges are never enumerated.
+func analyticsBuckets(query domain.AnalyticsQuery) []time.Time {
+	var buckets []time.Time
+	for bucket := query.From; bucket.Before(query.To) && len(buckets) <= MaxAnalyticsPoints; bucket = nextBucket(query, bucket) {
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets
+}
+
+func bucketStart(query domain.AnalyticsQuery, t time.Time) time.Time {
+	t = t.In(query.Location)
+	if query.Interval == domain.IntervalDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, query.Location)
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, query.Location)
+}
+
+func nextBucket(query domain.AnalyticsQuery, bucket time.Time) time.Time {
+	if query.Interval == domain.IntervalDay {
+		return bucket.AddDate(0, 0, 1)
+	}
+
+	return bucket.Add(time.Hour)
+}
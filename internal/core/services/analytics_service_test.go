@@ -0,0 +1,271 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(loc *time.Location, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2024, month, day, hour, minute, 0, 0, loc)
+}
+
+func TestNormalizeAnalyticsQuery(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+
+	tests := map[string]struct {
+		query    domain.AnalyticsQuery
+		from     time.Time
+		to       time.Time
+		interval domain.AnalyticsInterval
+		err      error
+	}{
+		"hours of a short range": {
+			query: domain.AnalyticsQuery{From: date(time.UTC, 3, 1, 10, 15), To: date(time.UTC, 3, 1, 12, 30)},
+			from:  date(time.UTC, 3, 1, 10, 0), to: date(time.UTC, 3, 1, 13, 0), interval: domain.IntervalHour,
+		},
+		"days of a long range": {
+			query: domain.AnalyticsQuery{From: date(time.UTC, 3, 1, 10, 0), To: date(time.UTC, 3, 5, 0, 0)},
+			from:  date(time.UTC, 3, 1, 0, 0), to: date(time.UTC, 3, 5, 0, 0), interval: domain.IntervalDay,
+		},
+		"days of another timezone": {
+			query: domain.AnalyticsQuery{From: date(time.UTC, 3, 30, 12, 0), To: date(time.UTC, 4, 1, 23, 0), Interval: domain.IntervalDay, Location: berlin},
+			from:  date(berlin, 3, 30, 0, 0), to: date(berlin, 4, 3, 0, 0), interval: domain.IntervalDay,
+		},
+		"empty range":      {query: domain.AnalyticsQuery{From: date(time.UTC, 3, 1, 10, 0), To: date(time.UTC, 3, 1, 10, 0)}, err: domain.ErrInvalidRange},
+		"reversed range":   {query: domain.AnalyticsQuery{From: date(time.UTC, 3, 2, 0, 0), To: date(time.UTC, 3, 1, 0, 0)}, err: domain.ErrInvalidRange},
+		"unknown interval": {query: domain.AnalyticsQuery{From: date(time.UTC, 3, 1, 0, 0), To: date(time.UTC, 3, 2, 0, 0), Interval: "week"}, err: domain.ErrInvalidRange},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, err := normalizeAnalyticsQuery(test.query)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("normalizeAnalyticsQuery() error = %v, want %v", err, test.err)
+			}
+			if err != nil {
+				return
+			}
+			if !query.From.Equal(test.from) || !query.To.Equal(test.to) || query.Interval != test.interval {
+				t.Errorf("normalizeAnalyticsQuery() = %s - %s by %s, want %s - %s by %s",
+					query.From, query.To, query.Interval, test.from, test.to, test.interval)
+			}
+		})
+	}
+}
+
+func TestAnalyticsBucketsAcrossDaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+
+	// the clocks went forward on 2024-03-31, so the day only has 23 hours
+	hours := analyticsBuckets(domain.AnalyticsQuery{From: date(berlin, 3, 31, 0, 0), To: date(berlin, 4, 1, 0, 0), Interval: domain.IntervalHour, Location: berlin})
+	if len(hours) != 23 {
+		t.Errorf("hour buckets = %d, want 23", len(hours))
+	}
+
+	days := analyticsBuckets(domain.AnalyticsQuery{From: date(berlin, 3, 30, 0, 0), To: date(berlin, 4, 2, 0, 0), Interval: domain.IntervalDay, Location: berlin})
+	if len(days) != 3 {
+		t.Fatalf("day buckets = %d, want 3", len(days))
+	}
+	for i, want := range []time.Time{date(berlin, 3, 30, 0, 0), date(berlin, 3, 31, 0, 0), date(berlin, 4, 1, 0, 0)} {
+		if !days[i].Equal(want) {
+			t.Errorf("day bucket %d = %s, want local midnight %s", i, days[i], want)
+		}
+	}
+}
+
+func TestGetAnalytics(t *testing.T) {
+	clicks := &fakeClickRepository{
+		counts: []domain.ClickCount{
+			{Bucket: date(time.UTC, 3, 1, 10, 0), URLID: 1, Clicks: 3},
+			{Bucket: date(time.UTC, 3, 1, 11, 0), URLID: 2, Clicks: 1},
+			// a destination that has been removed since
+			{Bucket: date(time.UTC, 3, 1, 11, 0), URLID: 9, Clicks: 2},
+			// outside of the range
+			{Bucket: date(time.UTC, 3, 1, 12, 0), URLID: 1, Clicks: 5},
+		},
+		top: map[string][]domain.DimensionCount{domain.DimensionCountry: {{Value: "DE", Clicks: 4}}},
+	}
+	service := &AnalyticsService{
+		ShortCodeRepository: &fakeShortCodeRepository{shortcodes: map[string]*domain.ShortCode{"spring": {Code: "spring"}}},
+		URLRepository: &fakeURLRepository{links: map[string][]*domain.URL{"spring": {
+			{ID: 2, Original: "https://b.example"},
+			{ID: 1, Original: "https://a.example"},
+		}}},
+		ClickRepository: clicks,
+		Policy:          &fakePolicy{},
+	}
+
+	analytics, err := service.GetAnalytics(context.Background(), domain.AnalyticsQuery{
+		Code: "spring", From: date(time.UTC, 3, 1, 10, 0), To: date(time.UTC, 3, 1, 12, 0), Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatalf("GetAnalytics() error = %v", err)
+	}
+
+	if analytics.Total != 6 || len(analytics.Series) != 3 {
+		t.Fatalf("GetAnalytics() = %d clicks in %d series, want 6 in 3", analytics.Total, len(analytics.Series))
+	}
+	want := []struct {
+		urlID  int
+		url    string
+		points []int64
+	}{
+		{1, "https://a.example", []int64{3, 0}},
+		{2, "https://b.example", []int64{0, 1}},
+		{9, "", []int64{0, 2}},
+	}
+	for i, series := range analytics.Series {
+		if series.URLID != want[i].urlID || series.URL != want[i].url || len(series.Points) != 2 {
+			t.Fatalf("series %d = %+v, want destination %d", i, series, want[i].urlID)
+		}
+		for j, point := range series.Points {
+			if point.Clicks != want[i].points[j] {
+				t.Errorf("series %d point %d = %d clicks, want %d", i, j, point.Clicks, want[i].points[j])
+			}
+		}
+	}
+	if len(analytics.Countries) != 1 || analytics.Countries[0].Value != "DE" {
+		t.Errorf("Countries = %+v, want the top values of the repository", analytics.Countries)
+	}
+}
+
+func TestGetAnalyticsReadsDailyRollups(t *testing.T) {
+	defer func(loc *time.Location) { RollupLocation = loc }(RollupLocation)
+	RollupLocation = time.UTC
+
+	tests := map[string]struct {
+		location *time.Location
+		want     string
+	}{
+		"rollup timezone": {time.UTC, "daily"},
+		"other timezone":  {time.FixedZone("UTC+2", 2*60*60), "hourly"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			clicks := &fakeClickRepository{}
+			service := &AnalyticsService{
+				ShortCodeRepository: &fakeShortCodeRepository{shortcodes: map[string]*domain.ShortCode{"spring": {Code: "spring"}}},
+				URLRepository:       &fakeURLRepository{},
+				ClickRepository:     clicks,
+				Policy:              &fakePolicy{},
+			}
+
+			_, err := service.GetAnalytics(context.Background(), domain.AnalyticsQuery{
+				Code: "spring", From: date(time.UTC, 3, 1, 0, 0), To: date(time.UTC, 3, 8, 0, 0), Interval: domain.IntervalDay, Location: test.location,
+			})
+			if err != nil {
+				t.Fatalf("GetAnalytics() error = %v", err)
+			}
+			if len(clicks.calls) != 1 || clicks.calls[0][:len(test.want)] != test.want {
+				t.Errorf("read %v, want the %s rollups", clicks.calls, test.want)
+			}
+		})
+	}
+}
+
+func TestGetAnalyticsLimitsPoints(t *testing.T) {
+	service := &AnalyticsService{
+		ShortCodeRepository: &fakeShortCodeRepository{shortcodes: map[string]*domain.ShortCode{"spring": {Code: "spring"}}},
+		ClickRepository:     &fakeClickRepository{},
+		Policy:              &fakePolicy{},
+	}
+
+	_, err := service.GetAnalytics(context.Background(), domain.AnalyticsQuery{
+		Code: "spring", From: date(time.UTC, 1, 1, 0, 0), To: date(time.UTC, 12, 1, 0, 0), Interval: domain.IntervalHour,
+	})
+	if !errors.Is(err, domain.ErrInvalidRange) {
+		t.Errorf("GetAnalytics() error = %v, want %v", err, domain.ErrInvalidRange)
+	}
+}
+
+func TestRollupClicks(t *testing.T) {
+	defer func(loc *time.Location) { RollupLocation = loc }(RollupLocation)
+	RollupLocation = time.UTC
+
+	clicks := &fakeClickRepository{}
+	service := &AnalyticsService{ClickRepository: clicks}
+
+	if err := service.RollupClicks(context.Background(), date(time.UTC, 3, 1, 10, 30), date(time.UTC, 3, 1, 12, 15)); err != nil {
+		t.Fatalf("RollupClicks() error = %v", err)
+	}
+
+	// both rollups cover whole buckets around the range
+	want := []string{"hourly 2024-03-01T10:00:00Z 2024-03-01T13:00:00Z", "daily 2024-03-01T00:00:00Z 2024-03-02T00:00:00Z"}
+	if len(clicks.calls) != 2 || clicks.calls[0] != want[0] || clicks.calls[1] != want[1] {
+		t.Errorf("rolled up %v, want %v", clicks.calls, want)
+	}
+}
+
+func TestGetAnalyticsCountsVisitors(t *testing.T) {
+	clicks := &fakeClickRepository{sketches: []*domain.VisitorSketch{
+		// the visitors of every destination together on two days, b visited on both
+		{Day: date(time.UTC, 3, 1, 0, 0), ShortCode: "spring", Sketch: fakeSketch("a", "b"), Visitors: 2},
+		{Day: date(time.UTC, 3, 2, 0, 0), ShortCode: "spring", Sketch: fakeSketch("b", "c"), Visitors: 2},
+		// a single day is counted without merging
+		{Day: date(time.UTC, 3, 1, 0, 0), ShortCode: "spring", URLID: 1, Sketch: fakeSketch("a", "b"), Visitors: 2},
+	}}
+	service := &AnalyticsService{
+		ShortCodeRepository: &fakeShortCodeRepository{shortcodes: map[string]*domain.ShortCode{"spring": {Code: "spring"}}},
+		URLRepository:       &fakeURLRepository{links: map[string][]*domain.URL{"spring": {{ID: 1}, {ID: 2}}}},
+		ClickRepository:     clicks,
+		CacheRepository:     &fakeCacheRepository{},
+		Policy:              &fakePolicy{},
+	}
+
+	analytics, err := service.GetAnalytics(context.Background(), domain.AnalyticsQuery{
+		Code: "spring", From: date(time.UTC, 3, 1, 0, 0), To: date(time.UTC, 3, 3, 0, 0), Interval: domain.IntervalDay, Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatalf("GetAnalytics() error = %v", err)
+	}
+
+	if analytics.Visitors != 3 {
+		t.Errorf("Visitors = %d, want 3 distinct visitors over both days", analytics.Visitors)
+	}
+	if analytics.Series[0].Visitors != 2 || analytics.Series[1].Visitors != 0 {
+		t.Errorf("visitors of the destinations = %d, %d, want 2, 0", analytics.Series[0].Visitors, analytics.Series[1].Visitors)
+	}
+}
+
+func TestPersistVisitors(t *testing.T) {
+	defer func(size int) { VisitorSketchBatch = size }(VisitorSketchBatch)
+	VisitorSketchBatch = 1
+
+	day := date(time.UTC, 3, 1, 0, 0)
+	cache := &fakeCacheRepository{sketches: []*domain.VisitorSketch{
+		// redis lost the sketch of the shortcode and only saw a since
+		{Day: day, ShortCode: "spring", Sketch: fakeSketch("a"), Visitors: 1},
+		{Day: day, ShortCode: "spring", URLID: 1, Sketch: fakeSketch("a", "b"), Visitors: 2},
+	}}
+	clicks := &fakeClickRepository{sketches: []*domain.VisitorSketch{
+		{ShortCode: "spring", Sketch: fakeSketch("a", "b", "c"), Visitors: 3},
+		{ShortCode: "spring", URLID: 1, Sketch: fakeSketch("a"), Visitors: 1},
+	}}
+	service := &AnalyticsService{ClickRepository: clicks, CacheRepository: cache}
+
+	if err := service.PersistVisitors(context.Background(), day); err != nil {
+		t.Fatalf("PersistVisitors() error = %v", err)
+	}
+
+	// only the sketch that has fewer visitors than the persisted one is merged back
+	if cache.merged != 1 {
+		t.Errorf("merged %d sketches, want 1", cache.merged)
+	}
+	if len(clicks.saved) != 2 {
+		t.Fatalf("saved %d batches, want one per sketch", len(clicks.saved))
+	}
+	for _, batch := range clicks.saved {
+		sketch := batch[0]
+		if want := map[int]int64{0: 3, 1: 2}[sketch.URLID]; sketch.Visitors != want {
+			t.Errorf("saved %d visitors of destination %d, want %d", sketch.Visitors, sketch.URLID, want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/infra/workerpool"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type APIKeyService struct {
+	APIKeyRepository ports.APIKeyRepository
+	AuditRepository  ports.AuditRepository
+}
+
+func NewAPIKeyService(APIKeyRepository ports.APIKeyRepository, AuditRepository ports.AuditRepository) ports.APIKeyService {
+	return &APIKeyService{
+		APIKeyRepository: APIKeyRepository,
+		AuditRepository:  AuditRepository,
+	}
+}
+
+// IssueKey creates a new key. The returned key is the only time it can be seen, only its hash is stored.
+func (s *APIKeyService) IssueKey(ctx context.Context, name string, scopes []string) (*domain.APIKey, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if len(scopes) == 0 {
+		return nil, "", domain.ErrInvalidScope
+	}
+
+	apiKey := &domain.APIKey{Name: strings.TrimSpace(name)}
+	seen := make(map[domain.Scope]bool, len(scopes))
+	for _, value := range scopes {
+		scope, ok := domain.ParseScope(strings.ToLower(strings.TrimSpace(value)))
+		if !ok {
+			return nil, "", domain.ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			apiKey.Scopes = append(apiKey.Scopes, scope)
+		}
+	}
+
+	key, prefix, err := pkg.GenerateAPIKey()
+	if err != nil {
+		logger.L.Errorw("failed to generate api key", "error", err.Error())
+		return nil, "", domain.ErrInternalServerError
+	}
+	apiKey.Prefix = prefix
+
+	apiKey, err = s.APIKeyRepository.Save(ctx, apiKey, pkg.HashAPIKey(key))
+	if err != nil {
+		return nil, "", err
+	}
+
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditKeyIssue, domain.AuditTargetAPIKey, strconv.Itoa(apiKey.ID), 0, nil, map[string]any{
+		"name":   apiKey.Name,
+		"prefix": apiKey.Prefix,
+		"scopes": apiKey.Scopes,
+	}))
+
+	return apiKey, key, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return s.APIKeyRepository.List(ctx)
+}
+
+func (s *APIKeyService) RevokeKey(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if err := s.APIKeyRepository.Revoke(ctx, id); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditKeyRevoke, domain.AuditTargetAPIKey, strconv.Itoa(id), 0,
+		map[string]any{"revoked": false}, map[string]any{"revoked": true}))
+
+	return nil
+}
+
+// Authenticate resolves a key sent by a client into the principal it stands for.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*domain.Principal, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if !strings.HasPrefix(key, pkg.APIKeyPrefix) {
+		return nil, domain.ErrUnauthorized
+	}
+
+	apiKey, err := s.APIKeyRepository.GetByHash(ctx, pkg.HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, domain.ErrDataNotFound) {
+			return nil, domain.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	_ = workerpool.Pool.Submit(func() {
+		myctx, mycancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer mycancel()
+		_ = s.APIKeyRepository.TouchLastUsed(myctx, apiKey.ID)
+	})
+
+	return &domain.Principal{
+		Subject: "key:" + strconv.Itoa(apiKey.ID),
+		Name:    apiKey.Name,
+		Scopes:  apiKey.Scopes,
+	}, nil
+}
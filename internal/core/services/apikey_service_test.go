@@ -0,0 +1,62 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/pkg"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestIssueKey(t *testing.T) {
+	repository := &fakeAPIKeyRepository{}
+	audit := &fakeAuditRepository{}
+	service := &APIKeyService{APIKeyRepository: repository, AuditRepository: audit}
+
+	apiKey, key, err := service.IssueKey(context.Background(), " ci ", []string{"create", " Read-Stats ", "create"})
+	if err != nil {
+		t.Fatalf("IssueKey() error = %v", err)
+	}
+	if apiKey.Name != "ci" || !reflect.DeepEqual(apiKey.Scopes, []domain.Scope{domain.ScopeCreate, domain.ScopeReadStats}) {
+		t.Errorf("IssueKey() = %+v, want ci with create and read-stats", apiKey)
+	}
+	// only the hash of the key is stored
+	if repository.hashes[0] != pkg.HashAPIKey(key) {
+		t.Errorf("stored %q, want the hash of the key", repository.hashes[0])
+	}
+	if len(audit.entries) != 1 || audit.entries[0].Action != domain.AuditKeyIssue {
+		t.Errorf("audit entries = %+v, want the issued key", audit.entries)
+	}
+
+	for name, scopes := range map[string][]string{"none": nil, "unknown": {"create", "delete"}} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := service.IssueKey(context.Background(), "ci", scopes); !errors.Is(err, domain.ErrInvalidScope) {
+				t.Errorf("IssueKey(%v) error = %v, want %v", scopes, err, domain.ErrInvalidScope)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	key := pkg.APIKeyPrefix + "valid"
+	service := &APIKeyService{APIKeyRepository: &fakeAPIKeyRepository{keys: map[string]*domain.APIKey{
+		pkg.HashAPIKey(key): {ID: 12, Name: "ci", Scopes: []domain.Scope{domain.ScopeCreate}},
+	}}}
+
+	principal, err := service.Authenticate(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	want := &domain.Principal{Subject: "key:12", Name: "ci", Scopes: []domain.Scope{domain.ScopeCreate}}
+	if !reflect.DeepEqual(principal, want) {
+		t.Errorf("Authenticate() = %+v, want %+v", principal, want)
+	}
+
+	// revoked keys are not found by their hash anymore
+	for _, key := range []string{pkg.APIKeyPrefix + "unknown", "valid"} {
+		if _, err := service.Authenticate(context.Background(), key); !errors.Is(err, domain.ErrUnauthorized) {
+			t.Errorf("Authenticate(%q) error = %v, want %v", key, err, domain.ErrUnauthorized)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"time"
+)
+
+// attemptLimit is a counter of failed attempts, e.g. of an ip, and how many it allows per window.
+type attemptLimit struct {
+	key string
+	max int
+}
+
+// claimAttempt counts an attempt against every limit before the credentials are checked, so that
+// concurrent guesses can't all pass a limit before the first failure is counted. Once a limit is
+// exceeded the attempt is released again and ErrTooManyAttempts returned. Otherwise the caller
+// releases the attempt unless it failed.
+func claimAttempt(ctx context.Context, cache ports.CacheRepository, window time.Duration, limits ...attemptLimit) error {
+	exceeded := false
+	for i, limit := range limits {
+		count, err := cache.IncrAttempts(ctx, limit.key, window)
+		if err != nil {
+			releaseAttempt(ctx, cache, limits[:i]...)
+			return domain.ErrInternalServerError
+		}
+		exceeded = exceeded || count > limit.max
+	}
+
+	if exceeded {
+		releaseAttempt(ctx, cache, limits...)
+		return domain.ErrTooManyAttempts
+	}
+
+	return nil
+}
+
+// releaseAttempt takes back an attempt that didn't fail. It isn't worth failing a request that
+// succeeded for, the counter expires with its window anyway.
+func releaseAttempt(ctx context.Context, cache ports.CacheRepository, limits ...attemptLimit) {
+	for _, limit := range limits {
+		_ = cache.DecrAttempts(ctx, limit.key)
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"encoding/base64"
+	"strconv"
+	"time"
+)
+
+var (
+	DefaultAuditLimit = 50
+	MaxAuditLimit     = 500
+)
+
+type AuditService struct {
+	AuditRepository ports.AuditRepository
+	Policy          ports.Policy
+}
+
+func NewAuditService(AuditRepository ports.AuditRepository, Policy ports.Policy) ports.AuditService {
+	return &AuditService{
+		AuditRepository: AuditRepository,
+		Policy:          Policy,
+	}
+}
+
+// Authorize checks that the principal may read the entries of the filter. The entries of a
+// workspace are visible to its admins, the whole log only to the admin scope.
+func (s *AuditService) Authorize(ctx context.Context, filter domain.AuditFilter) error {
+	if filter.WorkspaceID != 0 {
+		return s.Policy.Authorize(ctx, filter.WorkspaceID, domain.ActionReadAudit)
+	}
+	if !domain.PrincipalFromContext(ctx).HasScope(domain.ScopeAdmin) {
+		return domain.ErrForbidden
+	}
+
+	return nil
+}
+
+// ListEntries returns a page of the entries matching the filter, newest first.
+func (s *AuditService) ListEntries(ctx context.Context, filter domain.AuditFilter, cursor string) (*domain.AuditPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if err := s.Authorize(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditLimit
+	}
+	if filter.Limit > MaxAuditLimit {
+		filter.Limit = MaxAuditLimit
+	}
+	if cursor != "" {
+		before, err := decodeAuditCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.Before = before
+	}
+
+	// fetch one extra row to know whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+
+	entries, err := s.AuditRepository.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.AuditPage{Items: entries}
+	if len(entries) > limit {
+		page.Items = entries[:limit]
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(page.Items[limit-1].ID)))
+	}
+
+	return page, nil
+}
+
+// ExportEntries passes every entry matching the filter to fn, the caller authorizes beforehand.
+func (s *AuditService) ExportEntries(ctx context.Context, filter domain.AuditFilter, fn func(*domain.AuditEntry) error) error {
+	if err := s.Authorize(ctx, filter); err != nil {
+		return err
+	}
+
+	return s.AuditRepository.Export(ctx, filter, fn)
+}
+
+func decodeAuditCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, domain.ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(string(raw))
+	if err != nil || id <= 0 {
+		return 0, domain.ErrInvalidCursor
+	}
+
+	return id, nil
+}
+
+// recordAudit appends an entry to the audit log. The action already happened when it is called,
+// so a failure is logged instead of failing the request.
+func recordAudit(ctx context.Context, repository ports.AuditRepository, entry *domain.AuditEntry) {
+	if err := repository.Record(ctx, entry); err != nil {
+		logger.L.Errorw("failed to record audit entry", "action", entry.Action, "target", entry.TargetID, "error", err.Error())
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAuditAuthorize(t *testing.T) {
+	tests := map[string]struct {
+		principal *domain.Principal
+		filter    domain.AuditFilter
+		policy    error
+		err       error
+	}{
+		"admin reads everything":    {&domain.Principal{Subject: "key:1", Scopes: []domain.Scope{domain.ScopeAdmin}}, domain.AuditFilter{}, nil, nil},
+		"user reads everything":     {&domain.Principal{Subject: "user:2", UserID: 2, Scopes: domain.UserScopes}, domain.AuditFilter{}, nil, domain.ErrForbidden},
+		"workspace admin":           {&domain.Principal{Subject: "user:2", UserID: 2, Scopes: domain.UserScopes}, domain.AuditFilter{WorkspaceID: 1}, nil, nil},
+		"workspace editor":          {&domain.Principal{Subject: "user:2", UserID: 2, Scopes: domain.UserScopes}, domain.AuditFilter{WorkspaceID: 1}, domain.ErrForbidden, domain.ErrForbidden},
+		"anonymous reads workspace": {&domain.Principal{}, domain.AuditFilter{WorkspaceID: 1}, domain.ErrUnauthorized, domain.ErrUnauthorized},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := &AuditService{AuditRepository: &fakeAuditRepository{}, Policy: &fakePolicy{err: test.policy}}
+			ctx := domain.WithPrincipal(context.Background(), test.principal)
+
+			if err := service.Authorize(ctx, test.filter); !errors.Is(err, test.err) {
+				t.Errorf("Authorize() error = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestListAuditEntries(t *testing.T) {
+	repository := &fakeAuditRepository{}
+	for id := 1; id <= 5; id++ {
+		repository.entries = append(repository.entries, &domain.AuditEntry{ID: id, Action: domain.AuditLinkCreate})
+	}
+	service := &AuditService{AuditRepository: repository, Policy: &fakePolicy{}}
+	ctx := domain.WithPrincipal(context.Background(), &domain.Principal{Subject: "key:1", Scopes: []domain.Scope{domain.ScopeAdmin}})
+
+	var ids []int
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		page, err := service.ListEntries(ctx, domain.AuditFilter{Limit: 2}, cursor)
+		if err != nil {
+			t.Fatalf("ListEntries() error = %v", err)
+		}
+		for _, entry := range page.Items {
+			ids = append(ids, entry.ID)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+
+	want := []int{5, 4, 3, 2, 1}
+	if len(ids) != len(want) {
+		t.Fatalf("listed %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("listed %v, want %v", ids, want)
+		}
+	}
+
+	for _, cursor := range []string{"not base64!", "MA", "LTE"} {
+		if _, err := service.ListEntries(ctx, domain.AuditFilter{}, cursor); !errors.Is(err, domain.ErrInvalidCursor) {
+			t.Errorf("ListEntries(%q) error = %v, want %v", cursor, err, domain.ErrInvalidCursor)
+		}
+	}
+}
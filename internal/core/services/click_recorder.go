@@ -0,0 +1,209 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ClickBufferSize is how many events may wait to be written, events beyond it are dropped
+	// instead of slowing down redirects
+	ClickBufferSize = 10000
+	// ClickBatchSize is how many events are written at once at most
+	ClickBatchSize = 1000
+	// ClickFlushInterval is how long an event waits at most before it is written
+	ClickFlushInterval = time.Second
+	// LiveBufferSize is how many events may wait to be published to the live streams, like
+	// ClickBufferSize events beyond it are dropped
+	LiveBufferSize = 1000
+)
+
+// ClickRecorder buffers click events and writes them in batches from a single goroutine. Another
+// goroutine publishes them to the live streams of every instance as soon as they happen.
+type ClickRecorder struct {
+	ClickRepository   ports.ClickRepository
+	CacheRepository   ports.CacheRepository
+	WebhookRepository ports.WebhookRepository
+
+	events     chan *domain.ClickEvent
+	live       chan *domain.ClickEvent
+	stop       chan struct{}
+	done       sync.WaitGroup
+	dropped    atomic.Int64
+	partitions map[string]bool
+}
+
+func NewClickRecorder(ClickRepository ports.ClickRepository, CacheRepository ports.CacheRepository, WebhookRepository ports.WebhookRepository) ports.ClickRecorder {
+	return &ClickRecorder{
+		ClickRepository:   ClickRepository,
+		CacheRepository:   CacheRepository,
+		WebhookRepository: WebhookRepository,
+		events:            make(chan *domain.ClickEvent, ClickBufferSize),
+		live:              make(chan *domain.ClickEvent, LiveBufferSize),
+		stop:              make(chan struct{}),
+		partitions:        make(map[string]bool),
+	}
+}
+
+// Record queues the event without waiting, when a buffer is full the event is dropped.
+func (r *ClickRecorder) Record(event *domain.ClickEvent) {
+	select {
+	case r.events <- event:
+	default:
+		r.dropped.Add(1)
+	}
+
+	select {
+	case r.live <- event:
+	default:
+	}
+}
+
+// Start creates the partitions of this and the next month and starts writing events.
+func (r *ClickRecorder) Start(ctx context.Context) error {
+	now := time.Now().UTC()
+	for _, month := range []time.Time{now, now.AddDate(0, 1, 0)} {
+		if err := r.ensurePartition(ctx, month); err != nil {
+			return err
+		}
+	}
+
+	r.done.Add(2)
+	go r.run()
+	go r.publish()
+
+	return nil
+}
+
+// Stop writes the events that are still buffered and waits for them until ctx is done.
+func (r *ClickRecorder) Stop(ctx context.Context) error {
+	close(r.stop)
+
+	done := make(chan struct{})
+	go func() {
+		r.done.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *ClickRecorder) run() {
+	defer r.done.Done()
+
+	ticker := time.NewTicker(ClickFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*domain.ClickEvent, 0, ClickBatchSize)
+	for {
+		select {
+		case event := <-r.events:
+			batch = append(batch, event)
+			if len(batch) >= ClickBatchSize {
+				batch = r.flush(batch)
+			}
+		case <-ticker.C:
+			batch = r.flush(batch)
+		case <-r.stop:
+			for {
+				select {
+				case event := <-r.events:
+					batch = append(batch, event)
+					if len(batch) >= ClickBatchSize {
+						batch = r.flush(batch)
+					}
+				default:
+					r.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// publish sends the events to the live streams, whatever is queued is published at once.
+func (r *ClickRecorder) publish() {
+	defer r.done.Done()
+
+	for {
+		select {
+		case event := <-r.live:
+			events := []*domain.ClickEvent{event}
+			for drained := false; !drained && len(events) < LiveBufferSize; {
+				select {
+				case event = <-r.live:
+					events = append(events, event)
+				default:
+					drained = true
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			_ = r.CacheRepository.PublishClicks(ctx, events)
+			cancel()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// flush writes the batch and returns it emptied, events that can't be written are dropped.
+func (r *ClickRecorder) flush(batch []*domain.ClickEvent) []*domain.ClickEvent {
+	if dropped := r.dropped.Swap(0); dropped > 0 {
+		logger.L.Warnw("click buffer is full, events were dropped", "dropped", dropped)
+	}
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	for _, event := range batch {
+		if err := r.ensurePartition(ctx, event.ClickedAt); err != nil {
+			logger.L.Errorw("failed to record click events", "dropped", len(batch))
+			return batch[:0]
+		}
+	}
+	if err := r.ClickRepository.Save(ctx, batch); err != nil {
+		logger.L.Errorw("failed to record click events", "dropped", len(batch))
+		return batch[:0]
+	}
+
+	// like the hits, clicks of bots only reach the webhooks when bots are counted
+	events := make([]*domain.WebhookEvent, 0, len(batch))
+	for _, event := range batch {
+		if CountBots || event.IsHuman() {
+			events = append(events, domain.NewWebhookEvent(domain.WebhookLinkClicked, event.ShortCode, domain.NewWebhookClick(event)))
+		}
+	}
+	if err := r.WebhookRepository.Enqueue(ctx, events...); err != nil {
+		logger.L.Errorw("failed to enqueue click webhooks", "events", len(events))
+	}
+
+	return batch[:0]
+}
+
+func (r *ClickRecorder) ensurePartition(ctx context.Context, month time.Time) error {
+	key := month.Format("200601")
+	if r.partitions[key] {
+		return nil
+	}
+
+	if err := r.ClickRepository.EnsurePartition(ctx, month); err != nil {
+		return err
+	}
+	r.partitions[key] = true
+
+	return nil
+}
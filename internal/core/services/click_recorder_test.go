@@ -0,0 +1,117 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestClickRecorder(repository *fakeClickRepository) *ClickRecorder {
+	return NewClickRecorder(repository, &fakeCacheRepository{}, &fakeWebhookRepository{}).(*ClickRecorder)
+}
+
+func TestClickRecorderWritesBufferedEventsOnStop(t *testing.T) {
+	repository := &fakeClickRepository{}
+	recorder := newTestClickRecorder(repository)
+	if err := recorder.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// an event of a month without a partition yet gets one before it is written
+	lastYear := domain.NewClickEvent("spring", 1, domain.Visit{})
+	lastYear.ClickedAt = lastYear.ClickedAt.AddDate(-1, 0, 0)
+	recorder.Record(domain.NewClickEvent("spring", 1, domain.Visit{Country: "DE"}))
+	recorder.Record(domain.NewClickEvent("spring", 2, domain.Visit{}))
+	recorder.Record(lastYear)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := recorder.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	if len(repository.events) != 3 {
+		t.Fatalf("wrote %d events, want 3", len(repository.events))
+	}
+	if repository.events[0].Country != "DE" || repository.events[1].URLID != 2 {
+		t.Errorf("wrote %+v, %+v, want the events in order", repository.events[0], repository.events[1])
+	}
+
+	now := time.Now().UTC()
+	want := []string{now.Format("2006-01"), now.AddDate(0, 1, 0).Format("2006-01"), lastYear.ClickedAt.Format("2006-01")}
+	if len(repository.partitions) != len(want) {
+		t.Fatalf("created partitions %v, want %v", repository.partitions, want)
+	}
+	for i := range want {
+		if repository.partitions[i] != want[i] {
+			t.Errorf("created partitions %v, want %v", repository.partitions, want)
+		}
+	}
+}
+
+func TestClickRecorderDropsEventsWhenFull(t *testing.T) {
+	defer func(size int) { ClickBufferSize = size }(ClickBufferSize)
+	ClickBufferSize = 2
+
+	repository := &fakeClickRepository{}
+	recorder := newTestClickRecorder(repository)
+
+	// nothing is written before Start, so the third event doesn't fit and Record must not block
+	for i := 0; i < 3; i++ {
+		recorder.Record(domain.NewClickEvent("spring", 1, domain.Visit{}))
+	}
+	if dropped := recorder.dropped.Load(); dropped != 1 {
+		t.Errorf("dropped %d events, want 1", dropped)
+	}
+
+	if err := recorder.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := recorder.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if len(repository.events) != 2 {
+		t.Errorf("wrote %d events, want the 2 that were buffered", len(repository.events))
+	}
+}
+
+func TestClickRecorderDropsFailedBatches(t *testing.T) {
+	repository := &fakeClickRepository{err: errors.New("connection refused")}
+	recorder := newTestClickRecorder(repository)
+
+	batch := recorder.flush([]*domain.ClickEvent{domain.NewClickEvent("spring", 1, domain.Visit{})})
+	if len(batch) != 0 {
+		t.Errorf("flush() kept %d events, want the batch dropped", len(batch))
+	}
+}
+
+func TestClickRecorderPublishesLiveClicks(t *testing.T) {
+	cache := &fakeCacheRepository{published: make(chan []*domain.ClickEvent, 10)}
+	recorder := NewClickRecorder(&fakeClickRepository{}, cache, &fakeWebhookRepository{}).(*ClickRecorder)
+	if err := recorder.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer recorder.Stop(context.Background())
+
+	recorder.Record(domain.NewClickEvent("spring", 1, domain.Visit{}))
+	recorder.Record(domain.NewClickEvent("spring", 2, domain.Visit{}))
+
+	// the clicks are published right away instead of with the next batch
+	var urlIDs []int
+	timeout := time.After(time.Second)
+	for len(urlIDs) < 2 {
+		select {
+		case events := <-cache.published:
+			for _, event := range events {
+				urlIDs = append(urlIDs, event.URLID)
+			}
+		case <-timeout:
+			t.Fatalf("published %v within a second, want both clicks", urlIDs)
+		}
+	}
+	if urlIDs[0] != 1 || urlIDs[1] != 2 {
+		t.Errorf("published %v, want the clicks in order", urlIDs)
+	}
+}
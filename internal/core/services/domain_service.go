@@ -0,0 +1,183 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/infra/workerpool"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// DomainCacheTTL is how long a resolved host stays cached, including hosts that are not registered.
+var DomainCacheTTL = 5 * time.Minute
+
+type DomainService struct {
+	DomainRepository ports.DomainRepository
+	CacheRepository  ports.CacheRepository
+	AuditRepository  ports.AuditRepository
+	Policy           ports.Policy
+}
+
+func NewDomainService(DomainRepository ports.DomainRepository, CacheRepository ports.CacheRepository, AuditRepository ports.AuditRepository, Policy ports.Policy) ports.DomainService {
+	return &DomainService{
+		DomainRepository: DomainRepository,
+		CacheRepository:  CacheRepository,
+		AuditRepository:  AuditRepository,
+		Policy:           Policy,
+	}
+}
+
+// RegisterDomain maps a host to a workspace, the host has to be pointed at this service separately.
+func (s *DomainService) RegisterDomain(ctx context.Context, customDomain *domain.Domain) (*domain.Domain, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	host, ok := domain.NormalizeHost(customDomain.Host)
+	if !ok || customDomain.WorkspaceID == 0 {
+		return nil, domain.ErrInvalidDomain
+	}
+	customDomain.Host = host
+
+	if err := s.Policy.Authorize(ctx, customDomain.WorkspaceID, domain.ActionManageDomains); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.DomainRepository.Save(ctx, customDomain)
+	if err != nil {
+		return nil, err
+	}
+
+	// the host may have been cached as unknown
+	s.invalidate(saved.Host)
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditDomainCreate, domain.AuditTargetDomain, strconv.Itoa(saved.ID), saved.WorkspaceID,
+		nil, auditDomain(saved)))
+
+	return saved, nil
+}
+
+// ListDomains returns the domains of a workspace. Without a workspace only admins get every
+// domain, the others get nothing since domains always belong to a workspace.
+func (s *DomainService) ListDomains(ctx context.Context, workspaceID int) ([]*domain.Domain, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	scope, err := s.Policy.LinkScope(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if scope.OwnerID != 0 || scope.Unowned {
+		return []*domain.Domain{}, nil
+	}
+
+	return s.DomainRepository.List(ctx, scope.WorkspaceID)
+}
+
+func (s *DomainService) UpdateDomain(ctx context.Context, id int, update domain.DomainUpdate) (*domain.Domain, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	before, err := s.authorize(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	customDomain, err := s.DomainRepository.Update(ctx, id, update)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(customDomain.Host)
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditDomainUpdate, domain.AuditTargetDomain, strconv.Itoa(id), customDomain.WorkspaceID,
+		auditDomain(before), auditDomain(customDomain)))
+
+	return customDomain, nil
+}
+
+// DeleteDomain removes a domain, domains that still have shortcodes can't be removed.
+func (s *DomainService) DeleteDomain(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	customDomain, err := s.authorize(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = s.DomainRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate(customDomain.Host)
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditDomainDelete, domain.AuditTargetDomain, strconv.Itoa(id), customDomain.WorkspaceID,
+		auditDomain(customDomain), nil))
+
+	return nil
+}
+
+// ResolveHost returns the domain registered for the host of a request. Unknown hosts are cached
+// as well, so that requests for random hosts don't reach the database.
+func (s *DomainService) ResolveHost(ctx context.Context, host string) (*domain.Domain, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	host, ok := domain.NormalizeHost(host)
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+
+	customDomain, err := s.CacheRepository.GetDomain(ctx, host)
+	if err != nil {
+		customDomain, err = s.DomainRepository.GetByHost(ctx, host)
+		if err != nil && !errors.Is(err, domain.ErrDataNotFound) {
+			return nil, err
+		}
+		if err != nil {
+			customDomain = &domain.Domain{Host: host}
+		}
+
+		_ = workerpool.Pool.Submit(func() {
+			myctx, mycancel := context.WithTimeout(context.Background(), time.Second*10)
+			defer mycancel()
+			_ = s.CacheRepository.SaveDomain(myctx, customDomain, DomainCacheTTL)
+		})
+	}
+
+	if customDomain.ID == 0 {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return customDomain, nil
+}
+
+// authorize checks that the principal may manage the domain.
+func (s *DomainService) authorize(ctx context.Context, id int) (*domain.Domain, error) {
+	customDomain, err := s.DomainRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Policy.Authorize(ctx, customDomain.WorkspaceID, domain.ActionManageDomains); err != nil {
+		return nil, err
+	}
+
+	return customDomain, nil
+}
+
+func auditDomain(customDomain *domain.Domain) map[string]any {
+	return map[string]any{
+		"host":          customDomain.Host,
+		"root_redirect": customDomain.RootRedirect,
+		"not_found_url": customDomain.NotFoundURL,
+	}
+}
+
+func (s *DomainService) invalidate(host string) {
+	_ = workerpool.Pool.Submit(func() {
+		myctx, mycancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer mycancel()
+		if err := s.CacheRepository.DeleteDomain(myctx, host); err != nil {
+			logger.L.Errorw("failed to invalidate domain cache", "host", host, "error", err.Error())
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+)
+
+type ExportService struct {
+	ShortCodeRepository ports.ShortCodeRepository
+	Policy              ports.Policy
+}
+
+func NewExportService(ShortCodeRepository ports.ShortCodeRepository, Policy ports.Policy) ports.ExportService {
+	return &ExportService{
+		ShortCodeRepository: ShortCodeRepository,
+		Policy:              Policy,
+	}
+}
+
+// Authorize checks that the principal of the context may export the links of the filter, so that
+// callers can fail before they start streaming.
+func (s *ExportService) Authorize(ctx context.Context, filter domain.ExportFilter) error {
+	_, err := s.Policy.LinkScope(ctx, filter.WorkspaceID)
+	return err
+}
+
+// ExportShortCodes exports the shortcodes the principal of the context may see.
+func (s *ExportService) ExportShortCodes(ctx context.Context, filter domain.ExportFilter, fn func(*domain.ShortCodeExport) error) error {
+	scope, err := s.Policy.LinkScope(ctx, filter.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	return s.ShortCodeRepository.Export(ctx, scope, filter, fn)
+}
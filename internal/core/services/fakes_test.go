@@ -0,0 +1,584 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeShortCodeRepository keeps the shortcodes in memory, like postgres it skips codes that are taken.
+type fakeShortCodeRepository struct {
+	ports.ShortCodeRepository
+
+	shortcodes map[string]*domain.ShortCode
+	list       []*domain.ShortCode
+	queries    []domain.LinkQuery
+	taken      map[string]bool
+	err        error
+	urls       []*domain.URL
+	hits       fakeHits[string]
+}
+
+func (r *fakeShortCodeRepository) AddHits(ctx context.Context, hits map[string]int64) error {
+	return r.hits.add(hits)
+}
+
+func (r *fakeShortCodeRepository) GetShortCode(ctx context.Context, code string) (*domain.ShortCode, error) {
+	shortcode, ok := r.shortcodes[code]
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return shortcode, nil
+}
+
+// List returns at most query.Limit shortcodes of list after the cursor.
+func (r *fakeShortCodeRepository) List(ctx context.Context, scope domain.LinkScope, query domain.LinkQuery) ([]*domain.ShortCode, error) {
+	r.queries = append(r.queries, query)
+
+	items := r.list
+	if query.After != nil {
+		for i, shortcode := range items {
+			if shortcode.ID == strconv.Itoa(query.After.ID) {
+				items = items[i+1:]
+				break
+			}
+		}
+	}
+
+	return items[:min(query.Limit, len(items))], nil
+}
+
+func (r *fakeShortCodeRepository) FindByFingerprint(ctx context.Context, owner domain.LinkScope, host, fingerprint string) (*domain.ShortCode, error) {
+	for _, shortcode := range r.shortcodes {
+		if shortcode.Fingerprint == fingerprint && shortcode.OwnerID == owner.OwnerID && shortcode.WorkspaceID == owner.WorkspaceID {
+			return shortcode, nil
+		}
+	}
+
+	return nil, domain.ErrDataNotFound
+}
+
+func (r *fakeShortCodeRepository) Save(ctx context.Context, shortcode *domain.ShortCode, urls []*domain.URL) (*domain.ShortCode, []*domain.URL, error) {
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	if r.taken[shortcode.Code] {
+		return nil, nil, domain.ErrCodeAlreadyExists
+	}
+
+	shortcode.ID = "1"
+	for i, url := range urls {
+		url.ID = i + 1
+	}
+	r.urls = urls
+
+	return shortcode, urls, nil
+}
+
+func (r *fakeShortCodeRepository) SaveBatch(ctx context.Context, shortcodes []*domain.ShortCode, urls []*domain.URL) ([]*domain.ShortCode, []*domain.URL, error) {
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+
+	var saved []*domain.ShortCode
+	for _, shortcode := range shortcodes {
+		if !r.taken[shortcode.Code] {
+			saved = append(saved, shortcode)
+		}
+	}
+	var links []*domain.URL
+	for i, url := range urls {
+		if !r.taken[url.ShortCode] {
+			url.ID = i + 1
+			links = append(links, url)
+		}
+	}
+	r.urls = links
+
+	return saved, links, nil
+}
+
+type fakeCacheRepository struct {
+	ports.CacheRepository
+
+	deleted []string
+	// mu guards attempts, which are counted by concurrent requests
+	mu        sync.Mutex
+	attempts  map[string]int
+	sketches  []*domain.VisitorSketch
+	merged    int
+	published chan []*domain.ClickEvent
+	err       error
+}
+
+// fakeSketch stands in for a HyperLogLog, it lists the visitors so that merging and counting are exact.
+func fakeSketch(visitors ...string) []byte {
+	return []byte(strings.Join(visitors, ","))
+}
+
+func sketchVisitors(sketches ...*domain.VisitorSketch) map[string]bool {
+	visitors := make(map[string]bool)
+	for _, sketch := range sketches {
+		for _, visitor := range strings.Split(string(sketch.Sketch), ",") {
+			visitors[visitor] = true
+		}
+	}
+
+	return visitors
+}
+
+func (r *fakeCacheRepository) CountVisitors(ctx context.Context, sketches []*domain.VisitorSketch) (int64, error) {
+	return int64(len(sketchVisitors(sketches...))), nil
+}
+
+func (r *fakeCacheRepository) GetVisitorSketches(ctx context.Context, day time.Time) ([]*domain.VisitorSketch, error) {
+	return r.sketches, nil
+}
+
+func (r *fakeCacheRepository) MergeVisitorSketches(ctx context.Context, sketches []*domain.VisitorSketch) error {
+	for _, sketch := range sketches {
+		for _, live := range r.sketches {
+			if live.ShortCode == sketch.ShortCode && live.URLID == sketch.URLID {
+				visitors := make([]string, 0)
+				for visitor := range sketchVisitors(live, sketch) {
+					visitors = append(visitors, visitor)
+				}
+				live.Sketch, live.Visitors = fakeSketch(visitors...), int64(len(visitors))
+			}
+		}
+		r.merged++
+	}
+
+	return nil
+}
+
+func (r *fakeCacheRepository) IncrAttempts(ctx context.Context, key string, window time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.attempts == nil {
+		r.attempts = make(map[string]int)
+	}
+	r.attempts[key]++
+	return r.attempts[key], nil
+}
+
+func (r *fakeCacheRepository) DecrAttempts(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts[key]--
+	return nil
+}
+
+// PublishClicks is called from the goroutine of the click recorder, the events are sent to published.
+func (r *fakeCacheRepository) PublishClicks(ctx context.Context, events []*domain.ClickEvent) error {
+	if r.published != nil {
+		r.published <- events
+	}
+	return nil
+}
+
+func (r *fakeCacheRepository) DeleteLinks(ctx context.Context, code string) error {
+	r.deleted = append(r.deleted, code)
+	return r.err
+}
+
+func (r *fakeCacheRepository) SaveShortCode(ctx context.Context, shortcode *domain.ShortCode) error {
+	return nil
+}
+
+func (r *fakeCacheRepository) SaveLinks(ctx context.Context, links []*domain.URL) error {
+	return nil
+}
+
+// GetShortCode and GetLinks always miss, so the services fall back to the repositories.
+func (r *fakeCacheRepository) GetShortCode(ctx context.Context, code string) (*domain.ShortCode, error) {
+	return nil, domain.ErrDataNotFound
+}
+
+func (r *fakeCacheRepository) GetLinks(ctx context.Context, code string) ([]*domain.URL, error) {
+	return nil, domain.ErrDataNotFound
+}
+
+func (r *fakeCacheRepository) IncrHits(ctx context.Context, code, id string) error {
+	return nil
+}
+
+func (r *fakeCacheRepository) AddVisitor(ctx context.Context, code string, urlID int, visitor string, day time.Time) error {
+	return nil
+}
+
+type fakeURLRepository struct {
+	ports.URLRepository
+
+	links map[string][]*domain.URL
+	hits  fakeHits[int]
+}
+
+func (r *fakeURLRepository) AddHits(ctx context.Context, hits map[int]int64) error {
+	return r.hits.add(hits)
+}
+
+func (r *fakeURLRepository) GetLinks(ctx context.Context, code string) ([]*domain.URL, error) {
+	links, ok := r.links[code]
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return links, nil
+}
+
+func (r *fakeURLRepository) SetEnabled(ctx context.Context, code string, id int, enabled bool) (*domain.URL, error) {
+	for _, link := range r.links[code] {
+		if link.ID == id {
+			link.Enabled = enabled
+			return link, nil
+		}
+	}
+
+	return nil, domain.ErrDataNotFound
+}
+
+// fakeHits sums up the hits written by the hit counter. A write takes delay like a round trip to
+// the database, with err set it fails and writes nothing like the transaction of postgres.
+type fakeHits[K comparable] struct {
+	mu     sync.Mutex
+	totals map[K]int64
+	writes int
+	delay  time.Duration
+	err    error
+}
+
+func (h *fakeHits[K]) add(hits map[K]int64) error {
+	time.Sleep(h.delay)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writes++
+	if h.err != nil {
+		return h.err
+	}
+	if h.totals == nil {
+		h.totals = make(map[K]int64)
+	}
+	for key, count := range hits {
+		h.totals[key] += count
+	}
+
+	return nil
+}
+
+// fakePolicy allows everything unless err is set, LinkScope returns scope. Authorize refuses the
+// workspaces of denied with their error.
+type fakePolicy struct {
+	scope  domain.LinkScope
+	denied map[int]error
+	err    error
+}
+
+func (p *fakePolicy) Authorize(ctx context.Context, workspaceID int, action domain.Action) error {
+	if err, ok := p.denied[workspaceID]; ok {
+		return err
+	}
+	return p.err
+}
+
+func (p *fakePolicy) AuthorizeLink(ctx context.Context, shortcode *domain.ShortCode, action domain.Action) error {
+	return p.err
+}
+
+func (p *fakePolicy) LinkScope(ctx context.Context, workspaceID int) (domain.LinkScope, error) {
+	return p.scope, p.err
+}
+
+type fakeAuditRepository struct {
+	ports.AuditRepository
+
+	mu      sync.Mutex
+	entries []*domain.AuditEntry
+}
+
+func (r *fakeAuditRepository) Record(ctx context.Context, entries ...*domain.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, entries...)
+	return nil
+}
+
+// List returns the recorded entries newest first, it only honours the Before and Limit of the filter.
+func (r *fakeAuditRepository) List(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	var entries []*domain.AuditEntry
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+		if filter.Before == 0 || r.entries[i].ID < filter.Before {
+			entries = append(entries, r.entries[i])
+		}
+	}
+
+	return entries, nil
+}
+
+// fakeWebhookRepository is used by the concurrent sends of the dispatcher, the deliveries are
+// guarded by mu. ClaimDeliveries ignores NextAttemptAt and claims copies of every pending delivery,
+// like postgres CompleteDelivery only stores the outcome of the latest claim.
+type fakeWebhookRepository struct {
+	ports.WebhookRepository
+
+	events     []*domain.WebhookEvent
+	webhooks   map[int]*domain.Webhook
+	mu         sync.Mutex
+	deliveries []*domain.WebhookDelivery
+	completed  int
+	// completeErr is the error of the ctx CompleteDelivery was called with
+	completeErr error
+}
+
+func (r *fakeWebhookRepository) Enqueue(ctx context.Context, events ...*domain.WebhookEvent) error {
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func (r *fakeWebhookRepository) Save(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+	if r.webhooks == nil {
+		r.webhooks = make(map[int]*domain.Webhook)
+	}
+	webhook.ID = len(r.webhooks) + 1
+	r.webhooks[webhook.ID] = webhook
+
+	return webhook, nil
+}
+
+func (r *fakeWebhookRepository) GetByID(ctx context.Context, id int) (*domain.Webhook, error) {
+	if webhook, ok := r.webhooks[id]; ok {
+		return webhook, nil
+	}
+	return nil, domain.ErrDataNotFound
+}
+
+func (r *fakeWebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var claimed []*domain.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == domain.DeliveryPending && len(claimed) < limit {
+			delivery.Attempts++
+			claim := *delivery
+			claimed = append(claimed, &claim)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (r *fakeWebhookRepository) CompleteDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.completed++
+	r.completeErr = ctx.Err()
+	for _, stored := range r.deliveries {
+		if stored.ID == delivery.ID && stored.Attempts == delivery.Attempts {
+			*stored = *delivery
+			return nil
+		}
+	}
+
+	return domain.ErrDataNotFound
+}
+
+// fakeWebhookSender answers the sends with statuses in turn and 200 once they are used up, a
+// status outside 2xx fails the send. With wait set it blocks until the ctx of the send is done.
+type fakeWebhookSender struct {
+	mu       sync.Mutex
+	statuses []int
+	sent     []string
+	wait     bool
+}
+
+func (s *fakeWebhookSender) Send(ctx context.Context, delivery *domain.WebhookDelivery) (int, error) {
+	if s.wait {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, delivery.DeliveryID)
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	if status < 200 || status > 299 {
+		return status, fmt.Errorf("unexpected status %d", status)
+	}
+
+	return status, nil
+}
+
+type fakeAPIKeyRepository struct {
+	ports.APIKeyRepository
+
+	keys   map[string]*domain.APIKey
+	saved  *domain.APIKey
+	hashes []string
+}
+
+func (r *fakeAPIKeyRepository) Save(ctx context.Context, key *domain.APIKey, hash string) (*domain.APIKey, error) {
+	key.ID = len(r.hashes) + 1
+	r.saved = key
+	r.hashes = append(r.hashes, hash)
+	return key, nil
+}
+
+func (r *fakeAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	key, ok := r.keys[hash]
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return key, nil
+}
+
+func (r *fakeAPIKeyRepository) TouchLastUsed(ctx context.Context, id int) error {
+	return nil
+}
+
+type fakeUserRepository struct {
+	ports.UserRepository
+
+	users map[string]*domain.User
+}
+
+func (r *fakeUserRepository) Save(ctx context.Context, user *domain.User) (*domain.User, error) {
+	if _, ok := r.users[user.Email]; ok {
+		return nil, domain.ErrEmailAlreadyExists
+	}
+	user.ID = len(r.users) + 1
+	r.users[user.Email] = user
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	user, ok := r.users[email]
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return user, nil
+}
+
+type fakeDomainRepository struct {
+	ports.DomainRepository
+
+	domains map[string]*domain.Domain
+}
+
+func (r *fakeDomainRepository) GetByHost(ctx context.Context, host string) (*domain.Domain, error) {
+	customDomain, ok := r.domains[host]
+	if !ok {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return customDomain, nil
+}
+
+// fakeClickRepository keeps the saved click events and the months partitions were created for.
+// The series and top values it returns are fixed, the rollups and series it was asked for are
+// recorded as "hourly|daily from to".
+type fakeClickRepository struct {
+	ports.ClickRepository
+
+	events     []*domain.ClickEvent
+	partitions []string
+	counts     []domain.ClickCount
+	top        map[string][]domain.DimensionCount
+	sketches   []*domain.VisitorSketch
+	saved      [][]*domain.VisitorSketch
+	calls      []string
+	err        error
+}
+
+func (r *fakeClickRepository) record(call string, from, to time.Time) {
+	r.calls = append(r.calls, call+" "+from.Format(time.RFC3339)+" "+to.Format(time.RFC3339))
+}
+
+func (r *fakeClickRepository) Series(ctx context.Context, query domain.AnalyticsQuery) ([]domain.ClickCount, error) {
+	r.record("hourly", query.From, query.To)
+	return r.counts, nil
+}
+
+func (r *fakeClickRepository) DailySeries(ctx context.Context, query domain.AnalyticsQuery) ([]domain.ClickCount, error) {
+	r.record("daily", query.From, query.To)
+	return r.counts, nil
+}
+
+func (r *fakeClickRepository) TopValues(ctx context.Context, code, dimension string, from, to time.Time, limit int) ([]domain.DimensionCount, error) {
+	return r.top[dimension], nil
+}
+
+func (r *fakeClickRepository) RollupHourly(ctx context.Context, from, to time.Time, countBots bool) error {
+	r.record("hourly", from, to)
+	return nil
+}
+
+func (r *fakeClickRepository) RollupDaily(ctx context.Context, from, to time.Time, loc *time.Location, countBots bool) error {
+	r.record("daily", from, to)
+	return nil
+}
+
+func (r *fakeClickRepository) GetVisitorSketches(ctx context.Context, code string, from, to time.Time) ([]*domain.VisitorSketch, error) {
+	return r.sketches, nil
+}
+
+func (r *fakeClickRepository) GetDaySketches(ctx context.Context, day time.Time) ([]*domain.VisitorSketch, error) {
+	return r.sketches, nil
+}
+
+func (r *fakeClickRepository) SaveVisitorSketches(ctx context.Context, sketches []*domain.VisitorSketch) error {
+	r.saved = append(r.saved, sketches)
+	return nil
+}
+
+func (r *fakeClickRepository) Save(ctx context.Context, events []*domain.ClickEvent) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func (r *fakeClickRepository) EnsurePartition(ctx context.Context, month time.Time) error {
+	r.partitions = append(r.partitions, month.Format("2006-01"))
+	return nil
+}
+
+type fakeClickRecorder struct {
+	ports.ClickRecorder
+
+	events []*domain.ClickEvent
+}
+
+func (r *fakeClickRecorder) Record(event *domain.ClickEvent) {
+	r.events = append(r.events, event)
+}
+
+type fakeHitCounter struct {
+	ports.HitCounter
+
+	hits []int
+}
+
+func (c *fakeHitCounter) Record(code string, urlID int) {
+	c.hits = append(c.hits, urlID)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"sync"
+	"time"
+)
+
+// HitFlushInterval is how long hits are added up in memory before they are written. Hits of an
+// instance that crashes within the interval are lost, a graceful stop writes them first.
+var HitFlushInterval = 2 * time.Second
+
+// HitCounter adds up the hits of shortcodes and destinations in memory, so that a busy link costs
+// one row update per flush instead of two transactions per redirect. The hits are written with a
+// batched update for each table. The updates of a table are written in one transaction, hits that
+// can't be written are kept for the next flush.
+type HitCounter struct {
+	URLRepository       ports.URLRepository
+	ShortCodeRepository ports.ShortCodeRepository
+
+	mu         sync.Mutex
+	links      map[int]int64
+	shortcodes map[string]int64
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func NewHitCounter(URLRepository ports.URLRepository, ShortCodeRepository ports.ShortCodeRepository) ports.HitCounter {
+	return &HitCounter{
+		URLRepository:       URLRepository,
+		ShortCodeRepository: ShortCodeRepository,
+		links:               make(map[int]int64),
+		shortcodes:          make(map[string]int64),
+		stop:                make(chan struct{}),
+		done:                make(chan struct{}),
+	}
+}
+
+// Record counts a hit of the destination of the shortcode with the given key.
+func (c *HitCounter) Record(code string, urlID int) {
+	c.mu.Lock()
+	c.links[urlID]++
+	c.shortcodes[code]++
+	c.mu.Unlock()
+}
+
+func (c *HitCounter) Start(ctx context.Context) error {
+	go c.run()
+
+	return nil
+}
+
+// Stop writes the hits that are still in memory and waits for them until ctx is done.
+func (c *HitCounter) Stop(ctx context.Context) error {
+	close(c.stop)
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *HitCounter) run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(HitFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.flush()
+		case <-c.stop:
+			c.flush()
+			return
+		}
+	}
+}
+
+// flush swaps the counters for empty ones and writes them, redirects keep counting meanwhile.
+func (c *HitCounter) flush() {
+	c.mu.Lock()
+	links, shortcodes := c.links, c.shortcodes
+	if len(links) == 0 && len(shortcodes) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	c.links, c.shortcodes = make(map[int]int64, len(links)), make(map[string]int64, len(shortcodes))
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	if err := c.URLRepository.AddHits(ctx, links); err != nil {
+		logger.L.Errorw("failed to write destination hits, retrying with the next flush", "destinations", len(links), "error", err.Error())
+		c.restore(links, nil)
+	}
+	if err := c.ShortCodeRepository.AddHits(ctx, shortcodes); err != nil {
+		logger.L.Errorw("failed to write shortcode hits, retrying with the next flush", "shortcodes", len(shortcodes), "error", err.Error())
+		c.restore(nil, shortcodes)
+	}
+}
+
+// restore adds hits that couldn't be written back to the counters.
+func (c *HitCounter) restore(links map[int]int64, shortcodes map[string]int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, hits := range links {
+		c.links[id] += hits
+	}
+	for code, hits := range shortcodes {
+		c.shortcodes[code] += hits
+	}
+}
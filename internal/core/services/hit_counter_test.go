@@ -0,0 +1,135 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHitCounterFlush(t *testing.T) {
+	urls, shortcodes := &fakeURLRepository{}, &fakeShortCodeRepository{}
+	counter := NewHitCounter(urls, shortcodes).(*HitCounter)
+
+	counter.Record("spring", 1)
+	counter.Record("spring", 1)
+	counter.Record("spring", 2)
+	counter.Record("example.com/summer", 3)
+	counter.flush()
+
+	if urls.hits.totals[1] != 2 || urls.hits.totals[2] != 1 || urls.hits.totals[3] != 1 {
+		t.Errorf("destination hits = %v, want 2, 1 and 1", urls.hits.totals)
+	}
+	if shortcodes.hits.totals["spring"] != 3 || shortcodes.hits.totals["example.com/summer"] != 1 {
+		t.Errorf("shortcode hits = %v, want 3 and 1", shortcodes.hits.totals)
+	}
+	if urls.hits.writes != 1 || shortcodes.hits.writes != 1 {
+		t.Errorf("wrote %d and %d times, want one batch per table", urls.hits.writes, shortcodes.hits.writes)
+	}
+
+	// nothing is written without new hits
+	counter.flush()
+	if urls.hits.writes != 1 || shortcodes.hits.writes != 1 {
+		t.Errorf("flush() without hits wrote to the database")
+	}
+}
+
+func TestHitCounterRestoresFailedHits(t *testing.T) {
+	urls, shortcodes := &fakeURLRepository{}, &fakeShortCodeRepository{}
+	urls.hits.err = domain.ErrInternalServerError
+	counter := NewHitCounter(urls, shortcodes).(*HitCounter)
+
+	counter.Record("spring", 1)
+	counter.Record("spring", 2)
+	counter.flush()
+
+	// the shortcode hits were written, only the destination hits are kept for the next flush
+	if len(counter.links) != 2 || len(counter.shortcodes) != 0 {
+		t.Fatalf("kept %v and %v, want only the destination hits", counter.links, counter.shortcodes)
+	}
+
+	urls.hits.err = nil
+	counter.Record("spring", 1)
+	counter.flush()
+
+	if urls.hits.totals[1] != 2 || urls.hits.totals[2] != 1 {
+		t.Errorf("destination hits = %v, want the failed hits written once", urls.hits.totals)
+	}
+	if shortcodes.hits.totals["spring"] != 3 {
+		t.Errorf("shortcode hits = %v, want 3", shortcodes.hits.totals)
+	}
+}
+
+func TestHitCounterFlushesOnStop(t *testing.T) {
+	defer func(interval time.Duration) { HitFlushInterval = interval }(HitFlushInterval)
+	HitFlushInterval = time.Hour
+
+	urls, shortcodes := &fakeURLRepository{}, &fakeShortCodeRepository{}
+	counter := NewHitCounter(urls, shortcodes)
+	if err := counter.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	counter.Record("spring", 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := counter.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if urls.hits.totals[1] != 1 || shortcodes.hits.totals["spring"] != 1 {
+		t.Errorf("Stop() wrote %v and %v, want the hit", urls.hits.totals, shortcodes.hits.totals)
+	}
+}
+
+// hitWriteDelay stands in for the round trip of a transaction in the benchmarks.
+const hitWriteDelay = 200 * time.Microsecond
+
+var benchmarkLinks = func() []string {
+	codes := make([]string, 100)
+	for i := range codes {
+		codes[i] = "code" + strconv.Itoa(i)
+	}
+	return codes
+}()
+
+// BenchmarkHitCounter counts the hits in memory and writes them with the final flush.
+func BenchmarkHitCounter(b *testing.B) {
+	urls := &fakeURLRepository{hits: fakeHits[int]{delay: hitWriteDelay}}
+	shortcodes := &fakeShortCodeRepository{hits: fakeHits[string]{delay: hitWriteDelay}}
+	counter := NewHitCounter(urls, shortcodes)
+	if err := counter.Start(context.Background()); err != nil {
+		b.Fatalf("Start() error = %v", err)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			counter.Record(benchmarkLinks[i%len(benchmarkLinks)], i%len(benchmarkLinks))
+		}
+	})
+	if err := counter.Stop(context.Background()); err != nil {
+		b.Fatalf("Stop() error = %v", err)
+	}
+}
+
+// BenchmarkPerHitTx writes every hit itself with two transactions, like redirects did before the
+// hit counter.
+func BenchmarkPerHitTx(b *testing.B) {
+	urls := &fakeURLRepository{hits: fakeHits[int]{delay: hitWriteDelay}}
+	shortcodes := &fakeShortCodeRepository{hits: fakeHits[string]{delay: hitWriteDelay}}
+	ctx := context.Background()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			code, id := benchmarkLinks[i%len(benchmarkLinks)], i%len(benchmarkLinks)
+			if err := urls.AddHits(ctx, map[int]int64{id: 1}); err != nil {
+				b.Fatalf("AddHits() error = %v", err)
+			}
+			if err := shortcodes.AddHits(ctx, map[string]int64{code: 1}); err != nil {
+				b.Fatalf("AddHits() error = %v", err)
+			}
+		}
+	})
+}
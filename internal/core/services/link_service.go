@@ -0,0 +1,346 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+	MaxTagsPerLink   = 20
+	tagPattern       = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+)
+
+type LinkService struct {
+	ShortCodeRepository ports.ShortCodeRepository
+	URLRepository       ports.URLRepository
+	TagRepository       ports.TagRepository
+	RevisionRepository  ports.RevisionRepository
+	CacheRepository     ports.CacheRepository
+	Policy              ports.Policy
+}
+
+func NewLinkService(
+	ShortCodeRepository ports.ShortCodeRepository,
+	URLRepository ports.URLRepository,
+	TagRepository ports.TagRepository,
+	RevisionRepository ports.RevisionRepository,
+	CacheRepository ports.CacheRepository,
+	Policy ports.Policy,
+) ports.LinkService {
+	return &LinkService{
+		ShortCodeRepository: ShortCodeRepository,
+		URLRepository:       URLRepository,
+		TagRepository:       TagRepository,
+		RevisionRepository:  RevisionRepository,
+		CacheRepository:     CacheRepository,
+		Policy:              Policy,
+	}
+}
+
+func (s *LinkService) ListLinks(ctx context.Context, query domain.LinkQuery, cursor string) (*domain.LinkPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if query.Sort != domain.SortByHits {
+		query.Sort = domain.SortByCreatedAt
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultListLimit
+	}
+	if query.Limit > MaxListLimit {
+		query.Limit = MaxListLimit
+	}
+	query.Tag = strings.ToLower(strings.TrimSpace(query.Tag))
+
+	if cursor != "" {
+		after, err := decodeLinkCursor(cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
+	}
+
+	// fetch one extra row to know whether there is a next page
+	limit := query.Limit
+	query.Limit++
+
+	scope, err := s.Policy.LinkScope(ctx, query.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*domain.ShortCode
+	if strings.TrimSpace(query.Search) != "" {
+		items, err = s.ShortCodeRepository.Search(ctx, scope, query.Search, query)
+	} else {
+		items, err = s.ShortCodeRepository.List(ctx, scope, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.LinkPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = encodeLinkCursor(page.Items[limit-1], query.Sort)
+	}
+
+	return page, nil
+}
+
+func (s *LinkService) SetTags(ctx context.Context, code string, tags []string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.authorize(ctx, code, domain.ActionManageLinks); err != nil {
+		return nil, err
+	}
+
+	return s.TagRepository.SetTags(ctx, code, normalized)
+}
+
+func (s *LinkService) GetDestinations(ctx context.Context, code string) ([]*domain.URL, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if _, err := s.authorize(ctx, code, domain.ActionReadLinks); err != nil {
+		return nil, err
+	}
+
+	links, err := s.URLRepository.GetLinks(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].ID < links[j].ID
+	})
+
+	return links, nil
+}
+
+// SetDestinationEnabled includes or excludes a destination from rotation without touching its hit counts.
+// The cached destinations are dropped so that every instance picks up the change on the next redirect.
+func (s *LinkService) SetDestinationEnabled(ctx context.Context, code string, id int, enabled bool) (*domain.URL, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if _, err := s.authorize(ctx, code, domain.ActionManageLinks); err != nil {
+		return nil, err
+	}
+
+	link, err := s.URLRepository.SetEnabled(ctx, code, id, enabled)
+	if err != nil {
+		return nil, err
+	}
+
+	// the change is already stored, toggling again is safe, so a failed invalidation is reported
+	// to the caller instead of leaving the old state in rotation until the cache expires
+	if err = s.CacheRepository.DeleteLinks(ctx, code); err != nil {
+		logger.L.Errorw("failed to invalidate links cache", "shortcode", code, "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+
+	return link, nil
+}
+
+func (s *LinkService) UpdateLink(ctx context.Context, code string, request domain.UpdateLinkRequest) (*domain.ShortCode, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	update := domain.LinkUpdate{URLs: request.URLs, Social: request.Social}
+	if request.Redirect != nil {
+		redirect, err := resolveRedirect(*request.Redirect)
+		if err != nil {
+			return nil, err
+		}
+		update.Redirect = &redirect
+	}
+	if request.Strategy != nil {
+		strategy, ok := domain.ParseStrategy(*request.Strategy)
+		if !ok {
+			return nil, domain.ErrInvalidStrategy
+		}
+		update.Strategy = &strategy
+	}
+	if request.Password != nil {
+		hash := ""
+		if *request.Password != "" {
+			var err error
+			if hash, err = hashPassword(*request.Password); err != nil {
+				return nil, err
+			}
+		}
+		update.PasswordHash = &hash
+	}
+
+	if _, err := s.authorize(ctx, code, domain.ActionManageLinks); err != nil {
+		return nil, err
+	}
+
+	shortcode, err := s.ShortCodeRepository.Update(ctx, code, update)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.refreshCache(ctx, code); err != nil {
+		return nil, err
+	}
+
+	return shortcode, nil
+}
+
+func (s *LinkService) ListRevisions(ctx context.Context, code string) ([]*domain.Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if _, err := s.authorize(ctx, code, domain.ActionReadLinks); err != nil {
+		return nil, err
+	}
+
+	return s.RevisionRepository.List(ctx, code)
+}
+
+// RollbackLink restores an earlier revision and reloads the shortcode into the cache, so that
+// redirects use the restored destinations right away.
+func (s *LinkService) RollbackLink(ctx context.Context, code string, revision int) (*domain.Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if _, err := s.authorize(ctx, code, domain.ActionManageLinks); err != nil {
+		return nil, err
+	}
+
+	result, err := s.RevisionRepository.Rollback(ctx, code, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.refreshCache(ctx, code); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// authorize returns the shortcode if the principal of the context may perform the action on it.
+func (s *LinkService) authorize(ctx context.Context, code string, action domain.Action) (*domain.ShortCode, error) {
+	shortcode, err := s.ShortCodeRepository.GetShortCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Policy.AuthorizeLink(ctx, shortcode, action); err != nil {
+		return nil, err
+	}
+
+	return shortcode, nil
+}
+
+// refreshCache replaces the cached shortcode and destinations with the current state in postgres.
+func (s *LinkService) refreshCache(ctx context.Context, code string) error {
+	if err := s.CacheRepository.DeleteShortCode(ctx, code); err != nil {
+		return domain.ErrInternalServerError
+	}
+	if err := s.CacheRepository.DeleteLinks(ctx, code); err != nil {
+		return domain.ErrInternalServerError
+	}
+
+	shortcode, err := s.ShortCodeRepository.GetShortCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	links, err := s.URLRepository.GetLinks(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	// a failed refill is only a cache miss, the next redirect loads from postgres
+	if err = s.CacheRepository.SaveShortCode(ctx, shortcode); err != nil {
+		logger.L.Errorw("failed to save cache", "error", err.Error())
+	}
+	if len(links) > 0 {
+		if err = s.CacheRepository.SaveLinks(ctx, links); err != nil {
+			logger.L.Errorw("failed to save cache", "error", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func normalizeTags(tags []string) ([]string, error) {
+	unique := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return nil, domain.ErrInvalidTag
+		}
+		unique[tag] = true
+	}
+	if len(unique) > MaxTagsPerLink {
+		return nil, domain.ErrInvalidTag
+	}
+
+	normalized := make([]string, 0, len(unique))
+	for tag := range unique {
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+
+	return normalized, nil
+}
+
+// encodeLinkCursor builds an opaque cursor of the form "<sort>:<value>:<id>".
+func encodeLinkCursor(shortcode *domain.ShortCode, by domain.LinkSort) string {
+	value := strconv.FormatInt(shortcode.CreatedAt.UnixMicro(), 10)
+	if by == domain.SortByHits {
+		value = strconv.Itoa(shortcode.TotalHit)
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s:%s", by, value, shortcode.ID)))
+}
+
+func decodeLinkCursor(cursor string, by domain.LinkSort) (*domain.LinkCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || domain.LinkSort(parts[0]) != by {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	value, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	after := &domain.LinkCursor{ID: id}
+	if by == domain.SortByHits {
+		after.TotalHit = int(value)
+	} else {
+		after.CreatedAt = time.UnixMicro(value).UTC()
+	}
+
+	return after, nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLinkCursorRoundTrip(t *testing.T) {
+	shortcode := &domain.ShortCode{ID: "42", TotalHit: 7, CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123000, time.UTC)}
+
+	after, err := decodeLinkCursor(encodeLinkCursor(shortcode, domain.SortByCreatedAt), domain.SortByCreatedAt)
+	if err != nil {
+		t.Fatalf("decodeLinkCursor() error = %v", err)
+	}
+	if after.ID != 42 || !after.CreatedAt.Equal(shortcode.CreatedAt) {
+		t.Errorf("decodeLinkCursor() = %+v, want id 42 created at %s", after, shortcode.CreatedAt)
+	}
+
+	after, err = decodeLinkCursor(encodeLinkCursor(shortcode, domain.SortByHits), domain.SortByHits)
+	if err != nil {
+		t.Fatalf("decodeLinkCursor() error = %v", err)
+	}
+	if after.ID != 42 || after.TotalHit != 7 {
+		t.Errorf("decodeLinkCursor() = %+v, want id 42 with 7 hits", after)
+	}
+}
+
+func TestDecodeLinkCursorRejectsInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := map[string]string{
+		"not base64":     "!!!",
+		"other sort":     encode("hits:7:42"),
+		"missing parts":  encode("created_at:42"),
+		"invalid value":  encode("created_at:abc:42"),
+		"invalid id":     encode("created_at:1714566600000000:abc"),
+		"too many parts": encode("created_at:1:2:3"),
+	}
+	for name, cursor := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeLinkCursor(cursor, domain.SortByCreatedAt); !errors.Is(err, domain.ErrInvalidCursor) {
+				t.Errorf("decodeLinkCursor(%q) error = %v, want %v", cursor, err, domain.ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{" Sale ", "sale", "spring-2024", "a_b"})
+	if err != nil {
+		t.Fatalf("normalizeTags() error = %v", err)
+	}
+	if want := []string{"a_b", "sale", "spring-2024"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("normalizeTags() = %v, want %v", tags, want)
+	}
+
+	tooMany := make([]string, MaxTagsPerLink+1)
+	for i := range tooMany {
+		tooMany[i] = "tag" + strconv.Itoa(i)
+	}
+	for name, tags := range map[string][]string{
+		"empty":      {""},
+		"space":      {"two words"},
+		"leading -":  {"-sale"},
+		"too long":   {"a1234567890123456789012345678901234567890123456789012345678901234"},
+		"too many":   tooMany,
+		"punctuated": {"sale!"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := normalizeTags(tags); !errors.Is(err, domain.ErrInvalidTag) {
+				t.Errorf("normalizeTags(%v) error = %v, want %v", tags, err, domain.ErrInvalidTag)
+			}
+		})
+	}
+}
+
+func TestListLinksPaginates(t *testing.T) {
+	repository := &fakeShortCodeRepository{}
+	for i := 1; i <= 5; i++ {
+		repository.list = append(repository.list, &domain.ShortCode{ID: strconv.Itoa(i), Code: "code" + strconv.Itoa(i)})
+	}
+	service := &LinkService{ShortCodeRepository: repository, Policy: &fakePolicy{}}
+
+	var codes []string
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		page, err := service.ListLinks(context.Background(), domain.LinkQuery{Limit: 2}, cursor)
+		if err != nil {
+			t.Fatalf("ListLinks() error = %v", err)
+		}
+		for _, shortcode := range page.Items {
+			codes = append(codes, shortcode.Code)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+
+	if want := []string{"code1", "code2", "code3", "code4", "code5"}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("listed %v, want %v", codes, want)
+	}
+	if query := repository.queries[0]; query.Limit != 3 || query.Sort != domain.SortByCreatedAt {
+		t.Errorf("first query = %+v, want one extra row sorted by created_at", query)
+	}
+}
+
+func TestListLinksClampsLimit(t *testing.T) {
+	repository := &fakeShortCodeRepository{}
+	service := &LinkService{ShortCodeRepository: repository, Policy: &fakePolicy{}}
+
+	for limit, want := range map[int]int{0: DefaultListLimit + 1, -1: DefaultListLimit + 1, 1000: MaxListLimit + 1} {
+		repository.queries = nil
+		if _, err := service.ListLinks(context.Background(), domain.LinkQuery{Limit: limit}, ""); err != nil {
+			t.Fatalf("ListLinks() error = %v", err)
+		}
+		if got := repository.queries[0].Limit; got != want {
+			t.Errorf("limit %d queried %d rows, want %d", limit, got, want)
+		}
+	}
+}
+
+func TestSetDestinationEnabledInvalidatesCache(t *testing.T) {
+	links := &fakeURLRepository{links: map[string][]*domain.URL{
+		"abc": {{ID: 1, ShortCode: "abc", Enabled: true}, {ID: 2, ShortCode: "abc", Enabled: true}},
+	}}
+	cache := &fakeCacheRepository{}
+	service := &LinkService{
+		ShortCodeRepository: &fakeShortCodeRepository{shortcodes: map[string]*domain.ShortCode{"abc": {Code: "abc"}}},
+		URLRepository:       links,
+		CacheRepository:     cache,
+		Policy:              &fakePolicy{},
+	}
+
+	link, err := service.SetDestinationEnabled(context.Background(), "abc", 2, false)
+	if err != nil {
+		t.Fatalf("SetDestinationEnabled() error = %v", err)
+	}
+	if link.ID != 2 || link.Enabled {
+		t.Errorf("SetDestinationEnabled() = %+v, want destination 2 disabled", link)
+	}
+	if !reflect.DeepEqual(cache.deleted, []string{"abc"}) {
+		t.Errorf("invalidated %v, want the links of abc", cache.deleted)
+	}
+
+	cache.err = errors.New("redis is down")
+	if _, err = service.SetDestinationEnabled(context.Background(), "abc", 2, true); !errors.Is(err, domain.ErrInternalServerError) {
+		t.Errorf("SetDestinationEnabled() with a failed invalidation error = %v, want %v", err, domain.ErrInternalServerError)
+	}
+}
+
+func TestSetDestinationEnabledRequiresManage(t *testing.T) {
+	service := &LinkService{
+		ShortCodeRepository: &fakeShortCodeRepository{shortcodes: map[string]*domain.ShortCode{"abc": {Code: "abc"}}},
+		Policy:              &fakePolicy{err: domain.ErrForbidden},
+	}
+
+	if _, err := service.SetDestinationEnabled(context.Background(), "abc", 1, false); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("SetDestinationEnabled() error = %v, want %v", err, domain.ErrForbidden)
+	}
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/infra/workerpool"
+	"os"
+	"testing"
+
+	"github.com/panjf2000/ants/v2"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.L = zap.NewNop().Sugar()
+	workerpool.Pool, _ = ants.NewPool(4)
+
+	code := m.Run()
+	workerpool.ClosePool()
+	os.Exit(code)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"time"
+)
+
+var (
+	// RollupInterval is how often the rollups are brought up to date
+	RollupInterval = 5 * time.Minute
+	// RollupLookback is how far back every run recounts, long enough for events that were still
+	// buffered or written late by another instance
+	RollupLookback = 2 * time.Hour
+)
+
+// RollupJob periodically recounts the recent click rollups and persists the visitor sketches. Every instance may run it, the
+// rollups are serialized in postgres and recounting is idempotent.
+type RollupJob struct {
+	AnalyticsService ports.AnalyticsService
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewRollupJob(AnalyticsService ports.AnalyticsService) ports.RollupJob {
+	return &RollupJob{
+		AnalyticsService: AnalyticsService,
+		stop:             make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+}
+
+func (j *RollupJob) Start(ctx context.Context) error {
+	go j.run()
+
+	return nil
+}
+
+// Stop waits for a running rollup to finish until ctx is done.
+func (j *RollupJob) Stop(ctx context.Context) error {
+	close(j.stop)
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (j *RollupJob) run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(RollupInterval)
+	defer ticker.Stop()
+
+	for {
+		j.rollup()
+
+		select {
+		case <-ticker.C:
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+func (j *RollupJob) rollup() {
+	ctx, cancel := context.WithTimeout(context.Background(), RollupInterval)
+	defer cancel()
+
+	now := time.Now()
+	if err := j.AnalyticsService.RollupClicks(ctx, now.Add(-RollupLookback), now); err != nil {
+		logger.L.Errorw("failed to roll up clicks", "error", err.Error())
+	}
+
+	// yesterday's sketches still get the visitors that were counted just before midnight
+	today := now.In(RollupLocation)
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		if err := j.AnalyticsService.PersistVisitors(ctx, day); err != nil {
+			logger.L.Errorw("failed to persist visitors", "day", day.Format(time.DateOnly), "error", err.Error())
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"URLRotatorGo/pkg"
 	"context"
 	"errors"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,59 +19,111 @@ type ShortenerService struct {
 	ShortCodeRepository ports.ShortCodeRepository
 	URLRepository       ports.URLRepository
 	CacheRepository     ports.CacheRepository
+	DomainRepository    ports.DomainRepository
+	ClickRecorder       ports.ClickRecorder
+	HitCounter          ports.HitCounter
+	Policy              ports.Policy
 }
 
-func NewShortenerService(ShortCodeRepository ports.ShortCodeRepository, URLRepository ports.URLRepository, CacheRepository ports.CacheRepository) ports.ShortenerService {
+func NewShortenerService(ShortCodeRepository ports.ShortCodeRepository, URLRepository ports.URLRepository, CacheRepository ports.CacheRepository, DomainRepository ports.DomainRepository, ClickRecorder ports.ClickRecorder, HitCounter ports.HitCounter, Policy ports.Policy) ports.ShortenerService {
 	return &ShortenerService{
 		ShortCodeRepository: ShortCodeRepository,
 		URLRepository:       URLRepository,
 		CacheRepository:     CacheRepository,
+		DomainRepository:    DomainRepository,
+		ClickRecorder:       ClickRecorder,
+		HitCounter:          HitCounter,
+		Policy:              Policy,
 	}
 }
 
-func (s *ShortenerService) GetRedirectURL(ctx context.Context, code string) (string, error) {
+var (
+	MaxUnlockAttemptsPerIP   = 10
+	MaxUnlockAttemptsPerCode = 50
+	UnlockAttemptWindow      = 15 * time.Minute
+)
+
+func (s *ShortenerService) GetShortCode(ctx context.Context, code string) (*domain.ShortCode, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
 
+	return s.getShortCode(ctx, code)
+}
+
+func (s *ShortenerService) getShortCode(ctx context.Context, code string) (*domain.ShortCode, error) {
 	shortcode, err := s.CacheRepository.GetShortCode(ctx, code)
-	if err != nil {
-		logger.L.Info("no cache data for shortcode:", code)
+	if err == nil {
+		return shortcode, nil
+	}
 
-		shortcode, err = s.ShortCodeRepository.GetShortCode(ctx, code)
-		if err != nil {
-			if errors.Is(err, domain.ErrDataNotFound) {
-				return "", domain.ErrDataNotFound
-			}
-			return "", err
+	logger.L.Info("no cache data for shortcode:", code)
+
+	shortcode, err = s.ShortCodeRepository.GetShortCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, domain.ErrDataNotFound) {
+			return nil, domain.ErrDataNotFound
 		}
+		return nil, err
+	}
 
-		logger.L.Info("saving data to cache database")
-		_ = workerpool.Pool.Submit(func() {
-			myctx, mycancel := context.WithTimeout(context.Background(), time.Second*10)
-			defer mycancel()
-			_ = s.CacheRepository.SaveShortCode(myctx, shortcode)
-		})
+	logger.L.Info("saving data to cache database")
+	_ = workerpool.Pool.Submit(func() {
+		myctx, mycancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer mycancel()
+		_ = s.CacheRepository.SaveShortCode(myctx, shortcode)
+	})
+
+	return shortcode, nil
+}
+
+// UnlockShortCode checks the password of a protected shortcode. Failed attempts are limited per ip
+// and per shortcode, every attempt is counted before the password is compared.
+func (s *ShortenerService) UnlockShortCode(ctx context.Context, code, password, ip string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	shortcode, err := s.getShortCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	if !shortcode.IsProtected() {
+		return nil
 	}
 
-	links, err := s.CacheRepository.GetLinks(ctx, code)
-	if err != nil || len(links) == 0 {
-		logger.L.Info("no cache data for links with code:", code)
-		links, err = s.URLRepository.GetLinks(ctx, code)
-		if err != nil {
-			if errors.Is(err, domain.ErrDataNotFound) {
-				return "", domain.ErrDataNotFound
-			}
-			logger.L.Errorw("error while getlinks", "error", err.Error())
-			return "", domain.ErrInternalServerError
-		}
+	limits := []attemptLimit{
+		{key: "unlock:ip:" + ip, max: MaxUnlockAttemptsPerIP},
+		{key: "unlock:code:" + code, max: MaxUnlockAttemptsPerCode},
+	}
+	if err = claimAttempt(ctx, s.CacheRepository, UnlockAttemptWindow, limits...); err != nil {
+		return err
+	}
 
-		_ = workerpool.Pool.Submit(func() {
-			myctx, mycancel := context.WithTimeout(context.Background(), time.Second*10)
-			defer mycancel()
-			_ = s.CacheRepository.SaveLinks(myctx, links)
-		})
+	if !pkg.ComparePassword(shortcode.PasswordHash, password) {
+		return domain.ErrInvalidPassword
 	}
+	releaseAttempt(ctx, s.CacheRepository, limits...)
+
+	return nil
+}
+
+// GetRedirectURL picks the destination of the visit and counts the hit in the background. Bots
+// are redirected all the same, but unless CountBots is set they are only recorded as click events,
+// so they neither inflate the hits nor skew the balancing of RoundRobin.
+func (s *ShortenerService) GetRedirectURL(ctx context.Context, code string, visit domain.Visit) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
 
+	shortcode, err := s.getShortCode(ctx, code)
+	if err != nil {
+		return "", err
+	}
+
+	links, err := s.getLinks(ctx, code)
+	if err != nil {
+		return "", err
+	}
+
+	links = enabledLinks(links)
 	if len(links) == 0 {
 		return "", domain.ErrDataNotFound
 	}
@@ -85,72 +138,328 @@ func (s *ShortenerService) GetRedirectURL(ctx context.Context, code string) (str
 		link = links[pkg.GenerateRandomNumber(len(links))]
 	case domain.RoundRobin:
 		link = links[0]
+	default:
+		// a shortcode without a known strategy rotates like round robin instead of failing every redirect
+		link = links[0]
 	}
 
+	s.ClickRecorder.Record(domain.NewClickEvent(shortcode.Code, link.ID, visit))
+	if !visit.IsHuman() && !CountBots {
+		return link.Original, nil
+	}
+
+	// postgres gets the hits in batches, the cache right away since round robin rotates by it
+	s.HitCounter.Record(shortcode.Code, link.ID)
 	defer workerpool.Pool.Submit(func() {
 		myctx, mycancel := context.WithTimeout(context.Background(), time.Second*10)
 		defer mycancel()
 
-		linkID := strconv.Itoa(link.ID)
-		_ = s.URLRepository.UpdateHit(myctx, linkID)
-		_ = s.ShortCodeRepository.UpdateHit(myctx, shortcode.Code)
-		_ = s.CacheRepository.IncrShortCode(myctx, shortcode.Code)
-		_ = s.CacheRepository.IncrLink(myctx, code, linkID)
+		_ = s.CacheRepository.IncrHits(myctx, code, strconv.Itoa(link.ID))
+		_ = s.CacheRepository.AddVisitor(myctx, shortcode.Code, link.ID, visit.VisitorID(), time.Now().In(RollupLocation))
 	})
 
 	return link.Original, nil
 }
 
-func (s *ShortenerService) ShortURL(ctx context.Context, urls []string, strategy string) (*domain.ShortCode, error) {
+// GetLinks returns the destinations of a shortcode without counting a hit, e.g. for a preview.
+func (s *ShortenerService) GetLinks(ctx context.Context, code string) ([]*domain.URL, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
 
-	strategy = strings.ToUpper(strategy)
-	var strategyAlgo domain.Strategy
-	switch strategy {
-	case string(domain.Random):
-		strategyAlgo = domain.Random
-	case string(domain.RoundRobin):
-		strategyAlgo = domain.RoundRobin
-	default:
-		strategyAlgo = domain.RoundRobin
+	return s.getLinks(ctx, code)
+}
+
+func (s *ShortenerService) getLinks(ctx context.Context, code string) ([]*domain.URL, error) {
+	links, err := s.CacheRepository.GetLinks(ctx, code)
+	if err == nil && len(links) > 0 {
+		return links, nil
 	}
 
-	code := pkg.GenerateShortID()
+	logger.L.Info("no cache data for links with code:", code)
+	links, err = s.URLRepository.GetLinks(ctx, code)
+	if err != nil {
+		if errors.Is(err, domain.ErrDataNotFound) {
+			return nil, domain.ErrDataNotFound
+		}
+		logger.L.Errorw("error while getlinks", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
 
-	shortcode := &domain.ShortCode{
-		Code:     code,
-		Strategy: strategyAlgo,
+	_ = workerpool.Pool.Submit(func() {
+		myctx, mycancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer mycancel()
+		_ = s.CacheRepository.SaveLinks(myctx, links)
+	})
+
+	return links, nil
+}
+
+// enabledLinks drops the destinations that are currently excluded from rotation.
+func enabledLinks(links []*domain.URL) []*domain.URL {
+	enabled := make([]*domain.URL, 0, len(links))
+	for _, link := range links {
+		if link.Enabled {
+			enabled = append(enabled, link)
+		}
+	}
+
+	return enabled
+}
+
+var (
+	aliasPattern    = regexp.MustCompile(`^[a-zA-Z0-9_-]{3,64}$`)
+	reservedAliases = map[string]bool{"api": true}
+)
+
+// ShortURL creates a shortcode that belongs to the owner, a zero owner creates an anonymous shortcode.
+// Shortcodes on a custom domain belong to the workspace of the domain.
+func (s *ShortenerService) ShortURL(ctx context.Context, owner domain.LinkScope, request domain.ShortenRequest) (*domain.ShortCode, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	owner, host, err := s.resolveTarget(ctx, owner, request.Domain)
+	if err != nil {
+		return nil, err
 	}
 
-	shortcode, err := s.ShortCodeRepository.Save(ctx, shortcode)
+	shortcode, err := newShortCode(request)
 	if err != nil {
 		return nil, err
 	}
+	shortcode.Code = domain.LinkKey(host, shortcode.Code)
+	shortcode.OwnerID, shortcode.WorkspaceID = owner.OwnerID, owner.WorkspaceID
+
+	if request.Deduplicate && request.Alias == "" && shortcode.Fingerprint != "" {
+		existing, err := s.ShortCodeRepository.FindByFingerprint(ctx, owner, host, shortcode.Fingerprint)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, domain.ErrDataNotFound) {
+			return nil, err
+		}
+	}
 
 	var links []*domain.URL
-	for _, url := range urls {
+	for _, url := range request.URLs {
 		links = append(links, &domain.URL{
 			ShortCode: shortcode.Code,
 			Original:  url,
 		})
 	}
 
-	if links, err = s.URLRepository.Save(ctx, links); err != nil {
+	// the first revision is written together with the shortcode, so every link has a history
+	shortcode, links, err = s.ShortCodeRepository.Save(ctx, shortcode, links)
+	if err != nil {
 		return nil, err
 	}
 
 	_ = workerpool.Pool.Submit(func() {
-		ctx, cancel = context.WithTimeout(context.Background(), time.Second*10)
-		defer cancel()
+		myctx, mycancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer mycancel()
 
-		if err = s.CacheRepository.SaveShortCode(ctx, shortcode); err != nil {
+		if err := s.CacheRepository.SaveShortCode(myctx, shortcode); err != nil {
 			logger.L.Errorw("failed to save cache", "error", err.Error())
 		}
-		if err = s.CacheRepository.SaveLinks(ctx, links); err != nil {
+		if err := s.CacheRepository.SaveLinks(myctx, links); err != nil {
 			logger.L.Errorw("failed to save cache", "error", err.Error())
 		}
 	})
 
 	return shortcode, nil
 }
+
+// BulkShortURL creates one shortcode per request using multi-row inserts. The returned
+// results are in the same order as the requests, each one either succeeded or carries its error.
+func (s *ShortenerService) BulkShortURL(ctx context.Context, owner domain.LinkScope, requests []domain.ShortenRequest) []domain.BulkShortenResult {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	results := make([]domain.BulkShortenResult, len(requests))
+	pending := make(map[string]int, len(requests))
+
+	// most imports use a single domain, resolve each one once
+	type target struct {
+		owner domain.LinkScope
+		host  string
+		err   error
+	}
+	targets := make(map[string]target)
+
+	var shortcodes []*domain.ShortCode
+	for i, request := range requests {
+		t, ok := targets[request.Domain]
+		if !ok {
+			t.owner, t.host, t.err = s.resolveTarget(ctx, owner, request.Domain)
+			targets[request.Domain] = t
+		}
+		if t.err != nil {
+			results[i].Err = t.err
+			continue
+		}
+
+		shortcode, err := newShortCode(request)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		shortcode.Code = domain.LinkKey(t.host, shortcode.Code)
+		shortcode.OwnerID, shortcode.WorkspaceID = t.owner.OwnerID, t.owner.WorkspaceID
+		if _, exists := pending[shortcode.Code]; exists {
+			results[i].Err = domain.ErrCodeAlreadyExists
+			continue
+		}
+
+		pending[shortcode.Code] = i
+		shortcodes = append(shortcodes, shortcode)
+	}
+
+	var links []*domain.URL
+	for _, shortcode := range shortcodes {
+		for _, url := range requests[pending[shortcode.Code]].URLs {
+			links = append(links, &domain.URL{
+				ShortCode: shortcode.Code,
+				Original:  url,
+			})
+		}
+	}
+
+	saved, links, err := s.ShortCodeRepository.SaveBatch(ctx, shortcodes, links)
+	if err != nil {
+		for _, i := range pending {
+			results[i].Err = err
+		}
+		return results
+	}
+
+	for _, shortcode := range saved {
+		results[pending[shortcode.Code]].ShortCode = shortcode
+		delete(pending, shortcode.Code)
+	}
+
+	// whatever is left in pending was skipped because the code is already taken
+	for _, i := range pending {
+		results[i].Err = domain.ErrCodeAlreadyExists
+	}
+
+	if len(saved) == 0 {
+		return results
+	}
+
+	_ = workerpool.Pool.Submit(func() {
+		myctx, mycancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer mycancel()
+
+		for _, shortcode := range saved {
+			if err := s.CacheRepository.SaveShortCode(myctx, shortcode); err != nil {
+				logger.L.Errorw("failed to save cache", "error", err.Error())
+			}
+		}
+		if err := s.CacheRepository.SaveLinks(myctx, links); err != nil {
+			logger.L.Errorw("failed to save cache", "error", err.Error())
+		}
+	})
+
+	return results
+}
+
+// resolveTarget returns the owner and the host of new shortcodes and checks that the principal may
+// create links there. A custom domain moves the shortcode into the workspace of the domain, so only
+// members who may create links in that workspace can use it. It fails with ErrInvalidDomain if the
+// domain is unknown, belongs to a workspace the user isn't a member of or to another workspace than
+// the requested one.
+func (s *ShortenerService) resolveTarget(ctx context.Context, owner domain.LinkScope, host string) (domain.LinkScope, string, error) {
+	if host != "" {
+		normalized, ok := domain.NormalizeHost(host)
+		if !ok {
+			return owner, "", domain.ErrInvalidDomain
+		}
+
+		customDomain, err := s.DomainRepository.GetByHost(ctx, normalized)
+		if err != nil {
+			if errors.Is(err, domain.ErrDataNotFound) {
+				return owner, "", domain.ErrInvalidDomain
+			}
+			return owner, "", err
+		}
+		if err = s.Policy.Authorize(ctx, customDomain.WorkspaceID, domain.ActionCreateLinks); err != nil {
+			if errors.Is(err, domain.ErrDataNotFound) {
+				return owner, "", domain.ErrInvalidDomain
+			}
+			return owner, "", err
+		}
+		if owner.WorkspaceID != 0 && owner.WorkspaceID != customDomain.WorkspaceID {
+			return owner, "", domain.ErrInvalidDomain
+		}
+
+		owner.WorkspaceID = customDomain.WorkspaceID
+		return owner, customDomain.Host, nil
+	}
+
+	if owner.WorkspaceID != 0 {
+		if err := s.Policy.Authorize(ctx, owner.WorkspaceID, domain.ActionCreateLinks); err != nil {
+			return owner, "", err
+		}
+	}
+
+	return owner, host, nil
+}
+
+// newShortCode builds an unsaved shortcode from the request, resolving its strategy, code and password.
+func newShortCode(request domain.ShortenRequest) (*domain.ShortCode, error) {
+	strategyAlgo, ok := domain.ParseStrategy(request.Strategy)
+	if !ok {
+		strategyAlgo = domain.RoundRobin
+	}
+
+	code := pkg.GenerateShortID()
+	if request.Alias != "" {
+		if !aliasPattern.MatchString(request.Alias) || reservedAliases[strings.ToLower(request.Alias)] {
+			return nil, domain.ErrInvalidAlias
+		}
+		code = request.Alias
+	}
+
+	redirect, err := resolveRedirect(request.Redirect)
+	if err != nil {
+		return nil, err
+	}
+
+	shortcode := &domain.ShortCode{
+		Code:     code,
+		Strategy: strategyAlgo,
+		Social:   request.Social,
+		Redirect: redirect,
+	}
+
+	if request.Password != "" {
+		hash, err := hashPassword(request.Password)
+		if err != nil {
+			return nil, err
+		}
+		shortcode.PasswordHash = hash
+	} else if !request.Social.IsSet() {
+		shortcode.Fingerprint = domain.Fingerprint(strategyAlgo, redirect, request.URLs)
+	}
+
+	return shortcode, nil
+}
+
+// resolveRedirect validates the redirect of a request, an empty type means the default one.
+func resolveRedirect(redirect domain.Redirect) (domain.Redirect, error) {
+	if redirect.Type == "" {
+		redirect.Type = domain.DefaultRedirectType
+	}
+
+	redirectType, ok := domain.ParseRedirectType(string(redirect.Type))
+	if !ok || redirect.Delay < 0 || redirect.Delay > domain.MaxRedirectDelay {
+		return domain.Redirect{}, domain.ErrInvalidRedirect
+	}
+	redirect.Type = redirectType
+
+	// a status code redirect can't wait
+	if !redirect.IsPage() {
+		redirect.Delay = 0
+	}
+
+	return redirect, nil
+}
@@ -0,0 +1,381 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/pkg"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func newBulkService(repository *fakeShortCodeRepository) *ShortenerService {
+	// URLRepository stays nil, bulk creation must save the destinations together with the shortcodes
+	return &ShortenerService{
+		ShortCodeRepository: repository,
+		CacheRepository:     &fakeCacheRepository{},
+	}
+}
+
+func TestBulkShortURLSavesDestinationsWithShortcodes(t *testing.T) {
+	repository := &fakeShortCodeRepository{taken: map[string]bool{"taken": true}}
+	service := newBulkService(repository)
+
+	results := service.BulkShortURL(context.Background(), domain.LinkScope{}, []domain.ShortenRequest{
+		{Alias: "first", URLs: []string{"https://a.example", "https://b.example"}},
+		{Alias: "taken", URLs: []string{"https://c.example"}},
+		{Alias: "first", URLs: []string{"https://d.example"}},
+	})
+
+	if results[0].Err != nil || results[0].ShortCode == nil || results[0].ShortCode.Code != "first" {
+		t.Fatalf("results[0] = %+v, want the shortcode first", results[0])
+	}
+	for _, i := range []int{1, 2} {
+		if !errors.Is(results[i].Err, domain.ErrCodeAlreadyExists) {
+			t.Errorf("results[%d].Err = %v, want %v", i, results[i].Err, domain.ErrCodeAlreadyExists)
+		}
+	}
+
+	if len(repository.urls) != 2 {
+		t.Fatalf("saved %d destinations, want 2", len(repository.urls))
+	}
+	for _, url := range repository.urls {
+		if url.ShortCode != "first" {
+			t.Errorf("saved destination %s for %s, want only destinations of first", url.Original, url.ShortCode)
+		}
+	}
+}
+
+func TestBulkShortURLFailsAsAUnit(t *testing.T) {
+	service := newBulkService(&fakeShortCodeRepository{err: domain.ErrInternalServerError})
+
+	results := service.BulkShortURL(context.Background(), domain.LinkScope{}, []domain.ShortenRequest{
+		{Alias: "first", URLs: []string{"https://a.example"}},
+		{Alias: "second", URLs: []string{"https://b.example"}},
+	})
+
+	for i, result := range results {
+		if result.ShortCode != nil || !errors.Is(result.Err, domain.ErrInternalServerError) {
+			t.Errorf("results[%d] = %+v, want %v", i, result, domain.ErrInternalServerError)
+		}
+	}
+}
+
+func TestEnabledLinks(t *testing.T) {
+	links := []*domain.URL{{ID: 1, Enabled: true}, {ID: 2}, {ID: 3, Enabled: true}}
+
+	enabled := enabledLinks(links)
+	if len(enabled) != 2 || enabled[0].ID != 1 || enabled[1].ID != 3 {
+		t.Errorf("enabledLinks() kept %v, want destinations 1 and 3", enabled)
+	}
+	if len(enabledLinks([]*domain.URL{{ID: 1}})) != 0 {
+		t.Error("enabledLinks() kept a disabled destination")
+	}
+}
+
+func TestShortURLSavesDestinationsWithShortcode(t *testing.T) {
+	repository := &fakeShortCodeRepository{}
+	// URLRepository and RevisionRepository are not needed, the first revision is written with the shortcode
+	service := &ShortenerService{ShortCodeRepository: repository, CacheRepository: &fakeCacheRepository{}}
+
+	shortcode, err := service.ShortURL(context.Background(), domain.LinkScope{}, domain.ShortenRequest{
+		Alias: "spring",
+		URLs:  []string{"https://a.example", "https://b.example"},
+	})
+	if err != nil {
+		t.Fatalf("ShortURL() error = %v", err)
+	}
+	if shortcode.Code != "spring" {
+		t.Errorf("ShortURL() code = %q, want spring", shortcode.Code)
+	}
+	if len(repository.urls) != 2 || repository.urls[0].ShortCode != "spring" || repository.urls[1].Original != "https://b.example" {
+		t.Errorf("saved destinations %+v, want both destinations of spring", repository.urls)
+	}
+}
+
+func TestShortURLReturnsSaveErrors(t *testing.T) {
+	for _, want := range []error{domain.ErrCodeAlreadyExists, domain.ErrInternalServerError} {
+		repository := &fakeShortCodeRepository{taken: map[string]bool{"spring": true}}
+		if want != domain.ErrCodeAlreadyExists {
+			repository.err = want
+		}
+		service := &ShortenerService{ShortCodeRepository: repository, CacheRepository: &fakeCacheRepository{}}
+
+		shortcode, err := service.ShortURL(context.Background(), domain.LinkScope{}, domain.ShortenRequest{Alias: "spring", URLs: []string{"https://a.example"}})
+		if shortcode != nil || !errors.Is(err, want) {
+			t.Errorf("ShortURL() = %v, %v, want %v", shortcode, err, want)
+		}
+	}
+}
+
+func TestShortURLDeduplicates(t *testing.T) {
+	urls := []string{"https://a.example", "https://b.example"}
+	existing := &domain.ShortCode{Code: "existing", OwnerID: 7, Fingerprint: domain.Fingerprint(domain.RoundRobin, domain.Redirect{Type: domain.DefaultRedirectType}, urls)}
+	repository := &fakeShortCodeRepository{shortcodes: map[string]*domain.ShortCode{existing.Code: existing}}
+	service := &ShortenerService{ShortCodeRepository: repository, CacheRepository: &fakeCacheRepository{}}
+	owner := domain.LinkScope{OwnerID: 7}
+
+	shortcode, err := service.ShortURL(context.Background(), owner, domain.ShortenRequest{URLs: []string{urls[1], urls[0]}, Deduplicate: true})
+	if err != nil {
+		t.Fatalf("ShortURL() error = %v", err)
+	}
+	if shortcode != existing {
+		t.Errorf("ShortURL() = %s, want the existing shortcode", shortcode.Code)
+	}
+
+	for name, request := range map[string]domain.ShortenRequest{
+		"not requested": {URLs: urls},
+		"alias":         {URLs: urls, Deduplicate: true, Alias: "spring"},
+		"password":      {URLs: urls, Deduplicate: true, Password: "secret"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			shortcode, err := service.ShortURL(context.Background(), owner, request)
+			if err != nil {
+				t.Fatalf("ShortURL() error = %v", err)
+			}
+			if shortcode == existing {
+				t.Error("ShortURL() returned the existing shortcode, want a new one")
+			}
+		})
+	}
+
+	shortcode, err = service.ShortURL(context.Background(), domain.LinkScope{OwnerID: 8}, domain.ShortenRequest{URLs: urls, Deduplicate: true})
+	if err != nil {
+		t.Fatalf("ShortURL() error = %v", err)
+	}
+	if shortcode == existing {
+		t.Error("ShortURL() handed out the shortcode of another owner")
+	}
+}
+
+func TestResolveRedirect(t *testing.T) {
+	tests := map[string]struct {
+		redirect domain.Redirect
+		want     domain.Redirect
+	}{
+		"default":              {domain.Redirect{}, domain.Redirect{Type: domain.RedirectFound}},
+		"permanent":            {domain.Redirect{Type: "301"}, domain.Redirect{Type: domain.RedirectMovedPermanently}},
+		"upper case":           {domain.Redirect{Type: "META", Delay: 3}, domain.Redirect{Type: domain.RedirectMetaRefresh, Delay: 3}},
+		"status code no delay": {domain.Redirect{Type: "307", Delay: 5}, domain.Redirect{Type: domain.RedirectTemporary}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := resolveRedirect(test.redirect)
+			if err != nil || got != test.want {
+				t.Errorf("resolveRedirect(%+v) = %+v, %v, want %+v", test.redirect, got, err, test.want)
+			}
+		})
+	}
+
+	for name, redirect := range map[string]domain.Redirect{
+		"unknown type":   {Type: "303"},
+		"negative delay": {Type: domain.RedirectJavaScript, Delay: -1},
+		"too long delay": {Type: domain.RedirectJavaScript, Delay: domain.MaxRedirectDelay + 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := resolveRedirect(redirect); !errors.Is(err, domain.ErrInvalidRedirect) {
+				t.Errorf("resolveRedirect(%+v) error = %v, want %v", redirect, err, domain.ErrInvalidRedirect)
+			}
+		})
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	domains := &fakeDomainRepository{domains: map[string]*domain.Domain{
+		"go.acme.example": {ID: 1, Host: "go.acme.example", WorkspaceID: 7},
+	}}
+
+	tests := map[string]struct {
+		owner  domain.LinkScope
+		host   string
+		denied map[int]error
+		want   domain.LinkScope
+		err    error
+	}{
+		"member":               {domain.LinkScope{OwnerID: 2}, "Go.Acme.Example", nil, domain.LinkScope{OwnerID: 2, WorkspaceID: 7}, nil},
+		"requested workspace":  {domain.LinkScope{OwnerID: 2, WorkspaceID: 7}, "go.acme.example", nil, domain.LinkScope{OwnerID: 2, WorkspaceID: 7}, nil},
+		"other workspace":      {domain.LinkScope{OwnerID: 2, WorkspaceID: 3}, "go.acme.example", nil, domain.LinkScope{}, domain.ErrInvalidDomain},
+		"unknown domain":       {domain.LinkScope{OwnerID: 2}, "go.other.example", nil, domain.LinkScope{}, domain.ErrInvalidDomain},
+		"anonymous":            {domain.LinkScope{}, "go.acme.example", map[int]error{7: domain.ErrUnauthorized}, domain.LinkScope{}, domain.ErrUnauthorized},
+		"key without user":     {domain.LinkScope{}, "go.acme.example", map[int]error{7: domain.ErrForbidden}, domain.LinkScope{}, domain.ErrForbidden},
+		"not a member":         {domain.LinkScope{OwnerID: 4}, "go.acme.example", map[int]error{7: domain.ErrDataNotFound}, domain.LinkScope{}, domain.ErrInvalidDomain},
+		"workspace without it": {domain.LinkScope{OwnerID: 4, WorkspaceID: 3}, "", map[int]error{3: domain.ErrForbidden}, domain.LinkScope{}, domain.ErrForbidden},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := &ShortenerService{DomainRepository: domains, Policy: &fakePolicy{denied: test.denied}}
+
+			owner, host, err := service.resolveTarget(context.Background(), test.owner, test.host)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("resolveTarget() error = %v, want %v", err, test.err)
+			}
+			if err != nil {
+				return
+			}
+			if owner != test.want {
+				t.Errorf("resolveTarget() owner = %+v, want %+v", owner, test.want)
+			}
+			if test.host != "" && host != "go.acme.example" {
+				t.Errorf("resolveTarget() host = %q, want the domain's host", host)
+			}
+		})
+	}
+}
+
+func TestGetRedirectURLExcludesBots(t *testing.T) {
+	defer func(countBots bool) { CountBots = countBots }(CountBots)
+
+	tests := map[string]struct {
+		traffic   domain.Traffic
+		countBots bool
+		hits      int
+	}{
+		"human":                    {domain.TrafficHuman, false, 1},
+		"unclassified":             {"", false, 1},
+		"bot":                      {domain.TrafficBot, false, 0},
+		"suspected bot":            {domain.TrafficSuspectedBot, false, 0},
+		"prefetch":                 {domain.TrafficPrefetch, false, 0},
+		"bot when bots count":      {domain.TrafficBot, true, 1},
+		"prefetch when bots count": {domain.TrafficPrefetch, true, 1},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			CountBots = test.countBots
+			recorder := &fakeClickRecorder{}
+			hits := &fakeHitCounter{}
+			service := &ShortenerService{
+				ShortCodeRepository: &fakeShortCodeRepository{shortcodes: map[string]*domain.ShortCode{"spring": {Code: "spring", Strategy: domain.RoundRobin}}},
+				URLRepository: &fakeURLRepository{links: map[string][]*domain.URL{"spring": {
+					{ID: 1, Original: "https://a.example", Enabled: true, TotalHit: 5},
+					{ID: 2, Original: "https://b.example", Enabled: true, TotalHit: 3},
+				}}},
+				CacheRepository: &fakeCacheRepository{},
+				ClickRecorder:   recorder,
+				HitCounter:      hits,
+			}
+
+			url, err := service.GetRedirectURL(context.Background(), "spring", domain.Visit{Traffic: test.traffic})
+			if err != nil {
+				t.Fatalf("GetRedirectURL() error = %v", err)
+			}
+			// bots are redirected like everyone else
+			if url != "https://b.example" {
+				t.Errorf("GetRedirectURL() = %q, want the destination with the fewest hits", url)
+			}
+			// and always end up in the click log, where they can be told apart
+			if len(recorder.events) != 1 || recorder.events[0].Traffic != test.traffic {
+				t.Errorf("recorded %+v, want the click of %q", recorder.events, test.traffic)
+			}
+			if len(hits.hits) != test.hits {
+				t.Errorf("counted %d hits, want %d", len(hits.hits), test.hits)
+			}
+		})
+	}
+}
+
+func newUnlockService(t *testing.T) (*ShortenerService, *fakeCacheRepository) {
+	hash, err := pkg.HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	cache := &fakeCacheRepository{}
+
+	return &ShortenerService{
+		ShortCodeRepository: &fakeShortCodeRepository{shortcodes: map[string]*domain.ShortCode{
+			"secret": {Code: "secret", Strategy: domain.RoundRobin, PasswordHash: hash},
+			"open":   {Code: "open", Strategy: domain.RoundRobin},
+		}},
+		CacheRepository: cache,
+	}, cache
+}
+
+func TestUnlockShortCode(t *testing.T) {
+	service, cache := newUnlockService(t)
+
+	if err := service.UnlockShortCode(context.Background(), "open", "", "203.0.113.1"); err != nil {
+		t.Errorf("UnlockShortCode() of an unprotected link error = %v", err)
+	}
+	if err := service.UnlockShortCode(context.Background(), "secret", "wrong", "203.0.113.1"); !errors.Is(err, domain.ErrInvalidPassword) {
+		t.Errorf("UnlockShortCode() error = %v, want %v", err, domain.ErrInvalidPassword)
+	}
+	if err := service.UnlockShortCode(context.Background(), "secret", "correct horse", "203.0.113.1"); err != nil {
+		t.Errorf("UnlockShortCode() error = %v", err)
+	}
+
+	// only the failed attempt is counted
+	if cache.attempts["unlock:ip:203.0.113.1"] != 1 || cache.attempts["unlock:code:secret"] != 1 {
+		t.Errorf("attempts = %v, want the failed attempt once per counter", cache.attempts)
+	}
+}
+
+func TestUnlockShortCodeLocksOut(t *testing.T) {
+	tests := map[string]struct {
+		key      string
+		attempts int
+	}{
+		"ip":        {"unlock:ip:203.0.113.1", MaxUnlockAttemptsPerIP},
+		"shortcode": {"unlock:code:secret", MaxUnlockAttemptsPerCode},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			service, cache := newUnlockService(t)
+			cache.attempts = map[string]int{test.key: test.attempts - 1}
+
+			if err := service.UnlockShortCode(context.Background(), "secret", "wrong", "203.0.113.1"); !errors.Is(err, domain.ErrInvalidPassword) {
+				t.Fatalf("UnlockShortCode() error = %v, want %v", err, domain.ErrInvalidPassword)
+			}
+			// even the right password is refused once the limit is reached
+			if err := service.UnlockShortCode(context.Background(), "secret", "correct horse", "203.0.113.1"); !errors.Is(err, domain.ErrTooManyAttempts) {
+				t.Errorf("UnlockShortCode() error = %v, want %v", err, domain.ErrTooManyAttempts)
+			}
+			if cache.attempts[test.key] != test.attempts {
+				t.Errorf("attempts = %d, want the rejected attempt not counted", cache.attempts[test.key])
+			}
+		})
+	}
+}
+
+func TestUnlockShortCodeLimitsConcurrentGuesses(t *testing.T) {
+	defer func(max int) { MaxUnlockAttemptsPerCode = max }(MaxUnlockAttemptsPerCode)
+	MaxUnlockAttemptsPerCode = 5
+	service, _ := newUnlockService(t)
+
+	// the guesses come from different ips and are all checked at the same time
+	var wg sync.WaitGroup
+	var guessed atomic.Int64
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+			if err := service.UnlockShortCode(context.Background(), "secret", "wrong", ip); errors.Is(err, domain.ErrInvalidPassword) {
+				guessed.Add(1)
+			}
+		}("203.0.113." + strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	if guessed.Load() != 5 {
+		t.Errorf("%d guesses were checked, want %d", guessed.Load(), MaxUnlockAttemptsPerCode)
+	}
+}
+
+func TestGetRedirectURLWithoutStrategy(t *testing.T) {
+	service := &ShortenerService{
+		ShortCodeRepository: &fakeShortCodeRepository{shortcodes: map[string]*domain.ShortCode{"spring": {Code: "spring"}}},
+		URLRepository: &fakeURLRepository{links: map[string][]*domain.URL{"spring": {
+			{ID: 1, Original: "https://a.example", Enabled: true, TotalHit: 5},
+			{ID: 2, Original: "https://b.example", Enabled: true, TotalHit: 3},
+		}}},
+		CacheRepository: &fakeCacheRepository{},
+		ClickRecorder:   &fakeClickRecorder{},
+		HitCounter:      &fakeHitCounter{},
+	}
+
+	// rotates like round robin instead of failing the redirect
+	url, err := service.GetRedirectURL(context.Background(), "spring", domain.Visit{})
+	if err != nil || url != "https://b.example" {
+		t.Errorf("GetRedirectURL() = %q, %v, want the destination with the fewest hits", url, err)
+	}
+}
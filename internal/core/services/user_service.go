@@ -0,0 +1,138 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	MaxLoginAttemptsPerIP      = 20
+	MaxLoginAttemptsPerAccount = 10
+	LoginAttemptWindow         = 15 * time.Minute
+)
+
+var (
+	// dummyHash is compared against when the email is unknown, so that a login takes about as
+	// long whether the account exists or not
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+type UserService struct {
+	UserRepository  ports.UserRepository
+	CacheRepository ports.CacheRepository
+	AuditRepository ports.AuditRepository
+}
+
+func NewUserService(UserRepository ports.UserRepository, CacheRepository ports.CacheRepository, AuditRepository ports.AuditRepository) ports.UserService {
+	return &UserService{
+		UserRepository:  UserRepository,
+		CacheRepository: CacheRepository,
+		AuditRepository: AuditRepository,
+	}
+}
+
+func (s *UserService) Signup(ctx context.Context, email, password string) (*domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.UserRepository.Save(ctx, &domain.User{
+		Email:        normalizeEmail(email),
+		PasswordHash: hash,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditUserSignup, domain.AuditTargetUser, strconv.Itoa(user.ID), 0,
+		nil, map[string]any{"email": user.Email}))
+
+	return user, nil
+}
+
+// Login checks the credentials of a user. Failed attempts are counted per ip and per account,
+// once either limit is reached every attempt is rejected until the window expires. Every attempt
+// is counted before the password is compared and only taken back when it succeeded.
+func (s *UserService) Login(ctx context.Context, email, password, ip string) (*domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	email = normalizeEmail(email)
+	limits := []attemptLimit{
+		{key: "login:ip:" + ip, max: MaxLoginAttemptsPerIP},
+		{key: "login:account:" + email, max: MaxLoginAttemptsPerAccount},
+	}
+	if err := claimAttempt(ctx, s.CacheRepository, LoginAttemptWindow, limits...); err != nil {
+		return nil, err
+	}
+
+	user, err := s.UserRepository.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, domain.ErrDataNotFound) {
+		releaseAttempt(ctx, s.CacheRepository, limits...)
+		return nil, err
+	}
+
+	if user == nil {
+		pkg.ComparePassword(getDummyHash(), password)
+	}
+	if user == nil || !pkg.ComparePassword(user.PasswordHash, password) {
+		// the email is recorded as given, there may be no user to point to
+		recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditUserLoginFailed, domain.AuditTargetUser, email, 0, nil, nil))
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	releaseAttempt(ctx, s.CacheRepository, limits...)
+
+	// from here on the user is the one acting
+	subject := "user:" + strconv.Itoa(user.ID)
+	recordAudit(domain.WithActor(ctx, subject), s.AuditRepository, domain.NewAuditEntry(domain.AuditUserLogin, domain.AuditTargetUser, strconv.Itoa(user.ID), 0, nil, nil))
+
+	return user, nil
+}
+
+func (s *UserService) GetUser(ctx context.Context, id int) (*domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return s.UserRepository.GetByID(ctx, id)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func getDummyHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = pkg.HashPassword("dummy password")
+	})
+
+	return dummyHash
+}
+
+// hashPassword hashes a password of a user or a link, passwords bcrypt refuses because of their
+// length are rejected as invalid input.
+func hashPassword(password string) (string, error) {
+	hash, err := pkg.HashPassword(password)
+	if err != nil {
+		if errors.Is(err, pkg.ErrPasswordTooLong) {
+			return "", domain.ErrPasswordTooLong
+		}
+		logger.L.Errorw("failed to hash password", "error", err.Error())
+		return "", domain.ErrInternalServerError
+	}
+
+	return hash, nil
+}
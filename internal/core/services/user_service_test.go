@@ -0,0 +1,147 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/pkg"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func newUserService() (*UserService, *fakeCacheRepository, *fakeAuditRepository) {
+	cache := &fakeCacheRepository{}
+	audit := &fakeAuditRepository{}
+	service := &UserService{
+		UserRepository:  &fakeUserRepository{users: make(map[string]*domain.User)},
+		CacheRepository: cache,
+		AuditRepository: audit,
+	}
+
+	return service, cache, audit
+}
+
+func TestSignup(t *testing.T) {
+	service, _, audit := newUserService()
+
+	user, err := service.Signup(context.Background(), " Jane@Example.COM ", "correct horse")
+	if err != nil {
+		t.Fatalf("Signup() error = %v", err)
+	}
+	if user.Email != "jane@example.com" {
+		t.Errorf("Email = %q, want it normalized", user.Email)
+	}
+	if user.PasswordHash == "correct horse" || !pkg.ComparePassword(user.PasswordHash, "correct horse") {
+		t.Errorf("PasswordHash = %q, want a hash of the password", user.PasswordHash)
+	}
+	if len(audit.entries) != 1 || audit.entries[0].Action != domain.AuditUserSignup {
+		t.Errorf("audit entries = %+v, want the signup", audit.entries)
+	}
+
+	if _, err := service.Signup(context.Background(), "JANE@example.com", "another one"); !errors.Is(err, domain.ErrEmailAlreadyExists) {
+		t.Errorf("Signup() of a taken email error = %v, want %v", err, domain.ErrEmailAlreadyExists)
+	}
+	if _, err := service.Signup(context.Background(), "long@example.com", strings.Repeat("a", 73)); !errors.Is(err, domain.ErrPasswordTooLong) {
+		t.Errorf("Signup() of a long password error = %v, want %v", err, domain.ErrPasswordTooLong)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	service, cache, audit := newUserService()
+	user, err := service.Signup(context.Background(), "jane@example.com", "correct horse")
+	if err != nil {
+		t.Fatalf("Signup() error = %v", err)
+	}
+
+	tests := map[string]struct {
+		email    string
+		password string
+		err      error
+		action   string
+	}{
+		"valid":          {" JANE@example.com", "correct horse", nil, domain.AuditUserLogin},
+		"wrong password": {"jane@example.com", "battery staple", domain.ErrInvalidCredentials, domain.AuditUserLoginFailed},
+		"unknown email":  {"john@example.com", "correct horse", domain.ErrInvalidCredentials, domain.AuditUserLoginFailed},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			audit.entries = nil
+
+			got, err := service.Login(context.Background(), test.email, test.password, "203.0.113.1")
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Login() error = %v, want %v", err, test.err)
+			}
+			if err == nil && got.ID != user.ID {
+				t.Errorf("Login() = user %d, want %d", got.ID, user.ID)
+			}
+			if len(audit.entries) != 1 || audit.entries[0].Action != test.action {
+				t.Errorf("audit entries = %+v, want %s", audit.entries, test.action)
+			}
+		})
+	}
+
+	// only the two failures are counted
+	if got := cache.attempts["login:ip:203.0.113.1"]; got != 2 {
+		t.Errorf("attempts of the ip = %d, want 2", got)
+	}
+	if got := cache.attempts["login:account:jane@example.com"]; got != 1 {
+		t.Errorf("attempts of the account = %d, want 1", got)
+	}
+}
+
+func TestLoginLocksOut(t *testing.T) {
+	tests := map[string]struct {
+		key      string
+		attempts int
+	}{
+		"ip":      {"login:ip:203.0.113.1", MaxLoginAttemptsPerIP},
+		"account": {"login:account:jane@example.com", MaxLoginAttemptsPerAccount},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			service, cache, _ := newUserService()
+			if _, err := service.Signup(context.Background(), "jane@example.com", "correct horse"); err != nil {
+				t.Fatalf("Signup() error = %v", err)
+			}
+			cache.attempts = map[string]int{test.key: test.attempts - 1}
+
+			if _, err := service.Login(context.Background(), "jane@example.com", "wrong", "203.0.113.1"); !errors.Is(err, domain.ErrInvalidCredentials) {
+				t.Fatalf("Login() error = %v, want %v", err, domain.ErrInvalidCredentials)
+			}
+			// even the right password is refused once the limit is reached
+			if _, err := service.Login(context.Background(), "jane@example.com", "correct horse", "203.0.113.1"); !errors.Is(err, domain.ErrTooManyAttempts) {
+				t.Errorf("Login() error = %v, want %v", err, domain.ErrTooManyAttempts)
+			}
+		})
+	}
+}
+
+func TestLoginLimitsConcurrentGuesses(t *testing.T) {
+	defer func(max int) { MaxLoginAttemptsPerAccount = max }(MaxLoginAttemptsPerAccount)
+	MaxLoginAttemptsPerAccount = 5
+	service, _, _ := newUserService()
+	if _, err := service.Signup(context.Background(), "jane@example.com", "correct horse"); err != nil {
+		t.Fatalf("Signup() error = %v", err)
+	}
+
+	// the guesses come from different ips and are all checked at the same time
+	var wg sync.WaitGroup
+	var guessed atomic.Int64
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+			if _, err := service.Login(context.Background(), "jane@example.com", "wrong", ip); errors.Is(err, domain.ErrInvalidCredentials) {
+				guessed.Add(1)
+			}
+		}("203.0.113." + strconv.Itoa(i))
+	}
+	wg.Wait()
+
+	if guessed.Load() != 5 {
+		t.Errorf("%d guesses were checked, want %d", guessed.Load(), MaxLoginAttemptsPerAccount)
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// WebhookPollInterval is how often the queue is checked for due deliveries
+	WebhookPollInterval = 2 * time.Second
+	// WebhookBatchSize is how many deliveries are sent at once at most
+	WebhookBatchSize = 50
+	// WebhookLease is how long a claimed delivery is reserved for the instance sending it, it must
+	// be longer than the timeout of the sender
+	WebhookLease = time.Minute
+	// WebhookMaxAttempts is how often a delivery is tried before it is marked as failed
+	WebhookMaxAttempts = 10
+	// WebhookRetryBase is the delay after the first failed attempt, it doubles with every attempt
+	// up to WebhookRetryMax
+	WebhookRetryBase = 30 * time.Second
+	WebhookRetryMax  = 6 * time.Hour
+)
+
+// WebhookDispatcher sends the queued webhook deliveries and schedules the retries of failed ones.
+// Every instance may run it, deliveries are claimed with SKIP LOCKED so each is sent by one of them.
+type WebhookDispatcher struct {
+	WebhookRepository ports.WebhookRepository
+	WebhookSender     ports.WebhookSender
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewWebhookDispatcher(WebhookRepository ports.WebhookRepository, WebhookSender ports.WebhookSender) ports.WebhookDispatcher {
+	return &WebhookDispatcher{
+		WebhookRepository: WebhookRepository,
+		WebhookSender:     WebhookSender,
+		stop:              make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+}
+
+func (d *WebhookDispatcher) Start(ctx context.Context) error {
+	go d.run()
+
+	return nil
+}
+
+// Stop waits for the deliveries that are being sent until ctx is done, the others stay queued.
+func (d *WebhookDispatcher) Stop(ctx context.Context) error {
+	close(d.stop)
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *WebhookDispatcher) run() {
+	defer close(d.done)
+
+	ticker := time.NewTicker(WebhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// a full batch means more deliveries are probably due, those are sent right away
+		if d.dispatch() >= WebhookBatchSize {
+			select {
+			case <-d.stop:
+				return
+			default:
+				continue
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// dispatch sends a batch of due deliveries concurrently and returns how many were claimed.
+func (d *WebhookDispatcher) dispatch() int {
+	ctx, cancel := context.WithTimeout(context.Background(), WebhookLease)
+	defer cancel()
+
+	deliveries, err := d.WebhookRepository.ClaimDeliveries(ctx, WebhookBatchSize, WebhookLease)
+	if err != nil {
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *domain.WebhookDelivery) {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *domain.WebhookDelivery) {
+	status, err := d.WebhookSender.Send(ctx, delivery)
+	delivery.LastStatusCode = status
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.DeliverySucceeded
+		delivery.NextAttemptAt = time.Now()
+	case delivery.Attempts >= WebhookMaxAttempts:
+		delivery.Status = domain.DeliveryFailed
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = time.Now()
+	default:
+		delivery.Status = domain.DeliveryPending
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = time.Now().Add(webhookBackoff(delivery.Attempts))
+	}
+
+	// a slow receiver may use up the lease, the result is still stored
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	err = d.WebhookRepository.CompleteDelivery(ctx, delivery)
+	switch {
+	case errors.Is(err, domain.ErrDataNotFound):
+		// another instance claimed the delivery after the lease ran out, its attempt is the one kept
+		logger.L.Infow("webhook delivery was claimed again, dropping the outcome of the earlier attempt", "delivery", delivery.DeliveryID)
+	case err != nil:
+		// the lease runs out and the delivery is sent again, receivers drop it by its id
+		logger.L.Errorw("failed to complete webhook delivery", "delivery", delivery.DeliveryID, "error", err.Error())
+	}
+}
+
+// webhookBackoff is the delay before the next attempt after the given number of failed attempts.
+func webhookBackoff(attempts int) time.Duration {
+	delay := WebhookRetryBase
+	for i := 1; i < attempts && delay < WebhookRetryMax; i++ {
+		delay *= 2
+	}
+
+	return min(delay, WebhookRetryMax)
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"URLRotatorGo/internal/core/domain"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	defer func(base, max time.Duration) { WebhookRetryBase, WebhookRetryMax = base, max }(WebhookRetryBase, WebhookRetryMax)
+	WebhookRetryBase, WebhookRetryMax = 30*time.Second, 6*time.Hour
+
+	tests := map[int]time.Duration{
+		0:  30 * time.Second,
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		10: 4*time.Hour + 16*time.Minute,
+		11: 6 * time.Hour,
+		50: 6 * time.Hour,
+	}
+	for attempts, want := range tests {
+		if got := webhookBackoff(attempts); got != want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestDispatchRetriesFailedDeliveries(t *testing.T) {
+	repository := &fakeWebhookRepository{deliveries: []*domain.WebhookDelivery{{ID: 1, DeliveryID: "d1", Status: domain.DeliveryPending}}}
+	sender := &fakeWebhookSender{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+	dispatcher := NewWebhookDispatcher(repository, sender).(*WebhookDispatcher)
+	delivery := repository.deliveries[0]
+
+	for attempt, status := range []int{http.StatusInternalServerError, http.StatusBadGateway} {
+		before := time.Now()
+		if claimed := dispatcher.dispatch(); claimed != 1 {
+			t.Fatalf("dispatch() claimed %d deliveries, want 1", claimed)
+		}
+
+		retryAt := before.Add(webhookBackoff(attempt + 1))
+		if delivery.Status != domain.DeliveryPending || delivery.LastStatusCode != status || delivery.LastError == "" {
+			t.Errorf("attempt %d: delivery is %s with %d %q, want pending with the error", attempt+1, delivery.Status, delivery.LastStatusCode, delivery.LastError)
+		}
+		if delivery.NextAttemptAt.Before(retryAt) || delivery.NextAttemptAt.After(retryAt.Add(time.Second)) {
+			t.Errorf("attempt %d: next attempt at %v, want it after %v", attempt+1, delivery.NextAttemptAt, webhookBackoff(attempt+1))
+		}
+	}
+
+	dispatcher.dispatch()
+	if delivery.Status != domain.DeliverySucceeded || delivery.LastStatusCode != http.StatusOK || delivery.LastError != "" {
+		t.Errorf("delivery is %s with %d %q, want it succeeded", delivery.Status, delivery.LastStatusCode, delivery.LastError)
+	}
+	if dispatcher.dispatch() != 0 {
+		t.Errorf("dispatch() claimed a delivery that succeeded")
+	}
+	// every attempt is sent with the same delivery id, so receivers can drop duplicates
+	if len(sender.sent) != 3 || sender.sent[0] != "d1" || sender.sent[2] != "d1" {
+		t.Errorf("sent %v, want d1 three times", sender.sent)
+	}
+}
+
+func TestDispatchGivesUpAfterMaxAttempts(t *testing.T) {
+	defer func(attempts int) { WebhookMaxAttempts = attempts }(WebhookMaxAttempts)
+	WebhookMaxAttempts = 3
+
+	repository := &fakeWebhookRepository{deliveries: []*domain.WebhookDelivery{{ID: 1, DeliveryID: "d1", Status: domain.DeliveryPending}}}
+	sender := &fakeWebhookSender{statuses: []int{http.StatusGone, http.StatusGone, http.StatusGone, http.StatusGone}}
+	dispatcher := NewWebhookDispatcher(repository, sender).(*WebhookDispatcher)
+
+	for i := 0; i < 4; i++ {
+		dispatcher.dispatch()
+	}
+
+	delivery := repository.deliveries[0]
+	if delivery.Status != domain.DeliveryFailed || delivery.Attempts != 3 || delivery.LastStatusCode != http.StatusGone {
+		t.Errorf("delivery is %s after %d attempts with %d, want failed after 3", delivery.Status, delivery.Attempts, delivery.LastStatusCode)
+	}
+	if len(sender.sent) != 3 {
+		t.Errorf("sent %d times, want 3", len(sender.sent))
+	}
+}
+
+func TestDispatchCompletesAfterTheLease(t *testing.T) {
+	defer func(lease time.Duration) { WebhookLease = lease }(WebhookLease)
+	WebhookLease = 50 * time.Millisecond
+
+	repository := &fakeWebhookRepository{deliveries: []*domain.WebhookDelivery{{ID: 1, DeliveryID: "d1", Status: domain.DeliveryPending}}}
+	dispatcher := NewWebhookDispatcher(repository, &fakeWebhookSender{wait: true}).(*WebhookDispatcher)
+
+	dispatcher.dispatch()
+
+	// the receiver used up the lease, the failed attempt is stored anyway
+	if repository.completed != 1 || repository.completeErr != nil {
+		t.Errorf("completed %d deliveries with ctx error %v, want one with a live ctx", repository.completed, repository.completeErr)
+	}
+	if delivery := repository.deliveries[0]; delivery.Status != domain.DeliveryPending || delivery.LastError == "" {
+		t.Errorf("delivery is %s with %q, want pending with the error", delivery.Status, delivery.LastError)
+	}
+}
+
+// reclaimingSender has another instance claim and deliver the delivery while the first attempt is
+// still being sent, as it happens when a receiver takes longer than the lease.
+type reclaimingSender struct {
+	repository *fakeWebhookRepository
+}
+
+func (s *reclaimingSender) Send(ctx context.Context, delivery *domain.WebhookDelivery) (int, error) {
+	claimed, _ := s.repository.ClaimDeliveries(ctx, 1, WebhookLease)
+	claimed[0].Status = domain.DeliverySucceeded
+	if err := s.repository.CompleteDelivery(ctx, claimed[0]); err != nil {
+		return 0, err
+	}
+
+	return http.StatusBadGateway, errors.New("unexpected status 502")
+}
+
+func TestDispatchKeepsTheOutcomeOfTheLatestClaim(t *testing.T) {
+	repository := &fakeWebhookRepository{deliveries: []*domain.WebhookDelivery{{ID: 1, DeliveryID: "d1", Status: domain.DeliveryPending}}}
+	dispatcher := NewWebhookDispatcher(repository, &reclaimingSender{repository: repository}).(*WebhookDispatcher)
+
+	dispatcher.dispatch()
+
+	// the failed first attempt finished last, it must not turn the delivery back to pending
+	if delivery := repository.deliveries[0]; delivery.Status != domain.DeliverySucceeded || delivery.Attempts != 2 || delivery.LastError != "" {
+		t.Errorf("delivery is %s after %d attempts with %q, want the second attempt's success", delivery.Status, delivery.Attempts, delivery.LastError)
+	}
+}
+
+func TestWebhookDispatcherSendsQueuedDeliveries(t *testing.T) {
+	defer func(interval time.Duration) { WebhookPollInterval = interval }(WebhookPollInterval)
+	WebhookPollInterval = 10 * time.Millisecond
+
+	repository := &fakeWebhookRepository{}
+	for i, id := range []string{"d1", "d2", "d3"} {
+		repository.deliveries = append(repository.deliveries, &domain.WebhookDelivery{ID: int64(i + 1), DeliveryID: id, Status: domain.DeliveryPending})
+	}
+	sender := &fakeWebhookSender{}
+	dispatcher := NewWebhookDispatcher(repository, sender)
+	if err := dispatcher.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		repository.mu.Lock()
+		completed := repository.completed
+		repository.mu.Unlock()
+		if completed == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("completed %d deliveries within a second, want 3", completed)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := dispatcher.Stop(ctx); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+	for _, delivery := range repository.deliveries {
+		if delivery.Status != domain.DeliverySucceeded {
+			t.Errorf("delivery %s is %s, want succeeded", delivery.DeliveryID, delivery.Status)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	DefaultDeliveryLimit = 50
+	MaxDeliveryLimit     = 200
+)
+
+type WebhookService struct {
+	WebhookRepository ports.WebhookRepository
+	AuditRepository   ports.AuditRepository
+	Policy            ports.Policy
+}
+
+func NewWebhookService(WebhookRepository ports.WebhookRepository, AuditRepository ports.AuditRepository, Policy ports.Policy) ports.WebhookService {
+	return &WebhookService{
+		WebhookRepository: WebhookRepository,
+		AuditRepository:   AuditRepository,
+		Policy:            Policy,
+	}
+}
+
+// RegisterWebhook registers a webhook for the links of a workspace or, without a workspace, for
+// the personal links of the user. Only admins may register webhooks for every link, other
+// principals without a user need a workspace. The secret is generated here and only returned by
+// this call.
+func (s *WebhookService) RegisterWebhook(ctx context.Context, webhook *domain.Webhook) (*domain.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	events, ok := normalizeWebhookEvents(webhook.Events)
+	if !ok || !isWebhookURL(webhook.URL) {
+		return nil, domain.ErrInvalidWebhook
+	}
+	webhook.Events = events
+
+	if webhook.WorkspaceID != 0 {
+		if err := s.Policy.Authorize(ctx, webhook.WorkspaceID, domain.ActionManageWebhooks); err != nil {
+			return nil, err
+		}
+	} else {
+		scope, err := s.Policy.LinkScope(ctx, 0)
+		if err != nil {
+			return nil, err
+		}
+		if scope.Unowned {
+			return nil, domain.ErrForbidden
+		}
+		webhook.OwnerID = scope.OwnerID
+	}
+
+	secret, err := pkg.GenerateToken()
+	if err != nil {
+		logger.L.Errorw("failed to generate webhook secret", "error", err.Error())
+		return nil, domain.ErrInternalServerError
+	}
+	webhook.Secret = secret
+	webhook.Enabled = true
+
+	saved, err := s.WebhookRepository.Save(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditWebhookCreate, domain.AuditTargetWebhook, strconv.Itoa(saved.ID), saved.WorkspaceID,
+		nil, auditWebhook(saved)))
+
+	return saved, nil
+}
+
+// ListWebhooks returns the webhooks of a workspace, without a workspace users get the webhooks of
+// their personal links, admins every webhook and other principals none.
+func (s *WebhookService) ListWebhooks(ctx context.Context, workspaceID int) ([]*domain.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if workspaceID != 0 {
+		if err := s.Policy.Authorize(ctx, workspaceID, domain.ActionManageWebhooks); err != nil {
+			return nil, err
+		}
+		return s.WebhookRepository.List(ctx, domain.LinkScope{WorkspaceID: workspaceID})
+	}
+
+	scope, err := s.Policy.LinkScope(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	if scope.Unowned {
+		return []*domain.Webhook{}, nil
+	}
+
+	return s.WebhookRepository.List(ctx, scope)
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id int, update domain.WebhookUpdate) (*domain.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if update.URL != nil && !isWebhookURL(*update.URL) {
+		return nil, domain.ErrInvalidWebhook
+	}
+	if update.Events != nil {
+		events, ok := normalizeWebhookEvents(update.Events)
+		if !ok {
+			return nil, domain.ErrInvalidWebhook
+		}
+		update.Events = events
+	}
+
+	before, err := s.authorize(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.WebhookRepository.Update(ctx, id, update)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditWebhookUpdate, domain.AuditTargetWebhook, strconv.Itoa(id), webhook.WorkspaceID,
+		auditWebhook(before), auditWebhook(webhook)))
+
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook, its pending deliveries are dropped.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	webhook, err := s.authorize(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = s.WebhookRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditWebhookDelete, domain.AuditTargetWebhook, strconv.Itoa(id), webhook.WorkspaceID,
+		auditWebhook(webhook), nil))
+
+	return nil
+}
+
+// ListDeliveries returns the delivery log of a webhook newest first, before continues a listing
+// below the given delivery.
+func (s *WebhookService) ListDeliveries(ctx context.Context, id int, before int64, limit int) ([]*domain.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if _, err := s.authorize(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultDeliveryLimit
+	}
+	if limit > MaxDeliveryLimit {
+		limit = MaxDeliveryLimit
+	}
+
+	return s.WebhookRepository.ListDeliveries(ctx, id, before, limit)
+}
+
+// Redeliver queues the payload of a delivery again, e.g. after the receiver was fixed. The copy
+// gets a new delivery id and is sent with the next batch.
+func (s *WebhookService) Redeliver(ctx context.Context, id int, deliveryID int64) (*domain.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	webhook, err := s.authorize(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.WebhookRepository.Redeliver(ctx, id, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditWebhookRedeliver, domain.AuditTargetWebhook, strconv.Itoa(id), webhook.WorkspaceID,
+		nil, map[string]any{"redelivered": deliveryID, "delivery_id": delivery.DeliveryID}))
+
+	return delivery, nil
+}
+
+// authorize checks that the principal may manage the webhook. Webhooks of a workspace follow the
+// roles of the workspace, the others are only accessible by their owner and admins.
+func (s *WebhookService) authorize(ctx context.Context, id int) (*domain.Webhook, error) {
+	webhook, err := s.WebhookRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook.WorkspaceID != 0 {
+		if err = s.Policy.Authorize(ctx, webhook.WorkspaceID, domain.ActionManageWebhooks); err != nil {
+			return nil, err
+		}
+		return webhook, nil
+	}
+
+	scope, err := s.Policy.LinkScope(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	if scope.Unowned || (scope.OwnerID != 0 && scope.OwnerID != webhook.OwnerID) {
+		return nil, domain.ErrDataNotFound
+	}
+
+	return webhook, nil
+}
+
+// normalizeWebhookEvents drops duplicate events, it returns false if there is no event or an
+// unknown one.
+func normalizeWebhookEvents(events []string) ([]string, bool) {
+	seen := make(map[string]bool, len(events))
+	normalized := make([]string, 0, len(events))
+	for _, event := range events {
+		if !domain.IsWebhookEvent(event) {
+			return nil, false
+		}
+		if !seen[event] {
+			seen[event] = true
+			normalized = append(normalized, event)
+		}
+	}
+
+	return normalized, len(normalized) > 0
+}
+
+func isWebhookURL(value string) bool {
+	target, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	return (target.Scheme == "http" || target.Scheme == "https") && target.Host != "" && target.User == nil
+}
+
+// auditWebhook leaves out the secret.
+func auditWebhook(webhook *domain.Webhook) map[string]any {
+	return map[string]any{
+		"url":      webhook.URL,
+		"owner_id": webhook.OwnerID,
+		"events":   webhook.Events,
+		"enabled":  webhook.Enabled,
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"URLRotatorGo/infra/logger"
+	"URLRotatorGo/internal/core/domain"
+	"URLRotatorGo/internal/core/ports"
+	"URLRotatorGo/pkg"
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var InvitationTTL = 7 * 24 * time.Hour
+
+type WorkspaceService struct {
+	WorkspaceRepository ports.WorkspaceRepository
+	UserRepository      ports.UserRepository
+	AuditRepository     ports.AuditRepository
+	Policy              ports.Policy
+}
+
+func NewWorkspaceService(WorkspaceRepository ports.WorkspaceRepository, UserRepository ports.UserRepository, AuditRepository ports.AuditRepository, Policy ports.Policy) ports.WorkspaceService {
+	return &WorkspaceService{
+		WorkspaceRepository: WorkspaceRepository,
+		UserRepository:      UserRepository,
+		AuditRepository:     AuditRepository,
+		Policy:              Policy,
+	}
+}
+
+// CreateWorkspace creates a workspace owned by the user of the context.
+func (s *WorkspaceService) CreateWorkspace(ctx context.Context, name string) (*domain.Workspace, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	userID := domain.PrincipalFromContext(ctx).UserID
+	if userID == 0 {
+		return nil, domain.ErrUnauthorized
+	}
+
+	workspace, err := s.WorkspaceRepository.Create(ctx, &domain.Workspace{Name: strings.TrimSpace(name)}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditWorkspaceCreate, domain.AuditTargetWorkspace, strconv.Itoa(workspace.ID), workspace.ID,
+		nil, map[string]any{"name": workspace.Name}))
+
+	return workspace, nil
+}
+
+func (s *WorkspaceService) ListWorkspaces(ctx context.Context) ([]*domain.Workspace, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	userID := domain.PrincipalFromContext(ctx).UserID
+	if userID == 0 {
+		return nil, domain.ErrUnauthorized
+	}
+
+	return s.WorkspaceRepository.ListByUser(ctx, userID)
+}
+
+func (s *WorkspaceService) ListMembers(ctx context.Context, workspaceID int) ([]*domain.Member, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if err := s.Policy.Authorize(ctx, workspaceID, domain.ActionReadLinks); err != nil {
+		return nil, err
+	}
+
+	return s.WorkspaceRepository.ListMembers(ctx, workspaceID)
+}
+
+// SetMemberRole changes the role of a member. Only owners may promote to or demote from owner.
+func (s *WorkspaceService) SetMemberRole(ctx context.Context, workspaceID, userID int, value string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	role, ok := domain.ParseRole(value)
+	if !ok {
+		return domain.ErrInvalidRole
+	}
+
+	current, err := s.authorizeMemberChange(ctx, workspaceID, userID, role)
+	if err != nil {
+		return err
+	}
+
+	if err = s.WorkspaceRepository.SetMemberRole(ctx, workspaceID, userID, role); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditMemberRole, domain.AuditTargetMember, strconv.Itoa(userID), workspaceID,
+		map[string]any{"role": current}, map[string]any{"role": role}))
+
+	return nil
+}
+
+// RemoveMember removes a member from the workspace. Every member may leave a workspace on their own.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, workspaceID, userID int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	var current domain.Role
+	var err error
+	if userID == domain.PrincipalFromContext(ctx).UserID {
+		// being a member is all it takes to leave
+		current, err = s.WorkspaceRepository.GetRole(ctx, workspaceID, userID)
+	} else {
+		current, err = s.authorizeMemberChange(ctx, workspaceID, userID, "")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = s.WorkspaceRepository.RemoveMember(ctx, workspaceID, userID); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditMemberRemove, domain.AuditTargetMember, strconv.Itoa(userID), workspaceID,
+		map[string]any{"role": current}, nil))
+
+	return nil
+}
+
+// InviteMember creates an invitation for the email. The returned token is the only time it can be
+// seen, whoever signs in with the email can accept it until it expires.
+func (s *WorkspaceService) InviteMember(ctx context.Context, workspaceID int, email, value string) (*domain.Invitation, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	role, ok := domain.ParseRole(value)
+	if !ok {
+		return nil, "", domain.ErrInvalidRole
+	}
+
+	action := domain.ActionManageMembers
+	if role == domain.RoleOwner {
+		action = domain.ActionManageOwners
+	}
+	if err := s.Policy.Authorize(ctx, workspaceID, action); err != nil {
+		return nil, "", err
+	}
+
+	token, err := pkg.GenerateToken()
+	if err != nil {
+		logger.L.Errorw("failed to generate invitation token", "error", err.Error())
+		return nil, "", domain.ErrInternalServerError
+	}
+
+	invitation, err := s.WorkspaceRepository.SaveInvitation(ctx, &domain.Invitation{
+		WorkspaceID: workspaceID,
+		Email:       normalizeEmail(email),
+		Role:        role,
+		InvitedBy:   domain.PrincipalFromContext(ctx).UserID,
+		ExpiresAt:   time.Now().Add(InvitationTTL),
+	}, pkg.HashToken(token))
+	if err != nil {
+		return nil, "", err
+	}
+
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditInvitationCreate, domain.AuditTargetInvitation, strconv.Itoa(invitation.ID), workspaceID,
+		nil, map[string]any{"email": invitation.Email, "role": invitation.Role, "expires_at": invitation.ExpiresAt}))
+
+	return invitation, token, nil
+}
+
+func (s *WorkspaceService) ListInvitations(ctx context.Context, workspaceID int) ([]*domain.Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if err := s.Policy.Authorize(ctx, workspaceID, domain.ActionManageMembers); err != nil {
+		return nil, err
+	}
+
+	return s.WorkspaceRepository.ListInvitations(ctx, workspaceID)
+}
+
+func (s *WorkspaceService) RevokeInvitation(ctx context.Context, workspaceID, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	if err := s.Policy.Authorize(ctx, workspaceID, domain.ActionManageMembers); err != nil {
+		return err
+	}
+
+	if err := s.WorkspaceRepository.RevokeInvitation(ctx, workspaceID, id); err != nil {
+		return err
+	}
+
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditInvitationRevoke, domain.AuditTargetInvitation, strconv.Itoa(id), workspaceID,
+		map[string]any{"revoked": false}, map[string]any{"revoked": true}))
+
+	return nil
+}
+
+// AcceptInvitation adds the user of the context to the workspace of the invitation. The
+// invitation only works for the email it was sent to.
+func (s *WorkspaceService) AcceptInvitation(ctx context.Context, token string) (*domain.Invitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	userID := domain.PrincipalFromContext(ctx).UserID
+	if userID == 0 {
+		return nil, domain.ErrUnauthorized
+	}
+
+	invitation, err := s.WorkspaceRepository.GetInvitationByHash(ctx, pkg.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.UserRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email != invitation.Email {
+		return nil, domain.ErrDataNotFound
+	}
+	if !invitation.IsPending() {
+		return nil, domain.ErrInvitationExpired
+	}
+
+	if err = s.WorkspaceRepository.AcceptInvitation(ctx, invitation.ID, userID); err != nil {
+		return nil, err
+	}
+
+	recordAudit(ctx, s.AuditRepository, domain.NewAuditEntry(domain.AuditInvitationAccept, domain.AuditTargetInvitation, strconv.Itoa(invitation.ID), invitation.WorkspaceID,
+		nil, map[string]any{"user_id": userID, "role": invitation.Role}))
+
+	return invitation, nil
+}
+
+// authorizeMemberChange checks that the principal may change the member to the role, an empty
+// role means the member is removed. Changes that involve the owner role require an owner. It
+// returns the current role of the member.
+func (s *WorkspaceService) authorizeMemberChange(ctx context.Context, workspaceID, userID int, role domain.Role) (domain.Role, error) {
+	if err := s.Policy.Authorize(ctx, workspaceID, domain.ActionManageMembers); err != nil {
+		return "", err
+	}
+
+	current, err := s.WorkspaceRepository.GetRole(ctx, workspaceID, userID)
+	if err != nil {
+		return "", err
+	}
+	if current == domain.RoleOwner || role == domain.RoleOwner {
+		if err = s.Policy.Authorize(ctx, workspaceID, domain.ActionManageOwners); err != nil {
+			return "", err
+		}
+	}
+
+	return current, nil
+}
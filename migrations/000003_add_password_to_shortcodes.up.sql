@@ -0,0 +1 @@
+ALTER TABLE shortcodes ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';
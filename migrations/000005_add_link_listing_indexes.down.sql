@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS urls_original_search_idx;
+DROP INDEX IF EXISTS shortcodes_total_hit_id_idx;
+DROP INDEX IF EXISTS shortcodes_created_at_id_idx;
+DROP INDEX IF EXISTS urls_shortcode_idx;
@@ -0,0 +1,7 @@
+CREATE INDEX IF NOT EXISTS urls_shortcode_idx ON urls (shortcode);
+CREATE INDEX IF NOT EXISTS shortcodes_created_at_id_idx ON shortcodes (created_at, id);
+CREATE INDEX IF NOT EXISTS shortcodes_total_hit_id_idx ON shortcodes (total_hit, id);
+
+-- punctuation is replaced by spaces so that every part of a URL (host, path, query) becomes a searchable word
+CREATE INDEX IF NOT EXISTS urls_original_search_idx ON urls
+    USING GIN (to_tsvector('simple', regexp_replace(original, '[^[:alnum:]]+', ' ', 'g')));
@@ -0,0 +1 @@
+ALTER TABLE urls DROP COLUMN IF EXISTS enabled;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE urls ADD COLUMN enabled BOOLEAN NOT NULL DEFAULT TRUE;
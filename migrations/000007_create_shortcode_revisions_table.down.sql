@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS shortcode_revisions;
+DROP FUNCTION IF EXISTS shortcode_revisions_immutable();
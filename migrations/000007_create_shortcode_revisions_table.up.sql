@@ -0,0 +1,22 @@
+CREATE TABLE shortcode_revisions (
+    id SERIAL PRIMARY KEY,
+    shortcode_id INT NOT NULL REFERENCES shortcodes(id) ON DELETE CASCADE,
+    revision INT NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    snapshot JSONB NOT NULL,
+    diff JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (shortcode_id, revision)
+);
+
+-- revisions are append-only
+CREATE FUNCTION shortcode_revisions_immutable() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'shortcode revisions are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER shortcode_revisions_no_update
+    BEFORE UPDATE ON shortcode_revisions
+    FOR EACH ROW EXECUTE FUNCTION shortcode_revisions_immutable();
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS shortcodes_fingerprint_idx;
+ALTER TABLE shortcodes DROP COLUMN IF EXISTS fingerprint;
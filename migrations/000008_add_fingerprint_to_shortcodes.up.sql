@@ -0,0 +1,3 @@
+-- hash of the normalized destination set and strategy, NULL for password protected links
+ALTER TABLE shortcodes ADD COLUMN fingerprint TEXT NULL;
+CREATE INDEX IF NOT EXISTS shortcodes_fingerprint_idx ON shortcodes (fingerprint) WHERE fingerprint IS NOT NULL;
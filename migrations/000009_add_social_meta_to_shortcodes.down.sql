@@ -0,0 +1,3 @@
+ALTER TABLE shortcodes DROP COLUMN IF EXISTS og_image;
+ALTER TABLE shortcodes DROP COLUMN IF EXISTS og_description;
+ALTER TABLE shortcodes DROP COLUMN IF EXISTS og_title;
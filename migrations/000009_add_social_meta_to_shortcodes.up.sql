@@ -0,0 +1,3 @@
+ALTER TABLE shortcodes ADD COLUMN og_title TEXT NOT NULL DEFAULT '';
+ALTER TABLE shortcodes ADD COLUMN og_description TEXT NOT NULL DEFAULT '';
+ALTER TABLE shortcodes ADD COLUMN og_image TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE shortcodes DROP COLUMN IF EXISTS redirect_delay;
+ALTER TABLE shortcodes DROP COLUMN IF EXISTS redirect_type;
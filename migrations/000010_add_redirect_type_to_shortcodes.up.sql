@@ -0,0 +1,2 @@
+ALTER TABLE shortcodes ADD COLUMN redirect_type TEXT NOT NULL DEFAULT '302';
+ALTER TABLE shortcodes ADD COLUMN redirect_delay INTEGER NOT NULL DEFAULT 0;
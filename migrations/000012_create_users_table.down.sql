@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS shortcodes_owner_fingerprint_idx;
+CREATE INDEX IF NOT EXISTS shortcodes_fingerprint_idx ON shortcodes (fingerprint) WHERE fingerprint IS NOT NULL;
+DROP INDEX IF EXISTS shortcodes_owner_created_at_id_idx;
+ALTER TABLE shortcodes DROP COLUMN IF EXISTS owner_id;
+DROP TABLE IF EXISTS users;
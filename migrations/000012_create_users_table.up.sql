@@ -0,0 +1,15 @@
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- links created without an account, e.g. through the public form, have no owner
+ALTER TABLE shortcodes ADD COLUMN owner_id INT NULL REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS shortcodes_owner_created_at_id_idx ON shortcodes (owner_id, created_at, id);
+
+-- duplicates are only looked up among the links of the same owner
+DROP INDEX IF EXISTS shortcodes_fingerprint_idx;
+CREATE INDEX IF NOT EXISTS shortcodes_owner_fingerprint_idx ON shortcodes (owner_id, fingerprint) WHERE fingerprint IS NOT NULL;
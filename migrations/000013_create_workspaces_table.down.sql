@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS shortcodes_workspace_created_at_id_idx;
+ALTER TABLE shortcodes DROP COLUMN IF EXISTS workspace_id;
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,4 @@
+ALTER TABLE urls ALTER COLUMN shortcode TYPE VARCHAR(255);
+ALTER TABLE shortcodes ALTER COLUMN code TYPE VARCHAR(255);
+DROP INDEX IF EXISTS shortcodes_code_pattern_idx;
+DROP TABLE IF EXISTS domains;
@@ -0,0 +1,17 @@
+CREATE TABLE domains (
+    id SERIAL PRIMARY KEY,
+    host VARCHAR(253) UNIQUE NOT NULL,
+    workspace_id INT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    root_redirect TEXT NOT NULL DEFAULT '',
+    not_found_url TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS domains_workspace_id_idx ON domains (workspace_id);
+
+-- shortcodes of a custom domain are stored as "host/code", prefix lookups need the C collation
+CREATE INDEX IF NOT EXISTS shortcodes_code_pattern_idx ON shortcodes (code varchar_pattern_ops);
+
+-- room for the longest host in front of the longest alias
+ALTER TABLE shortcodes ALTER COLUMN code TYPE VARCHAR(320);
+ALTER TABLE urls ALTER COLUMN shortcode TYPE VARCHAR(320);
@@ -0,0 +1,15 @@
+-- one row per redirect, partitioned by month so old months can be detached or dropped cheaply.
+-- partitions are created ahead of time by the application, shortcode has no foreign key so the
+-- history of deleted links is kept
+CREATE TABLE click_events (
+    clicked_at TIMESTAMP NOT NULL,
+    shortcode VARCHAR(320) NOT NULL,
+    url_id INT NOT NULL,
+    ip_hash VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    referrer VARCHAR(1024) NOT NULL DEFAULT '',
+    country VARCHAR(2) NOT NULL DEFAULT '',
+    request_id VARCHAR(64) NOT NULL DEFAULT ''
+) PARTITION BY RANGE (clicked_at);
+CREATE INDEX IF NOT EXISTS click_events_shortcode_idx ON click_events (shortcode, clicked_at);
+CREATE INDEX IF NOT EXISTS click_events_clicked_at_idx ON click_events USING BRIN (clicked_at);
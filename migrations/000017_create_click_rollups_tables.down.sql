@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS click_dimensions_hourly;
+DROP TABLE IF EXISTS click_rollups_daily;
+DROP TABLE IF EXISTS click_rollups_hourly;
+ALTER TABLE click_events DROP COLUMN IF EXISTS device;
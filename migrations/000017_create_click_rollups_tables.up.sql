@@ -0,0 +1,31 @@
+ALTER TABLE click_events ADD COLUMN IF NOT EXISTS device VARCHAR(10) NOT NULL DEFAULT '';
+
+-- clicks per destination and UTC hour, any timezone with whole hour offsets can be bucketed from it
+CREATE TABLE click_rollups_hourly (
+    bucket TIMESTAMP NOT NULL,
+    shortcode VARCHAR(320) NOT NULL,
+    url_id INT NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (shortcode, bucket, url_id)
+);
+
+-- clicks per destination and day of the timezone the rollup ran in, so a changed app.timezone
+-- never mixes days of different timezones
+CREATE TABLE click_rollups_daily (
+    timezone VARCHAR(64) NOT NULL,
+    day DATE NOT NULL,
+    shortcode VARCHAR(320) NOT NULL,
+    url_id INT NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (timezone, shortcode, day, url_id)
+);
+
+-- clicks per UTC hour and referrer host, country or device
+CREATE TABLE click_dimensions_hourly (
+    bucket TIMESTAMP NOT NULL,
+    shortcode VARCHAR(320) NOT NULL,
+    dimension VARCHAR(20) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (shortcode, dimension, bucket, value)
+);
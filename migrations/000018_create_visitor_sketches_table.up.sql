@@ -0,0 +1,12 @@
+-- daily HyperLogLog sketches of the visitors, persisted from redis. url_id 0 holds the visitors of
+-- all destinations, sketches of several days are merged to count weekly or monthly visitors
+CREATE TABLE visitor_sketches (
+    day DATE NOT NULL,
+    shortcode VARCHAR(320) NOT NULL,
+    url_id INT NOT NULL DEFAULT 0,
+    sketch BYTEA NOT NULL,
+    visitors BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (shortcode, day, url_id)
+);
+CREATE INDEX IF NOT EXISTS visitor_sketches_day_idx ON visitor_sketches (day);
@@ -0,0 +1,2 @@
+-- human, bot, suspected_bot or prefetch, only humans are counted unless analytics.count_bots is set
+ALTER TABLE click_events ADD COLUMN IF NOT EXISTS traffic VARCHAR(16) NOT NULL DEFAULT 'human';
@@ -0,0 +1,28 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// APIKeyPrefix marks our keys, so that leaked keys are easy to recognize in logs and code.
+const APIKeyPrefix = "rk_"
+
+// GenerateAPIKey returns a new random key and the short prefix it is displayed by.
+func GenerateAPIKey() (string, string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:len(APIKeyPrefix)+8], nil
+}
+
+// HashAPIKey hashes a key for storage. Keys are long and random, so a plain sha256 is enough.
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
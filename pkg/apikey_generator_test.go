@@ -0,0 +1,31 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey() error = %v", err)
+	}
+	if !strings.HasPrefix(key, APIKeyPrefix) || !strings.HasPrefix(key, prefix) || len(prefix) != len(APIKeyPrefix)+8 {
+		t.Errorf("GenerateAPIKey() = %q, %q, want a %s key starting with its prefix", key, prefix, APIKeyPrefix)
+	}
+
+	other, _, err := GenerateAPIKey()
+	if err != nil || other == key {
+		t.Errorf("GenerateAPIKey() returned the same key twice")
+	}
+}
+
+func TestHashAPIKey(t *testing.T) {
+	hash := HashAPIKey("rk_example")
+	if hash != HashAPIKey("rk_example") {
+		t.Error("HashAPIKey() is not deterministic")
+	}
+	if hash == HashAPIKey("rk_other") || strings.Contains(hash, "example") || len(hash) != 64 {
+		t.Errorf("HashAPIKey() = %q, want a sha256 hex digest", hash)
+	}
+}
@@ -0,0 +1,145 @@
+package pkg
+
+import (
+	"bufio"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// knownBots are the User-Agent fragments of crawlers, monitors, scripts and the link scanners of
+// email security gateways that announce themselves.
+var knownBots = []string{
+	"bot/",
+	"bot;",
+	"crawler",
+	"spider",
+	"googlebot",
+	"bingbot",
+	"bingpreview",
+	"yandex",
+	"baiduspider",
+	"duckduckbot",
+	"applebot",
+	"ahrefs",
+	"semrush",
+	"petalbot",
+	"bytespider",
+	"gptbot",
+	"uptimerobot",
+	"pingdom",
+	"statuscake",
+	"curl/",
+	"wget/",
+	"python-requests",
+	"python-urllib",
+	"aiohttp",
+	"go-http-client",
+	"okhttp",
+	"java/",
+	"libwww-perl",
+	"httpclient",
+	"postmanruntime",
+	"insomnia",
+	"barracuda",
+	"proofpoint",
+	"mimecast",
+	"safelinks",
+	"symantec",
+	"trendmicro",
+	"forcepoint",
+}
+
+// headlessBrowsers are the User-Agent fragments of browsers driven by scripts.
+var headlessBrowsers = []string{
+	"headless",
+	"phantomjs",
+	"puppeteer",
+	"playwright",
+	"selenium",
+	"webdriver",
+	"slimerjs",
+}
+
+// IsKnownBot reports whether the User-Agent belongs to a bot that identifies itself as one.
+func IsKnownBot(userAgent string) bool {
+	return IsLinkUnfurler(userAgent) || containsAny(strings.ToLower(userAgent), knownBots)
+}
+
+// IsHeadlessBrowser reports whether the User-Agent belongs to a browser without a user.
+func IsHeadlessBrowser(userAgent string) bool {
+	return containsAny(strings.ToLower(userAgent), headlessBrowsers)
+}
+
+// IsPrefetch reports whether the values of the Sec-Purpose, Purpose or X-Moz headers mark the
+// request as a prefetch or prerender of the browser instead of a click.
+func IsPrefetch(values ...string) bool {
+	for _, value := range values {
+		value = strings.ToLower(value)
+		if strings.Contains(value, "prefetch") || strings.Contains(value, "prerender") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsAny(s string, fragments []string) bool {
+	for _, fragment := range fragments {
+		if strings.Contains(s, fragment) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IPRanges is a list of networks, e.g. of the datacenters of cloud providers.
+type IPRanges []netip.Prefix
+
+// LoadIPRanges reads one CIDR or address per line, empty lines and lines starting with # are skipped.
+func LoadIPRanges(path string) (IPRanges, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var ranges IPRanges
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(line)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(line)
+			if addrErr != nil {
+				return nil, err
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		ranges = append(ranges, prefix.Masked())
+	}
+
+	return ranges, scanner.Err()
+}
+
+// Contains reports whether the ip address is in one of the ranges.
+func (r IPRanges) Contains(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range r {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
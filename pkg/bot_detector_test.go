@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const chrome = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+func TestIsKnownBot(t *testing.T) {
+	for userAgent, want := range map[string]bool{
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)": true,
+		"Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)":  true,
+		"curl/8.4.0":             true,
+		"python-requests/2.31.0": true,
+		"Go-http-client/1.1":     true,
+		"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)": true,
+		"Mozilla/5.0 (compatible; Barracuda Sentinel)":               true,
+		chrome: false,
+		"":     false,
+	} {
+		if got := IsKnownBot(userAgent); got != want {
+			t.Errorf("IsKnownBot(%q) = %v, want %v", userAgent, got, want)
+		}
+	}
+}
+
+func TestIsHeadlessBrowser(t *testing.T) {
+	for userAgent, want := range map[string]bool{
+		"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0 Safari/537.36": true,
+		"Mozilla/5.0 PhantomJS/2.1.1": true,
+		chrome:                        false,
+	} {
+		if got := IsHeadlessBrowser(userAgent); got != want {
+			t.Errorf("IsHeadlessBrowser(%q) = %v, want %v", userAgent, got, want)
+		}
+	}
+}
+
+func TestIsPrefetch(t *testing.T) {
+	tests := map[string]struct {
+		values []string
+		want   bool
+	}{
+		"sec-purpose":   {[]string{"prefetch;prerender", "", ""}, true},
+		"purpose":       {[]string{"", "prefetch", ""}, true},
+		"x-moz":         {[]string{"", "", "prefetch"}, true},
+		"prerender":     {[]string{"Prerender", "", ""}, true},
+		"navigation":    {[]string{"", "", ""}, false},
+		"other purpose": {[]string{"", "preview", ""}, false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := IsPrefetch(test.values...); got != test.want {
+				t.Errorf("IsPrefetch(%q) = %v, want %v", test.values, got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadIPRanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ranges.txt")
+	content := "# aws\n3.0.0.0/9\n\n  2600:1f00::/24  \n198.51.100.7\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	ranges, err := LoadIPRanges(path)
+	if err != nil {
+		t.Fatalf("LoadIPRanges() error = %v", err)
+	}
+	if len(ranges) != 3 {
+		t.Fatalf("LoadIPRanges() = %v, want 3 ranges", ranges)
+	}
+
+	for ip, want := range map[string]bool{
+		"3.12.0.1":        true,
+		"::ffff:3.12.0.1": true,
+		"2600:1f00::1":    true,
+		"198.51.100.7":    true,
+		"198.51.100.8":    false,
+		"203.0.113.1":     false,
+		"not an ip":       false,
+	} {
+		if got := ranges.Contains(ip); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", ip, got, want)
+		}
+	}
+
+	if err = os.WriteFile(path, []byte("3.0.0.0/99\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if _, err = LoadIPRanges(path); err == nil {
+		t.Errorf("LoadIPRanges() of an invalid range succeeded")
+	}
+}
@@ -0,0 +1,21 @@
+package pkg
+
+import "golang.org/x/crypto/bcrypt"
+
+// MaxPasswordBytes is the longest password bcrypt accepts, longer ones fail with ErrPasswordTooLong.
+const MaxPasswordBytes = 72
+
+var ErrPasswordTooLong = bcrypt.ErrPasswordTooLong
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+func ComparePassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,182 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// QROptions controls how a QR code is rendered. Size is the width and height in pixels, Margin is
+// the quiet zone in modules and Logo, when set, is drawn in the center of the code.
+type QROptions struct {
+	Size       int
+	Level      qrcode.RecoveryLevel
+	Margin     int
+	Foreground color.RGBA
+	Background color.RGBA
+	Logo       image.Image
+}
+
+// ParseQRLevel converts L, M, Q or H into a recovery level.
+func ParseQRLevel(value string) (qrcode.RecoveryLevel, bool) {
+	switch strings.ToUpper(value) {
+	case "L":
+		return qrcode.Low, true
+	case "M":
+		return qrcode.Medium, true
+	case "Q":
+		return qrcode.High, true
+	case "H":
+		return qrcode.Highest, true
+	default:
+		return 0, false
+	}
+}
+
+// ParseHexColor parses colors written as fff, ffffff or #ffffff.
+func ParseHexColor(value string) (color.RGBA, error) {
+	value = strings.TrimPrefix(value, "#")
+	if len(value) == 3 {
+		value = string([]byte{value[0], value[0], value[1], value[1], value[2], value[2]})
+	}
+	if len(value) != 6 {
+		return color.RGBA{}, errors.New("invalid color")
+	}
+
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return color.RGBA{}, errors.New("invalid color")
+	}
+
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xff}, nil
+}
+
+// GenerateQRPNG renders content as a PNG QR code.
+func GenerateQRPNG(content string, opts QROptions) ([]byte, error) {
+	bitmap, err := qrBitmap(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// every module gets the same number of pixels, the remainder becomes extra quiet zone
+	total := len(bitmap) + 2*opts.Margin
+	scale := max(opts.Size/total, 1)
+	size := max(opts.Size, total*scale)
+	offset := (size-total*scale)/2 + opts.Margin*scale
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+
+	fg := image.NewUniform(opts.Foreground)
+	for y, row := range bitmap {
+		for x, set := range row {
+			if set {
+				rect := image.Rect(offset+x*scale, offset+y*scale, offset+(x+1)*scale, offset+(y+1)*scale)
+				draw.Draw(img, rect, fg, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	if opts.Logo != nil {
+		// the logo covers at most a fifth of the code, which the higher recovery levels can restore
+		logoSize := len(bitmap) * scale / 5
+		area := image.Rect(0, 0, logoSize, logoSize).Add(image.Pt((size-logoSize)/2, (size-logoSize)/2))
+		draw.Draw(img, area.Inset(-scale/2), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		draw.Draw(img, area, scaleImage(opts.Logo, logoSize), image.Point{}, draw.Over)
+	}
+
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GenerateQRSVG renders content as a SVG QR code, drawn as a single path so it stays small.
+func GenerateQRSVG(content string, opts QROptions) ([]byte, error) {
+	bitmap, err := qrBitmap(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(bitmap) + 2*opts.Margin
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, total, total)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, total, total, hexColor(opts.Background))
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, hexColor(opts.Foreground))
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x+1 < len(row) && row[x+1] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start+opts.Margin, y+opts.Margin, x-start+1, x-start+1)
+		}
+	}
+	buf.WriteString(`"/>`)
+
+	if opts.Logo != nil {
+		logoSize := float64(len(bitmap)) / 5
+		pos := (float64(total) - logoSize) / 2
+
+		var logo bytes.Buffer
+		if err = png.Encode(&logo, opts.Logo); err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(&buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`,
+			pos-0.5, pos-0.5, logoSize+1, logoSize+1, hexColor(opts.Background))
+		fmt.Fprintf(&buf, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" href="data:image/png;base64,%s"/>`,
+			pos, pos, logoSize, logoSize, base64.StdEncoding.EncodeToString(logo.Bytes()))
+	}
+
+	buf.WriteString(`</svg>`)
+
+	return buf.Bytes(), nil
+}
+
+func qrBitmap(content string, opts QROptions) ([][]bool, error) {
+	code, err := qrcode.New(content, opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+
+	return code.Bitmap(), nil
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// scaleImage resizes src to a size x size square using nearest neighbour sampling.
+func scaleImage(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	if size == 0 {
+		return dst
+	}
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dst.Set(x, y, src.At(bounds.Min.X+x*bounds.Dx()/size, bounds.Min.Y+y*bounds.Dy()/size))
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+)
+
+func TestParseHexColor(t *testing.T) {
+	for value, want := range map[string]color.RGBA{
+		"000000":  {A: 0xff},
+		"#ff8000": {R: 0xff, G: 0x80, A: 0xff},
+		"fff":     {R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		"#0a0":    {G: 0xaa, A: 0xff},
+	} {
+		got, err := ParseHexColor(value)
+		if err != nil || got != want {
+			t.Errorf("ParseHexColor(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{"", "ff", "ffff", "gggggg", "#1234567"} {
+		if _, err := ParseHexColor(value); err == nil {
+			t.Errorf("ParseHexColor(%q) accepted an invalid color", value)
+		}
+	}
+}
+
+func TestParseQRLevel(t *testing.T) {
+	for value, want := range map[string]qrcode.RecoveryLevel{"L": qrcode.Low, "m": qrcode.Medium, "Q": qrcode.High, "h": qrcode.Highest} {
+		if got, ok := ParseQRLevel(value); !ok || got != want {
+			t.Errorf("ParseQRLevel(%q) = %v, %v, want %v", value, got, ok, want)
+		}
+	}
+	if _, ok := ParseQRLevel("X"); ok {
+		t.Error("ParseQRLevel() accepted X")
+	}
+}
+
+func testQROptions() QROptions {
+	return QROptions{
+		Size:       256,
+		Level:      qrcode.Medium,
+		Margin:     4,
+		Foreground: color.RGBA{A: 0xff},
+		Background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+func TestGenerateQRPNG(t *testing.T) {
+	data, err := GenerateQRPNG("https://example.com/spring", testQROptions())
+	if err != nil {
+		t.Fatalf("GenerateQRPNG() error = %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("GenerateQRPNG() wrote an invalid png: %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 256 || bounds.Dy() != 256 {
+		t.Errorf("GenerateQRPNG() size = %v, want 256x256", bounds)
+	}
+	// the quiet zone keeps the background color
+	if r, g, b, _ := img.At(0, 0).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Errorf("corner pixel = %v, want the background", img.At(0, 0))
+	}
+}
+
+func TestGenerateQRSVG(t *testing.T) {
+	data, err := GenerateQRSVG("https://example.com/spring", testQROptions())
+	if err != nil {
+		t.Fatalf("GenerateQRSVG() error = %v", err)
+	}
+
+	svg := string(data)
+	for _, want := range []string{`width="256"`, `fill="#ffffff"`, `<path fill="#000000" d="M`, `</svg>`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("GenerateQRSVG() misses %s", want)
+		}
+	}
+}
@@ -4,10 +4,22 @@ import (
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
-var validate = validator.New()
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// max counts characters, maxbytes limits the encoded length, e.g. the 72 bytes of bcrypt
+	_ = v.RegisterValidation("maxbytes", func(fl validator.FieldLevel) bool {
+		limit, err := strconv.Atoi(fl.Param())
+		return err == nil && len(fl.Field().String()) <= limit
+	})
+
+	return v
+}
 
 func ValidateRequest(request interface{}) error {
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
@@ -39,6 +51,8 @@ func ValidateRequest(request interface{}) error {
 					report = fmt.Sprintf("%s value must be greater than %s", err.Field(), err.Param())
 				case "lte", "max":
 					report = fmt.Sprintf("%s value must be lower than %s", err.Field(), err.Param())
+				case "maxbytes":
+					report = fmt.Sprintf("%s must not be longer than %s bytes", err.Field(), err.Param())
 				case "url":
 					report = fmt.Sprintf("invalid URL '%s'", err.Value())
 				default:
@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+type passwordRequest struct {
+	Password string `json:"password" validate:"omitempty,min=4,maxbytes=72"`
+}
+
+func TestValidateRequestMaxBytes(t *testing.T) {
+	tests := map[string]struct {
+		password string
+		valid    bool
+	}{
+		"empty":             {"", true},
+		"72 ascii bytes":    {strings.Repeat("a", 72), true},
+		"73 ascii bytes":    {strings.Repeat("a", 73), false},
+		"24 runes 72 bytes": {strings.Repeat("€", 24), true},
+		// only 30 characters, but 90 bytes that bcrypt would refuse
+		"30 runes 90 bytes": {strings.Repeat("€", 30), false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateRequest(&passwordRequest{Password: test.password})
+			if (err == nil) != test.valid {
+				t.Errorf("ValidateRequest() error = %v, want valid %v", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestHashPasswordTooLong(t *testing.T) {
+	if _, err := HashPassword(strings.Repeat("€", 30)); err != ErrPasswordTooLong {
+		t.Errorf("HashPassword() error = %v, want ErrPasswordTooLong", err)
+	}
+}
@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const sessionTokenPrefix = "user"
+
+// SignSessionToken issues a token that identifies the user until it expires.
+func SignSessionToken(secret string, userID int, expires time.Time) string {
+	return SignValue(secret, sessionTokenPrefix+"|"+strconv.Itoa(userID)+"|"+strconv.FormatInt(expires.Unix(), 10))
+}
+
+// VerifySessionToken returns the user of a token produced by SignSessionToken if it is valid and not expired.
+func VerifySessionToken(secret, token string) (int, bool) {
+	value, ok := VerifySignedValue(secret, token)
+	if !ok {
+		return 0, false
+	}
+
+	parts := strings.Split(value, "|")
+	if len(parts) != 3 || parts[0] != sessionTokenPrefix {
+		return 0, false
+	}
+
+	userID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, false
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return 0, false
+	}
+
+	return userID, true
+}
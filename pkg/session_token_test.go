@@ -0,0 +1,31 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionToken(t *testing.T) {
+	token := SignSessionToken("secret", 7, time.Now().Add(time.Hour))
+
+	if userID, ok := VerifySessionToken("secret", token); !ok || userID != 7 {
+		t.Errorf("VerifySessionToken() = %d, %v, want user 7", userID, ok)
+	}
+
+	tests := map[string]struct {
+		secret string
+		token  string
+	}{
+		"other secret": {"other", token},
+		"expired":      {"secret", SignSessionToken("secret", 7, time.Now().Add(-time.Second))},
+		"other value":  {"secret", SignValue("secret", "unlock|7|9999999999")},
+		"forged":       {"secret", "user|1|9999999999"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if userID, ok := VerifySessionToken(test.secret, test.token); ok {
+				t.Errorf("VerifySessionToken() accepted the token of user %d", userID)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package pkg
+
+import "strings"
+
+// linkUnfurlers are the User-Agent fragments of crawlers that fetch a link to build a preview card.
+var linkUnfurlers = []string{
+	"slackbot",
+	"slack-imgproxy",
+	"facebookexternalhit",
+	"facebookcatalog",
+	"twitterbot",
+	"linkedinbot",
+	"discordbot",
+	"telegrambot",
+	"whatsapp",
+	"skypeuripreview",
+	"microsoftpreview",
+	"pinterestbot",
+	"redditbot",
+	"embedly",
+	"mastodon",
+	"iframely",
+	"vkshare",
+	"viber",
+	"line-poker",
+	"google-pagerenderer",
+}
+
+// IsLinkUnfurler reports whether the User-Agent belongs to a chat app or social network crawler
+// that only fetches a link to render its preview.
+func IsLinkUnfurler(userAgent string) bool {
+	return containsAny(strings.ToLower(userAgent), linkUnfurlers)
+}
+
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceOther   = "other"
+)
+
+// DeviceType roughly classifies the device of a User-Agent, anything that isn't a browser on a
+// desktop, phone or tablet is other.
+func DeviceType(userAgent string) string {
+	userAgent = strings.ToLower(userAgent)
+	switch {
+	case userAgent == "":
+		return DeviceOther
+	case strings.Contains(userAgent, "ipad"), strings.Contains(userAgent, "tablet"),
+		strings.Contains(userAgent, "android") && !strings.Contains(userAgent, "mobile"):
+		return DeviceTablet
+	case strings.Contains(userAgent, "mobi"), strings.Contains(userAgent, "iphone"), strings.Contains(userAgent, "ipod"):
+		return DeviceMobile
+	case strings.Contains(userAgent, "windows"), strings.Contains(userAgent, "macintosh"),
+		strings.Contains(userAgent, "x11"), strings.Contains(userAgent, "cros"):
+		return DeviceDesktop
+	default:
+		return DeviceOther
+	}
+}
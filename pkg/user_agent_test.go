@@ -0,0 +1,37 @@
+package pkg
+
+import "testing"
+
+func TestIsLinkUnfurler(t *testing.T) {
+	for userAgent, want := range map[string]bool{
+		"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)":                                                  true,
+		"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)":                                   true,
+		"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)":                                           true,
+		"TelegramBot (like TwitterBot)":                                                                               true,
+		"WhatsApp/2.23.20.0":                                                                                          true,
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36": false,
+		"curl/8.4.0": false,
+		"":           false,
+	} {
+		if got := IsLinkUnfurler(userAgent); got != want {
+			t.Errorf("IsLinkUnfurler(%q) = %v, want %v", userAgent, got, want)
+		}
+	}
+}
+
+func TestDeviceType(t *testing.T) {
+	for userAgent, want := range map[string]string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36":                    DeviceDesktop,
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_1) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15":             DeviceDesktop,
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148":     DeviceMobile,
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36":              DeviceMobile,
+		"Mozilla/5.0 (iPad; CPU OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1": DeviceTablet,
+		"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36":                     DeviceTablet,
+		"curl/8.4.0": DeviceOther,
+		"":           DeviceOther,
+	} {
+		if got := DeviceType(userAgent); got != want {
+			t.Errorf("DeviceType(%q) = %q, want %q", userAgent, got, want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// SignValue appends an HMAC-SHA256 signature to value, separated by a dot.
+func SignValue(secret, value string) string {
+	return value + "." + signature(secret, value)
+}
+
+// VerifySignedValue returns the original value if the signature produced by SignValue is valid.
+func VerifySignedValue(secret, signed string) (string, bool) {
+	idx := strings.LastIndex(signed, ".")
+	if idx < 0 {
+		return "", false
+	}
+
+	value, sig := signed[:idx], signed[idx+1:]
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, value))) {
+		return "", false
+	}
+
+	return value, true
+}
+
+// HashIP pseudonymizes an ip address, the same address always gives the same hash for a secret.
+func HashIP(secret, ip string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ip))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignWebhook signs a webhook payload sent at timestamp, the hex HMAC-SHA256 of "<timestamp>.<body>".
+// Receivers recompute it with the shared secret and reject old timestamps to prevent replays.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func signature(secret, value string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(value))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
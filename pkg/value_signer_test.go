@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignValue(t *testing.T) {
+	signed := SignValue("secret", "abc|1700000000")
+
+	value, ok := VerifySignedValue("secret", signed)
+	if !ok || value != "abc|1700000000" {
+		t.Fatalf("VerifySignedValue() = %q, %v, want the original value", value, ok)
+	}
+}
+
+func TestVerifySignedValueRejectsForgery(t *testing.T) {
+	signed := SignValue("secret", "abc|1700000000")
+
+	tests := map[string]struct {
+		secret string
+		signed string
+	}{
+		"other secret":      {"other", signed},
+		"changed value":     {"secret", "abd" + signed[3:]},
+		"missing signature": {"secret", "abc|1700000000"},
+		"empty":             {"secret", ""},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, ok := VerifySignedValue(test.secret, test.signed); ok {
+				t.Errorf("VerifySignedValue(%q) accepted a forged value", test.signed)
+			}
+		})
+	}
+}
+
+func TestHashIP(t *testing.T) {
+	hash := HashIP("secret", "203.0.113.1")
+
+	if hash != HashIP("secret", "203.0.113.1") {
+		t.Errorf("HashIP() is not stable for the same address")
+	}
+	if hash == HashIP("secret", "203.0.113.2") || hash == HashIP("other", "203.0.113.1") {
+		t.Errorf("HashIP() = %q for another address or secret, want a different hash", hash)
+	}
+	if strings.Contains(hash, "203.0.113") {
+		t.Errorf("HashIP() = %q, want no trace of the address", hash)
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"event":"link.created"}`)
+
+	// the hex HMAC-SHA256 of "1700000000.<body>", as receivers compute it
+	want := "4183334cf814c621c4bd89e061af921be573dd36def10573e13eca9fe9857c31"
+	if got := SignWebhook("secret", 1700000000, body); got != want {
+		t.Errorf("SignWebhook() = %q, want %q", got, want)
+	}
+	if SignWebhook("secret", 1700000001, body) == want {
+		t.Errorf("SignWebhook() ignores the timestamp")
+	}
+	if SignWebhook("other", 1700000000, body) == want {
+		t.Errorf("SignWebhook() ignores the secret")
+	}
+}